@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	apiflag "github.com/dictyBase/aphgrpc"
 	arangoflag "github.com/dictyBase/arangomanager/command/flag"
@@ -39,6 +40,13 @@ func main() {
 			Before: validate.ServerArgs,
 			Flags:  getServerFlags(),
 		},
+		{
+			Name:   "export-audit",
+			Usage:  "exports the audit log of all changes as json lines",
+			Action: server.ExportAudit,
+			Before: validate.ExportAuditArgs,
+			Flags:  getExportAuditFlags(),
+		},
 		{
 			Name:   "load-ontologies",
 			Usage:  "load one or more ontologies in obograph json format",
@@ -59,7 +67,38 @@ func getServerFlags() []cli.Flag {
 			Usage: "tcp port at which the server will be available",
 			Value: "9560",
 		},
+		cli.StringFlag{
+			Name:  "metrics-port",
+			Usage: "tcp port of the http server for prometheus metrics, metrics are disabled when empty",
+		},
+		cli.StringFlag{
+			Name:  "http-port",
+			Usage: "tcp port of the http server for the JSON REST endpoints, they are disabled when empty",
+		},
+		cli.BoolFlag{
+			Name:  "graphql",
+			Usage: "serve the GraphQL read api at /graphql of the http server, needs http-port",
+		},
+		cli.DurationFlag{
+			Name:  "metrics-count-interval",
+			Usage: "interval for counting annotations and groups per ontology",
+			Value: time.Minute,
+		},
+		cli.DurationFlag{
+			Name:  "shutdown-timeout",
+			Usage: "time given to in-flight requests to finish on shutdown",
+			Value: 30 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "health-timeout",
+			Usage: "timeout for checking arangodb and nats in readiness probes",
+			Value: 5 * time.Second,
+		},
 	}
+	flg = append(flg, authFlags()...)
+	flg = append(flg, limitFlags()...)
+	flg = append(flg, tlsFlags()...)
+	flg = append(flg, tracingFlags()...)
 	flg = append(flg, annoCollFlags()...)
 	flg = append(flg, ontoCollFlags()...)
 	flg = append(flg, arangoflag.ArangoFlags()...)
@@ -75,6 +114,146 @@ func getServerFlags() []cli.Flag {
 	return append(flg, apiflag.NatsFlag()...)
 }
 
+func limitFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.Float64Flag{
+			Name:  "rate-limit-ip",
+			Usage: "requests per second allowed from a client address, zero turns it off",
+		},
+		cli.Float64Flag{
+			Name:  "rate-limit-identity",
+			Usage: "requests per second allowed for an authenticated caller, zero turns it off",
+		},
+		cli.IntFlag{
+			Name:  "rate-limit-burst",
+			Usage: "requests allowed at once above the rate limits",
+			Value: 20,
+		},
+		cli.Int64Flag{
+			Name:  "max-page-size",
+			Usage: "largest limit of annotation and group lists, zero allows any",
+			Value: 500,
+		},
+		cli.Int64Flag{
+			Name:  "max-upload-size",
+			Usage: "most bytes of an uploaded ontology, zero allows any",
+			Value: 256 << 20,
+		},
+	}
+}
+
+func authFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "jwks-file",
+			EnvVar: "JWKS_FILE",
+			Usage:  "json web key set file for verifying bearer tokens, enables authentication",
+		},
+		cli.StringFlag{
+			Name:   "jwt-issuer",
+			EnvVar: "JWT_ISSUER",
+			Usage:  "expected issuer of bearer tokens",
+		},
+		cli.StringFlag{
+			Name:   "jwt-audience",
+			EnvVar: "JWT_AUDIENCE",
+			Usage:  "expected audience of bearer tokens",
+		},
+		cli.StringFlag{
+			Name:   "api-keys-file",
+			EnvVar: "API_KEYS_FILE",
+			Usage:  "json file of static api keys with their subject and role, enables authentication",
+		},
+	}
+}
+
+func tlsFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "tls-cert",
+			EnvVar: "TLS_CERT",
+			Usage:  "server certificate file in pem format, enables TLS",
+		},
+		cli.StringFlag{
+			Name:   "tls-key",
+			EnvVar: "TLS_KEY",
+			Usage:  "private key file of the server certificate in pem format",
+		},
+		cli.StringFlag{
+			Name:   "tls-client-ca",
+			EnvVar: "TLS_CLIENT_CA",
+			Usage:  "ca certificates file in pem format for verifying client certificates, enables mutual TLS",
+		},
+		cli.DurationFlag{
+			Name:  "tls-reload-interval",
+			Usage: "interval for checking the certificate files for changes",
+			Value: time.Minute,
+		},
+		cli.StringFlag{
+			Name:  "client-cert-role",
+			Usage: "role of client certificates whose organizational units name no role, either of reader, curator or admin",
+			Value: "reader",
+		},
+	}
+}
+
+func tracingFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "tracing-exporter",
+			EnvVar: "TRACING_EXPORTER",
+			Usage:  "exporter of opentelemetry spans, either of none, stdout or otlp",
+			Value:  "none",
+		},
+		cli.StringFlag{
+			Name:   "otlp-endpoint",
+			EnvVar: "OTLP_ENDPOINT",
+			Usage:  "host:port of the otlp grpc collector, defaults to the standard OTEL_EXPORTER_OTLP environment variables",
+		},
+		cli.BoolFlag{
+			Name:   "otlp-insecure",
+			EnvVar: "OTLP_INSECURE",
+			Usage:  "connect to the otlp collector without TLS",
+		},
+	}
+}
+
+func getExportAuditFlags() []cli.Flag {
+	flg := []cli.Flag{
+		cli.StringFlag{
+			Name:  "actor",
+			Usage: "export only the changes made by this curator",
+		},
+		cli.StringFlag{
+			Name:  "entry-id",
+			Usage: "export only the changes of annotations of this entry",
+		},
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "export only the changes made at or after this RFC3339 time",
+		},
+		cli.StringFlag{
+			Name:  "until",
+			Usage: "export only the changes made at or before this RFC3339 time",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "output file, - for standard output",
+			Value: "-",
+		},
+	}
+	flg = append(flg, annoCollFlags()...)
+	flg = append(flg, ontoCollFlags()...)
+	flg = append(flg, arangoflag.ArangoFlags()...)
+
+	return append(flg, cli.StringFlag{
+		Name:   "arangodb-database, db",
+		EnvVar: "ARANGODB_DATABASE",
+		Usage:  "arangodb database name",
+		Value:  "annotation",
+	})
+}
+
 func ontoCollFlags() []cli.Flag {
 	return []cli.Flag{
 		cli.StringFlag{
@@ -127,6 +306,21 @@ func annoCollFlags() []cli.Flag {
 			Usage: "arangodb collection for storing annotation group",
 			Value: "annotation_group",
 		},
+		cli.StringFlag{
+			Name:  "annogrouptype-collection",
+			Usage: "arangodb collection for storing types of annotation group",
+			Value: "annotation_group_type",
+		},
+		cli.StringFlag{
+			Name:  "annogroupversion-collection",
+			Usage: "arangodb collection for storing membership history of annotation group",
+			Value: "annotation_group_version",
+		},
+		cli.StringFlag{
+			Name:  "annoaudit-collection",
+			Usage: "arangodb collection for storing the audit log of all changes",
+			Value: "annotation_audit",
+		},
 		cli.StringFlag{
 			Name:  "annoterm-graph",
 			Usage: "arangodb named graph for managing relations between annotation and ontology term",
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// apiKeyHeader is the metadata key carrying a static api key.
+const apiKeyHeader = "x-api-key"
+
+type apiKeyEntry struct {
+	Key     string `json:"key"`
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+// APIKeyAuthenticator accepts the static api keys listed in a file.
+type APIKeyAuthenticator struct {
+	// keys are indexed by the sha256 sum of the api key
+	keys map[[sha256.Size]byte]*Identity
+}
+
+// NewAPIKeyAuthenticator reads the api keys from a json file holding a
+// list of objects with key, subject and role fields.
+func NewAPIKeyAuthenticator(file string) (*APIKeyAuthenticator, error) {
+	cnt, err := os.ReadFile(file)
+	if err != nil {
+		return &APIKeyAuthenticator{}, fmt.Errorf("error in reading api key file %s", err)
+	}
+	var entries []*apiKeyEntry
+	if err := json.Unmarshal(cnt, &entries); err != nil {
+		return &APIKeyAuthenticator{}, fmt.Errorf("error in decoding api key file %s", err)
+	}
+	keys := make(map[[sha256.Size]byte]*Identity)
+	for _, ent := range entries {
+		if len(ent.Key) == 0 || len(ent.Subject) == 0 {
+			return &APIKeyAuthenticator{}, fmt.Errorf("api key without key or subject")
+		}
+		role, err := ParseRole(ent.Role)
+		if err != nil {
+			return &APIKeyAuthenticator{}, fmt.Errorf(
+				"error in api key of %s %s", ent.Subject, err,
+			)
+		}
+		keys[sha256.Sum256([]byte(ent.Key))] = &Identity{
+			Subject: ent.Subject,
+			Role:    role,
+		}
+	}
+
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+// Authenticate looks up the api key of the x-api-key header.
+func (aa *APIKeyAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	vals := metadata.ValueFromIncomingContext(ctx, apiKeyHeader)
+	if len(vals) == 0 || len(vals[0]) == 0 {
+		return &Identity{}, ErrNoCredential
+	}
+	idn, ok := aa.keys[sha256.Sum256([]byte(vals[0]))]
+	if !ok {
+		return &Identity{}, fmt.Errorf("unknown api key")
+	}
+
+	return &Identity{Subject: idn.Subject, Role: idn.Role}, nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func writeKeyFile(t *testing.T, cnt string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(file, []byte(cnt), 0o600), "expect no error from writing the key file")
+
+	return file
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+	athr, err := NewAPIKeyAuthenticator(writeKeyFile(t, `[
+		{"key": "s3cret", "subject": "loader", "role": "curator"},
+		{"key": "t0ken", "subject": "ops", "role": "Admin"}
+	]`))
+	require.NoError(t, err, "expect no error from reading the key file")
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		noCred  bool
+		wantErr bool
+		subject string
+		role    Role
+	}{
+		{name: "no header", md: metadata.MD{}, noCred: true},
+		{name: "empty key", md: metadata.Pairs(apiKeyHeader, ""), noCred: true},
+		{name: "unknown key", md: metadata.Pairs(apiKeyHeader, "guess"), wantErr: true},
+		{
+			name:    "curator key",
+			md:      metadata.Pairs(apiKeyHeader, "s3cret"),
+			subject: "loader",
+			role:    RoleCurator,
+		},
+		{
+			name:    "role name in any case",
+			md:      metadata.Pairs(apiKeyHeader, "t0ken"),
+			subject: "ops",
+			role:    RoleAdmin,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			idn, err := athr.Authenticate(metadata.NewIncomingContext(context.Background(), tst.md))
+			switch {
+			case tst.noCred:
+				assert.ErrorIs(err, ErrNoCredential, "should find no credential")
+			case tst.wantErr:
+				assert.Error(err, "expect error from an unknown key")
+				assert.NotErrorIs(err, ErrNoCredential, "should not skip an unknown key")
+			default:
+				assert.NoError(err, "expect no error from a known key")
+				assert.Equal(tst.subject, idn.Subject, "should match the subject")
+				assert.Equal(tst.role, idn.Role, "should match the role")
+			}
+		})
+	}
+}
+
+func TestNewAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		cnt  string
+	}{
+		{name: "not json", cnt: "key=s3cret"},
+		{name: "no subject", cnt: `[{"key": "s3cret", "role": "reader"}]`},
+		{name: "no key", cnt: `[{"subject": "loader", "role": "reader"}]`},
+		{name: "unknown role", cnt: `[{"key": "s3cret", "subject": "loader", "role": "owner"}]`},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewAPIKeyAuthenticator(writeKeyFile(t, tst.cnt))
+			require.Error(t, err, "expect error from an invalid key file")
+		})
+	}
+}
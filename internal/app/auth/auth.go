@@ -0,0 +1,93 @@
+// Package auth authenticates the callers of the grpc server and authorizes
+// them by role for every rpc.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Role is the level of access of a caller, every role includes the access
+// of the roles below it.
+type Role int
+
+const (
+	// RoleReader can only read annotations, groups and tags
+	RoleReader Role = iota + 1
+	// RoleCurator can create, edit and obsolete annotations and groups
+	RoleCurator
+	// RoleAdmin can also purge annotations and upload ontologies
+	RoleAdmin
+)
+
+// ErrNoCredential is returned by an Authenticator when the request does not
+// carry the credential it handles.
+var ErrNoCredential = errors.New("no credential in request")
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "reader"
+	case RoleCurator:
+		return "curator"
+	case RoleAdmin:
+		return "admin"
+	}
+
+	return "unknown"
+}
+
+// ParseRole converts the name of a role to Role.
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(name) {
+	case "reader":
+		return RoleReader, nil
+	case "curator":
+		return RoleCurator, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+
+	return 0, fmt.Errorf("unknown role %s", name)
+}
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	// Subject identifies the caller, it is used as the curator of the
+	// changes
+	Subject string
+	Role    Role
+}
+
+// Authenticator verifies the credential of a request and returns the
+// identity of its caller. ErrNoCredential is returned when the request
+// carries no credential of its kind.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of the context that carries the identity.
+func NewContext(ctx context.Context, idn *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, idn)
+}
+
+// FromContext returns the identity attached to the context, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	idn, ok := ctx.Value(identityKey{}).(*Identity)
+
+	return idn, ok
+}
+
+// Actor returns the subject of the authenticated caller, or the claimed
+// curator when the request is not authenticated.
+func Actor(ctx context.Context, claimed string) string {
+	if idn, ok := FromContext(ctx); ok {
+		return idn.Subject
+	}
+
+	return claimed
+}
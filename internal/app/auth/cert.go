@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// CertAuthenticator identifies callers by their verified client
+// certificate of a mutual TLS connection.
+type CertAuthenticator struct {
+	role Role
+}
+
+// NewCertAuthenticator returns a CertAuthenticator giving the default role
+// to certificates whose subject names no role.
+func NewCertAuthenticator(defaultRole Role) *CertAuthenticator {
+	return &CertAuthenticator{role: defaultRole}
+}
+
+// Authenticate maps the subject of the client certificate to an identity.
+// The subject is the first email address of the certificate, or its common
+// name when it has none, and the role is the highest role named by the
+// organizational units of the subject.
+func (ca *CertAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	prr, ok := peer.FromContext(ctx)
+	if !ok {
+		return &Identity{}, ErrNoCredential
+	}
+	tlsInfo, ok := prr.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return &Identity{}, ErrNoCredential
+	}
+	chains := tlsInfo.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return &Identity{}, ErrNoCredential
+	}
+
+	return ca.identity(chains[0][0])
+}
+
+func (ca *CertAuthenticator) identity(cert *x509.Certificate) (*Identity, error) {
+	idn := &Identity{Subject: cert.Subject.CommonName}
+	if len(cert.EmailAddresses) > 0 {
+		idn.Subject = cert.EmailAddresses[0]
+	}
+	if len(idn.Subject) == 0 {
+		return &Identity{}, fmt.Errorf("client certificate has no subject")
+	}
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		role, err := ParseRole(unit)
+		if err != nil {
+			continue
+		}
+		if role > idn.Role {
+			idn.Role = role
+		}
+	}
+	if idn.Role == 0 {
+		idn.Role = ca.role
+	}
+
+	return idn, nil
+}
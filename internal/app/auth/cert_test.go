@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func peerContext(chains ...[]*x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{},
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: chains},
+		},
+	})
+}
+
+func TestCertAuthenticator(t *testing.T) {
+	t.Parallel()
+	athr := NewCertAuthenticator(RoleReader)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		noCred  bool
+		wantErr bool
+		subject string
+		role    Role
+	}{
+		{name: "no peer", ctx: context.Background(), noCred: true},
+		{
+			name:   "plain connection",
+			ctx:    peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{}}),
+			noCred: true,
+		},
+		{name: "no verified chain", ctx: peerContext(), noCred: true},
+		{
+			name: "email over common name",
+			ctx: peerContext([]*x509.Certificate{{
+				Subject:        pkix.Name{CommonName: "loader", OrganizationalUnit: []string{"curator"}},
+				EmailAddresses: []string{"loader@dictybase.org"},
+			}}),
+			subject: "loader@dictybase.org",
+			role:    RoleCurator,
+		},
+		{
+			name: "highest role of the units",
+			ctx: peerContext([]*x509.Certificate{{
+				Subject: pkix.Name{
+					CommonName:         "ops",
+					OrganizationalUnit: []string{"admin", "infrastructure", "reader"},
+				},
+			}}),
+			subject: "ops",
+			role:    RoleAdmin,
+		},
+		{
+			name: "default role",
+			ctx: peerContext([]*x509.Certificate{{
+				Subject: pkix.Name{CommonName: "browser", OrganizationalUnit: []string{"frontend"}},
+			}}),
+			subject: "browser",
+			role:    RoleReader,
+		},
+		{
+			name:    "no subject",
+			ctx:     peerContext([]*x509.Certificate{{Subject: pkix.Name{}}}),
+			wantErr: true,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			idn, err := athr.Authenticate(tst.ctx)
+			switch {
+			case tst.noCred:
+				assert.ErrorIs(err, ErrNoCredential, "should find no credential")
+			case tst.wantErr:
+				assert.Error(err, "expect error from a certificate without subject")
+				assert.NotErrorIs(err, ErrNoCredential, "should not skip the certificate")
+			default:
+				assert.NoError(err, "expect no error from a verified certificate")
+				assert.Equal(tst.subject, idn.Subject, "should match the subject")
+				assert.Equal(tst.role, idn.Role, "should match the role")
+			}
+		})
+	}
+}
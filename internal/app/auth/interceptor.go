@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const annoService = "/dictybase.annotation.TaggedAnnotationService/"
+
+// GraphQLMethod is the method name the queries of the GraphQL api are
+// authorized as, it only reads.
+const GraphQLMethod = "/dictybase.annotation.GraphQL/Query"
+
+// methodRoles is the least role needed for each rpc of the annotation
+// service, and for each of its operations served by the http gateway only.
+// Any other method needs RoleAdmin.
+var methodRoles = map[string]Role{
+	annoService + "GetAnnotation":               RoleReader,
+	annoService + "GetEntryAnnotation":          RoleReader,
+	annoService + "GetEntryProfile":             RoleReader,
+	annoService + "ListAnnotations":             RoleReader,
+	annoService + "GetAnnotationGroup":          RoleReader,
+	annoService + "ListAnnotationGroups":        RoleReader,
+	annoService + "GetAnnotationTag":            RoleReader,
+	annoService + "GetGroupType":                RoleReader,
+	annoService + "ListAnnotationGroupVersions": RoleReader,
+	annoService + "ListGroupsByAnnotation":      RoleReader,
+	annoService + "ListGroupsByEntry":           RoleReader,
+	annoService + "CreateAnnotation":            RoleCurator,
+	annoService + "UpdateAnnotation":            RoleCurator,
+	annoService + "DeleteAnnotation":            RoleCurator,
+	annoService + "CreateAnnotationGroup":       RoleCurator,
+	annoService + "AddToAnnotationGroup":        RoleCurator,
+	annoService + "DeleteAnnotationGroup":       RoleCurator,
+	annoService + "CreateTypedAnnotationGroup":  RoleCurator,
+	annoService + "EditAnnotationGroup":         RoleCurator,
+	annoService + "ReorderAnnotationGroup":      RoleCurator,
+	annoService + "MoveAnnotationGroupMember":   RoleCurator,
+	annoService + "ApplyBatch":                  RoleCurator,
+	annoService + "RestoreAnnotation":           RoleCurator,
+	annoService + "BulkUpdateAnnotations":       RoleCurator,
+	annoService + "BulkObsoleteAnnotations":     RoleCurator,
+	annoService + "ListObsoleteAnnotations":     RoleCurator,
+	annoService + "SetGroupType":                RoleAdmin,
+	annoService + "ListAuditEntries":            RoleAdmin,
+	annoService + "OboJSONFileUpload":           RoleAdmin,
+	GraphQLMethod:                               RoleReader,
+}
+
+// publicPrefixes are the services open to unauthenticated callers.
+var publicPrefixes = []string{
+	"/grpc.reflection.",
+	"/grpc.health.v1.",
+}
+
+// Interceptor authenticates every request with the first authenticator
+// that finds a credential in it and checks the role of the caller.
+type Interceptor struct {
+	authenticators []Authenticator
+}
+
+// NewInterceptor returns an Interceptor trying the authenticators in order.
+func NewInterceptor(authenticators ...Authenticator) *Interceptor {
+	return &Interceptor{authenticators: authenticators}
+}
+
+// Unary returns the interceptor for unary rpcs.
+func (in *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if IsPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		idn, err := in.authorize(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(NewContext(ctx, idn), req)
+	}
+}
+
+// Stream returns the interceptor for streaming rpcs.
+func (in *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if IsPublic(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		idn, err := in.authorize(stream.Context(), info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = NewContext(stream.Context(), idn)
+
+		return handler(srv, wrapped)
+	}
+}
+
+func (in *Interceptor) authorize(
+	ctx context.Context,
+	method string,
+	req interface{},
+) (*Identity, error) {
+	idn, err := in.authenticate(ctx)
+	if err != nil {
+		return idn, err
+	}
+	if need := requiredRole(method, req); idn.Role < need {
+		return idn, status.Errorf(
+			codes.PermissionDenied,
+			"%s needs the %s role", method, need,
+		)
+	}
+
+	return idn, nil
+}
+
+func (in *Interceptor) authenticate(ctx context.Context) (*Identity, error) {
+	for _, athr := range in.authenticators {
+		idn, err := athr.Authenticate(ctx)
+		if errors.Is(err, ErrNoCredential) {
+			continue
+		}
+		if err != nil {
+			return idn, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return idn, nil
+	}
+
+	return &Identity{}, status.Error(codes.Unauthenticated, "no credential in request")
+}
+
+// requiredRole is the least role for a method, purging an annotation needs
+// RoleAdmin.
+func requiredRole(method string, req interface{}) Role {
+	if dar, ok := req.(*annotation.DeleteAnnotationRequest); ok && dar.Purge {
+		return RoleAdmin
+	}
+	if role, ok := methodRoles[method]; ok {
+		return role
+	}
+
+	return RoleAdmin
+}
+
+// IsPublic tells whether a method is open to unauthenticated callers.
+func IsPublic(method string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// staticAuthenticator returns the same identity, or error, for every
+// request.
+type staticAuthenticator struct {
+	idn *Identity
+	err error
+}
+
+func (sa *staticAuthenticator) Authenticate(_ context.Context) (*Identity, error) {
+	if sa.err != nil {
+		return &Identity{}, sa.err
+	}
+
+	return sa.idn, nil
+}
+
+func TestRequiredRole(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		req    interface{}
+		role   Role
+	}{
+		{
+			name:   "read",
+			method: annoService + "GetAnnotation",
+			req:    &annotation.AnnotationId{Id: "4589"},
+			role:   RoleReader,
+		},
+		{
+			name:   "graphql query",
+			method: GraphQLMethod,
+			role:   RoleReader,
+		},
+		{
+			name:   "gateway only read",
+			method: annoService + "GetEntryProfile",
+			role:   RoleReader,
+		},
+		{
+			name:   "create",
+			method: annoService + "CreateAnnotation",
+			req:    &annotation.NewTaggedAnnotation{},
+			role:   RoleCurator,
+		},
+		{
+			name:   "obsolete",
+			method: annoService + "DeleteAnnotation",
+			req:    &annotation.DeleteAnnotationRequest{Id: "4589"},
+			role:   RoleCurator,
+		},
+		{
+			name:   "purge",
+			method: annoService + "DeleteAnnotation",
+			req:    &annotation.DeleteAnnotationRequest{Id: "4589", Purge: true},
+			role:   RoleAdmin,
+		},
+		{
+			name:   "gateway only change",
+			method: annoService + "BulkObsoleteAnnotations",
+			role:   RoleCurator,
+		},
+		{
+			name:   "obsolete listing",
+			method: annoService + "ListObsoleteAnnotations",
+			role:   RoleCurator,
+		},
+		{
+			name:   "upload",
+			method: annoService + "OboJSONFileUpload",
+			role:   RoleAdmin,
+		},
+		{
+			name:   "audit log",
+			method: annoService + "ListAuditEntries",
+			role:   RoleAdmin,
+		},
+		{
+			name:   "unknown method",
+			method: "/dictybase.annotation.Other/Method",
+			role:   RoleAdmin,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tst.role, requiredRole(tst.method, tst.req), "should match the role")
+		})
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+	purge := &annotation.DeleteAnnotationRequest{Id: "4589", Purge: true}
+	tests := []struct {
+		name   string
+		athrs  []Authenticator
+		method string
+		req    interface{}
+		code   codes.Code
+		actor  string
+	}{
+		{
+			name:   "public method without credential",
+			athrs:  []Authenticator{&staticAuthenticator{err: ErrNoCredential}},
+			method: "/grpc.health.v1.Health/Check",
+			code:   codes.OK,
+		},
+		{
+			name:   "no credential",
+			athrs:  []Authenticator{&staticAuthenticator{err: ErrNoCredential}},
+			method: annoService + "GetAnnotation",
+			code:   codes.Unauthenticated,
+		},
+		{
+			name:   "invalid credential",
+			athrs:  []Authenticator{&staticAuthenticator{err: errors.New("expired")}},
+			method: annoService + "GetAnnotation",
+			code:   codes.Unauthenticated,
+		},
+		{
+			name: "next authenticator",
+			athrs: []Authenticator{
+				&staticAuthenticator{err: ErrNoCredential},
+				&staticAuthenticator{idn: &Identity{Subject: "reader@dictybase.org", Role: RoleReader}},
+			},
+			method: annoService + "GetAnnotation",
+			code:   codes.OK,
+			actor:  "reader@dictybase.org",
+		},
+		{
+			name: "role too low",
+			athrs: []Authenticator{
+				&staticAuthenticator{idn: &Identity{Subject: "reader@dictybase.org", Role: RoleReader}},
+			},
+			method: annoService + "CreateAnnotation",
+			code:   codes.PermissionDenied,
+		},
+		{
+			name: "curator purging",
+			athrs: []Authenticator{
+				&staticAuthenticator{idn: &Identity{Subject: "pfey@gmail.com", Role: RoleCurator}},
+			},
+			method: annoService + "DeleteAnnotation",
+			req:    purge,
+			code:   codes.PermissionDenied,
+		},
+		{
+			name: "admin purging",
+			athrs: []Authenticator{
+				&staticAuthenticator{idn: &Identity{Subject: "admin@dictybase.org", Role: RoleAdmin}},
+			},
+			method: annoService + "DeleteAnnotation",
+			req:    purge,
+			code:   codes.OK,
+			actor:  "admin@dictybase.org",
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			var actor string
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				actor = Actor(ctx, "")
+
+				return "done", nil
+			}
+			_, err := NewInterceptor(tst.athrs...).Unary()(
+				context.Background(),
+				tst.req,
+				&grpc.UnaryServerInfo{FullMethod: tst.method},
+				handler,
+			)
+			assert.Equal(tst.code, status.Code(err), "should match the status code")
+			assert.Equal(tst.actor, actor, "should pass the identity to the handler")
+		})
+	}
+}
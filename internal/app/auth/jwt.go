@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+)
+
+// jwtClaims are the claims read from a bearer token.
+type jwtClaims struct {
+	jwt.Claims
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+// JWTAuthenticator verifies bearer tokens signed by any key of a local
+// JSON web key set.
+type JWTAuthenticator struct {
+	keys     *jose.JSONWebKeySet
+	issuer   string
+	audience string
+}
+
+// NewJWTAuthenticator reads the JSON web key set from a file. Tokens are
+// expected to have the given issuer and audience unless they are empty.
+func NewJWTAuthenticator(
+	jwksFile, issuer, audience string,
+) (*JWTAuthenticator, error) {
+	cnt, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return &JWTAuthenticator{}, fmt.Errorf("error in reading jwks file %s", err)
+	}
+	keys := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(cnt, keys); err != nil {
+		return &JWTAuthenticator{}, fmt.Errorf("error in decoding jwks file %s", err)
+	}
+	if len(keys.Keys) == 0 {
+		return &JWTAuthenticator{}, fmt.Errorf("no key in jwks file %s", jwksFile)
+	}
+
+	return &JWTAuthenticator{keys: keys, issuer: issuer, audience: audience}, nil
+}
+
+// Authenticate verifies the bearer token of the authorization header. The
+// subject is the email claim, or the sub claim when there is no email, and
+// the role is the highest of the roles claim.
+func (ja *JWTAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	raw, err := grpc_auth.AuthFromMD(ctx, "bearer")
+	if err != nil {
+		return &Identity{}, ErrNoCredential
+	}
+	tok, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return &Identity{}, fmt.Errorf("error in parsing token %s", err)
+	}
+	claims := &jwtClaims{}
+	if err := tok.Claims(ja.keys, claims); err != nil {
+		return &Identity{}, fmt.Errorf("error in verifying token %s", err)
+	}
+	expected := jwt.Expected{Issuer: ja.issuer, Time: time.Now()}
+	if len(ja.audience) > 0 {
+		expected.Audience = jwt.Audience{ja.audience}
+	}
+	if err := claims.Validate(expected); err != nil {
+		return &Identity{}, fmt.Errorf("error in validating token %s", err)
+	}
+	idn := &Identity{Subject: claims.Email}
+	if len(idn.Subject) == 0 {
+		idn.Subject = claims.Subject
+	}
+	if len(idn.Subject) == 0 {
+		return &Identity{}, fmt.Errorf("token has no subject")
+	}
+	for _, name := range claims.Roles {
+		role, err := ParseRole(name)
+		if err != nil {
+			continue
+		}
+		if role > idn.Role {
+			idn.Role = role
+		}
+	}
+
+	return idn, nil
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testIssuer   = "https://auth.dictybase.org"
+	testAudience = "annotation"
+)
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "expect no error from generating a key")
+
+	return key
+}
+
+// writeJWKS writes the public keys, by key id, as a json web key set.
+func writeJWKS(t *testing.T, keys map[string]*ecdsa.PrivateKey) string {
+	t.Helper()
+	jwks := &jose.JSONWebKeySet{}
+	for kid, key := range keys {
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{
+			Key:       &key.PublicKey,
+			KeyID:     kid,
+			Algorithm: string(jose.ES256),
+			Use:       "sig",
+		})
+	}
+	cnt, err := json.Marshal(jwks)
+	require.NoError(t, err, "expect no error from encoding the key set")
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(file, cnt, 0o600), "expect no error from writing the key set")
+
+	return file
+}
+
+func signToken(t *testing.T, kid string, key *ecdsa.PrivateKey, claims interface{}) string {
+	t.Helper()
+	sig, err := jose.NewSigner(
+		jose.SigningKey{
+			Algorithm: jose.ES256,
+			Key:       jose.JSONWebKey{Key: key, KeyID: kid},
+		},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	require.NoError(t, err, "expect no error from creating the signer")
+	raw, err := jwt.Signed(sig).Claims(claims).CompactSerialize()
+	require.NoError(t, err, "expect no error from signing the token")
+
+	return raw
+}
+
+func bearerContext(raw string) context.Context {
+	return metadata.NewIncomingContext(
+		context.Background(),
+		metadata.Pairs("authorization", "Bearer "+raw),
+	)
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	known, other := newTestKey(t), newTestKey(t)
+	file := writeJWKS(t, map[string]*ecdsa.PrivateKey{"k1": known, "k2": newTestKey(t)})
+	athr, err := NewJWTAuthenticator(file, testIssuer, testAudience)
+	require.NoError(t, err, "expect no error from reading the key set")
+	now := time.Now()
+	valid := jwt.Claims{
+		Issuer:   testIssuer,
+		Subject:  "sub-42",
+		Audience: jwt.Audience{testAudience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+	withClaims := func(fn func(*jwt.Claims)) jwt.Claims {
+		cls := valid
+		fn(&cls)
+
+		return cls
+	}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		noCred  bool
+		wantErr bool
+		subject string
+		role    Role
+	}{
+		{
+			name:   "no token",
+			ctx:    context.Background(),
+			noCred: true,
+		},
+		{
+			name: "email and highest role",
+			ctx: bearerContext(signToken(t, "k1", known, &jwtClaims{
+				Claims: valid,
+				Email:  "pfey@gmail.com",
+				Roles:  []string{"reader", "unknown", "curator"},
+			})),
+			subject: "pfey@gmail.com",
+			role:    RoleCurator,
+		},
+		{
+			name:    "sub without email",
+			ctx:     bearerContext(signToken(t, "k1", known, &jwtClaims{Claims: valid})),
+			subject: "sub-42",
+		},
+		{
+			name: "expired",
+			ctx: bearerContext(signToken(t, "k1", known, &jwtClaims{
+				Claims: withClaims(func(cls *jwt.Claims) {
+					cls.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+				}),
+			})),
+			wantErr: true,
+		},
+		{
+			name: "other audience",
+			ctx: bearerContext(signToken(t, "k1", known, &jwtClaims{
+				Claims: withClaims(func(cls *jwt.Claims) {
+					cls.Audience = jwt.Audience{"stock"}
+				}),
+			})),
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			ctx: bearerContext(signToken(t, "k1", known, &jwtClaims{
+				Claims: withClaims(func(cls *jwt.Claims) {
+					cls.Issuer = "https://example.org"
+				}),
+			})),
+			wantErr: true,
+		},
+		{
+			name:    "key id not in the set",
+			ctx:     bearerContext(signToken(t, "k3", known, &jwtClaims{Claims: valid})),
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key",
+			ctx:     bearerContext(signToken(t, "k1", other, &jwtClaims{Claims: valid})),
+			wantErr: true,
+		},
+		{
+			name:    "malformed token",
+			ctx:     bearerContext("not.a.token"),
+			wantErr: true,
+		},
+		{
+			name: "no subject",
+			ctx: bearerContext(signToken(t, "k1", known, &jwtClaims{
+				Claims: withClaims(func(cls *jwt.Claims) { cls.Subject = "" }),
+			})),
+			wantErr: true,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			idn, err := athr.Authenticate(tst.ctx)
+			switch {
+			case tst.noCred:
+				assert.ErrorIs(err, ErrNoCredential, "should find no credential")
+			case tst.wantErr:
+				assert.Error(err, "expect error from an invalid token")
+				assert.NotErrorIs(err, ErrNoCredential, "should not skip an invalid token")
+			default:
+				assert.NoError(err, "expect no error from a valid token")
+				assert.Equal(tst.subject, idn.Subject, "should match the subject")
+				assert.Equal(tst.role, idn.Role, "should match the role")
+			}
+		})
+	}
+}
+
+func TestNewJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	_, err := NewJWTAuthenticator(filepath.Join(t.TempDir(), "missing.json"), "", "")
+	assert.Error(err, "expect error from a missing file")
+	empty := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(os.WriteFile(empty, []byte(`{"keys": []}`), 0o600))
+	_, err = NewJWTAuthenticator(empty, "", "")
+	assert.Error(err, "expect error from a key set without keys")
+}
@@ -0,0 +1,320 @@
+// Package gateway serves the operations of the tagged annotation service as
+// JSON:API styled REST endpoints. Every request runs the generated grpc
+// handler of its rpc behind the interceptors of the grpc server, so
+// authentication, logging and metrics behave the same for both transports.
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Interceptors are the interceptors of the grpc server, they are run in the
+// given order around every request.
+type Interceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// Invoke runs a handler behind the unary interceptors as the given method,
+// for http endpoints that are not rpcs of the service.
+func (icp *Interceptors) Invoke(
+	ctx context.Context,
+	method string,
+	req interface{},
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return chainUnary(icp.Unary)(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+}
+
+// Service is the annotation service along with its operations that have no
+// rpc in the protocol buffer definition, those are only served over http.
+type Service interface {
+	annotation.TaggedAnnotationServiceServer
+	GetEntryProfile(ctx context.Context, entryID string) (*model.EntryProfile, error)
+	ListGroupsByAnnotation(
+		ctx context.Context,
+		req *annotation.AnnotationId,
+	) (*annotation.TaggedAnnotationGroupCollection, error)
+	ListGroupsByEntry(
+		ctx context.Context,
+		entryID string,
+	) (*annotation.TaggedAnnotationGroupCollection, error)
+	CreateTypedAnnotationGroup(
+		ctx context.Context,
+		params *model.GroupParams,
+		ids ...string,
+	) (*model.AnnoGroup, error)
+	EditAnnotationGroup(
+		ctx context.Context,
+		groupID, name, description, updatedBy string,
+	) (*model.AnnoGroup, error)
+	ReorderAnnotationGroup(
+		ctx context.Context,
+		groupID string,
+		ids ...string,
+	) (*annotation.TaggedAnnotationGroup, error)
+	MoveAnnotationGroupMember(
+		ctx context.Context,
+		groupID, annoID string,
+		position int64,
+	) (*annotation.TaggedAnnotationGroup, error)
+	ListAnnotationGroupVersions(
+		ctx context.Context,
+		rid *annotation.GroupEntryId,
+	) ([]*model.GroupVersion, error)
+	SetGroupType(ctx context.Context, gtp *model.GroupType) (*model.GroupType, error)
+	ApplyBatch(ctx context.Context, ops []*model.BatchOperation) ([]*model.BatchResult, error)
+	BulkUpdateAnnotations(
+		ctx context.Context,
+		filter string,
+		edit *model.BulkEdit,
+		dryRun bool,
+	) (*model.BulkResult, error)
+	BulkObsoleteAnnotations(
+		ctx context.Context,
+		filter string,
+		info *model.DeleteInfo,
+		dryRun bool,
+	) (*model.BulkResult, error)
+	GetGroupType(ctx context.Context, ontology, tag string) (*model.GroupType, error)
+	RestoreAnnotation(ctx context.Context, id, restoredBy string) (*annotation.TaggedAnnotation, error)
+	ListAuditEntries(ctx context.Context, filter *model.AuditFilter) (*model.AuditPage, error)
+	ListObsoleteAnnotations(
+		ctx context.Context,
+		filter string,
+		cursor, limit int64,
+	) (*model.AnnotationPage, error)
+}
+
+// requestFunc builds the rpc request from the path, query parameters and
+// body of a http request.
+type requestFunc func(r *http.Request) (proto.Message, error)
+
+type gateway struct {
+	srv    Service
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// NewHandler creates the http handler of the REST endpoints
+//
+//	GET    /annotations                 ListAnnotations
+//	POST   /annotations                 CreateAnnotation
+//	POST   /annotations/bulk-edit       BulkUpdateAnnotations
+//	POST   /annotations/bulk-obsolete   BulkObsoleteAnnotations
+//	GET    /annotations/obsolete        ListObsoleteAnnotations
+//	GET    /annotations/{id}            GetAnnotation
+//	PATCH  /annotations/{id}            UpdateAnnotation
+//	DELETE /annotations/{id}            DeleteAnnotation
+//	GET    /annotations/{id}/groups     ListGroupsByAnnotation
+//	POST   /annotations/{id}/restore    RestoreAnnotation
+//	GET    /entries/{entry_id}/annotation GetEntryAnnotation
+//	GET    /entries/{entry_id}/profile  GetEntryProfile
+//	GET    /entries/{entry_id}/groups   ListGroupsByEntry
+//	GET    /groups                      ListAnnotationGroups
+//	POST   /groups                      CreateAnnotationGroup
+//	POST   /groups/typed                CreateTypedAnnotationGroup
+//	GET    /groups/{id}                 GetAnnotationGroup
+//	PATCH  /groups/{id}                 EditAnnotationGroup
+//	DELETE /groups/{id}                 DeleteAnnotationGroup
+//	POST   /groups/{id}/annotations     AddToAnnotationGroup
+//	PATCH  /groups/{id}/annotations/{anno_id} MoveAnnotationGroupMember
+//	PUT    /groups/{id}/order           ReorderAnnotationGroup
+//	GET    /groups/{id}/versions        ListAnnotationGroupVersions
+//	GET    /group-types/{ontology}/{tag} GetGroupType
+//	PUT    /group-types/{ontology}/{tag} SetGroupType
+//	POST   /batch                       ApplyBatch
+//	GET    /audit                       ListAuditEntries
+//	GET    /tags                        GetAnnotationTag
+//	POST   /ontologies                  OboJSONFileUpload
+func NewHandler(
+	srv Service,
+	icp *Interceptors,
+) http.Handler {
+	gtw := &gateway{
+		srv:    srv,
+		unary:  chainUnary(icp.Unary),
+		stream: chainStream(icp.Stream),
+	}
+	rtr := chi.NewRouter()
+	rtr.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint "+r.URL.Path)
+	})
+	rtr.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+	})
+	rtr.Route("/annotations", func(rtr chi.Router) {
+		rtr.Get("/", gtw.unaryHandler("ListAnnotations", http.StatusOK, listParams))
+		rtr.Post("/", gtw.unaryHandler("CreateAnnotation", http.StatusCreated, newAnnotation))
+		rtr.Post("/bulk-edit", gtw.operation("BulkUpdateAnnotations", http.StatusOK, bulkEdit))
+		rtr.Post("/bulk-obsolete", gtw.operation("BulkObsoleteAnnotations", http.StatusOK, bulkObsolete))
+		rtr.Get("/obsolete", gtw.operation("ListObsoleteAnnotations", http.StatusOK, obsoleteAnnotations))
+		rtr.Get("/{id}", gtw.unaryHandler("GetAnnotation", http.StatusOK, annotationID))
+		rtr.Patch("/{id}", gtw.unaryHandler("UpdateAnnotation", http.StatusOK, annotationUpdate))
+		rtr.Delete("/{id}", gtw.unaryHandler("DeleteAnnotation", http.StatusNoContent, deleteAnnotation))
+		rtr.Get("/{id}/groups", gtw.operation("ListGroupsByAnnotation", http.StatusOK, annotationGroups))
+		rtr.Post("/{id}/restore", gtw.operation("RestoreAnnotation", http.StatusOK, restoreAnnotation))
+	})
+	rtr.Route("/entries/{entry_id}", func(rtr chi.Router) {
+		rtr.Get("/annotation", gtw.unaryHandler("GetEntryAnnotation", http.StatusOK, entryAnnotation))
+		rtr.Get("/profile", gtw.operation("GetEntryProfile", http.StatusOK, entryProfile))
+		rtr.Get("/groups", gtw.operation("ListGroupsByEntry", http.StatusOK, entryGroups))
+	})
+	rtr.Route("/groups", func(rtr chi.Router) {
+		rtr.Get("/", gtw.unaryHandler("ListAnnotationGroups", http.StatusOK, listGroupParams))
+		rtr.Post("/", gtw.unaryHandler("CreateAnnotationGroup", http.StatusCreated, annotationIDList))
+		rtr.Post("/typed", gtw.operation("CreateTypedAnnotationGroup", http.StatusCreated, typedGroup))
+		rtr.Get("/{id}", gtw.unaryHandler("GetAnnotationGroup", http.StatusOK, groupID))
+		rtr.Patch("/{id}", gtw.operation("EditAnnotationGroup", http.StatusOK, groupEdit))
+		rtr.Delete("/{id}", gtw.unaryHandler("DeleteAnnotationGroup", http.StatusNoContent, groupID))
+		rtr.Post("/{id}/annotations", gtw.unaryHandler("AddToAnnotationGroup", http.StatusOK, groupMember))
+		rtr.Patch(
+			"/{id}/annotations/{anno_id}",
+			gtw.operation("MoveAnnotationGroupMember", http.StatusOK, memberMove),
+		)
+		rtr.Put("/{id}/order", gtw.operation("ReorderAnnotationGroup", http.StatusOK, groupOrder))
+		rtr.Get("/{id}/versions", gtw.operation("ListAnnotationGroupVersions", http.StatusOK, groupVersions))
+	})
+	rtr.Route("/group-types/{ontology}/{tag}", func(rtr chi.Router) {
+		rtr.Get("/", gtw.operation("GetGroupType", http.StatusOK, groupTypeKey))
+		rtr.Put("/", gtw.operation("SetGroupType", http.StatusOK, groupType))
+	})
+	rtr.Post("/batch", gtw.operation("ApplyBatch", http.StatusOK, batch))
+	rtr.Get("/audit", gtw.operation("ListAuditEntries", http.StatusOK, auditEntries))
+	rtr.Get("/tags", gtw.unaryHandler("GetAnnotationTag", http.StatusOK, tagRequest))
+	rtr.Post("/ontologies", gtw.uploadHandler)
+
+	return rtr
+}
+
+// unaryHandler calls an rpc through the generated handler of the service
+// descriptor and writes its response with the given status.
+func (gtw *gateway) unaryHandler(
+	method string,
+	status int,
+	reqFn requestFunc,
+) http.HandlerFunc {
+	handler := findMethod(method)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := reqFn(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+		dec := func(in interface{}) error {
+			proto.Merge(in.(proto.Message), req)
+
+			return nil
+		}
+		ctx, hst := withHeaderStream(IncomingContext(r), fullMethod(method))
+		resp, err := handler(gtw.srv, ctx, dec, gtw.unary)
+		hst.writeTo(w)
+		if err != nil {
+			writeStatusError(w, err)
+
+			return
+		}
+		writeMessage(w, status, resp.(proto.Message))
+	}
+}
+
+// IncomingContext carries the headers of a http request as grpc metadata
+// and the address and TLS state of the client as its peer.
+func IncomingContext(r *http.Request) context.Context {
+	mdt := make(metadata.MD, len(r.Header))
+	for key, vals := range r.Header {
+		mdt[strings.ToLower(key)] = vals
+	}
+	prr := &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		prr.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+
+	return peer.NewContext(metadata.NewIncomingContext(r.Context(), mdt), prr)
+}
+
+func remoteAddr(addr string) net.Addr {
+	adp, err := netip.ParseAddrPort(addr)
+	if err != nil {
+		return &net.TCPAddr{}
+	}
+
+	return net.TCPAddrFromAddrPort(adp)
+}
+
+func fullMethod(method string) string {
+	return "/" + annotation.TaggedAnnotationService_ServiceDesc.ServiceName + "/" + method
+}
+
+// findMethod returns the generated handler of a unary rpc in the service
+// descriptor.
+func findMethod(method string) func(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	icp grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	for _, mdc := range annotation.TaggedAnnotationService_ServiceDesc.Methods {
+		if mdc.MethodName == method {
+			return mdc.Handler
+		}
+	}
+	panic("no rpc " + method + " in the annotation service")
+}
+
+// chainUnary folds the interceptors into one, the first one is the
+// outermost.
+func chainUnary(icps []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		next := handler
+		for i := len(icps) - 1; i >= 0; i-- {
+			icp, inner := icps[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return icp(ctx, req, info, inner)
+			}
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// chainStream folds the interceptors into one, the first one is the
+// outermost.
+func chainStream(icps []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		next := handler
+		for i := len(icps) - 1; i >= 0; i-- {
+			icp, inner := icps[i], next
+			next = func(srv interface{}, stream grpc.ServerStream) error {
+				return icp(srv, stream, info, inner)
+			}
+		}
+
+		return next(srv, stream)
+	}
+}
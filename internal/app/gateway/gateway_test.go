@@ -0,0 +1,457 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeService records the request it gets, the methods it does not
+// override are never reached by the tests.
+type fakeService struct {
+	Service
+	mu  sync.Mutex
+	req interface{}
+	err error
+}
+
+func (fs *fakeService) record(req interface{}) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.req = req
+
+	return fs.err
+}
+
+func (fs *fakeService) received() interface{} {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.req
+}
+
+func (fs *fakeService) GetAnnotation(
+	ctx context.Context,
+	req *annotation.AnnotationId,
+) (*annotation.TaggedAnnotation, error) {
+	if err := fs.record(req); err != nil {
+		return nil, err
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("group-metadata-bin", `{"name":"cluster"}`))
+
+	return &annotation.TaggedAnnotation{
+		Data: &annotation.TaggedAnnotation_Data{
+			Type: "annotations",
+			Id:   req.Id,
+			Attributes: &annotation.TaggedAnnotationAttributes{
+				Value:   "ameboid",
+				EntryId: "DDB_G0267474",
+			},
+		},
+	}, nil
+}
+
+func (fs *fakeService) CreateAnnotation(
+	_ context.Context,
+	req *annotation.NewTaggedAnnotation,
+) (*annotation.TaggedAnnotation, error) {
+	if err := fs.record(req); err != nil {
+		return nil, err
+	}
+
+	return &annotation.TaggedAnnotation{
+		Data: &annotation.TaggedAnnotation_Data{Type: "annotations", Id: "4589"},
+	}, nil
+}
+
+func (fs *fakeService) DeleteAnnotation(
+	_ context.Context,
+	req *annotation.DeleteAnnotationRequest,
+) (*empty.Empty, error) {
+	return &empty.Empty{}, fs.record(req)
+}
+
+func (fs *fakeService) GetEntryProfile(
+	_ context.Context,
+	entryID string,
+) (*model.EntryProfile, error) {
+	if err := fs.record(entryID); err != nil {
+		return nil, err
+	}
+
+	return &model.EntryProfile{EntryId: entryID}, nil
+}
+
+func (fs *fakeService) MoveAnnotationGroupMember(
+	_ context.Context,
+	groupID, annoID string,
+	position int64,
+) (*annotation.TaggedAnnotationGroup, error) {
+	if err := fs.record([]interface{}{groupID, annoID, position}); err != nil {
+		return nil, err
+	}
+
+	return &annotation.TaggedAnnotationGroup{}, nil
+}
+
+func (fs *fakeService) ListObsoleteAnnotations(
+	_ context.Context,
+	filter string,
+	cursor, limit int64,
+) (*model.AnnotationPage, error) {
+	if err := fs.record([]interface{}{filter, cursor, limit}); err != nil {
+		return nil, err
+	}
+	deletedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	return &model.AnnotationPage{
+		Annotations: []*model.AnnoDoc{{
+			Value:        "ameboid",
+			IsObsolete:   true,
+			DeletedBy:    "pfey@gmail.com",
+			DeletedAt:    &deletedAt,
+			DeleteReason: "duplicate curation",
+		}},
+	}, nil
+}
+
+// call is what an interceptor saw of a request.
+type call struct {
+	method string
+	md     metadata.MD
+	addr   string
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []*call
+	deny  error
+}
+
+func (rec *recorder) intercept(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	mdt, _ := metadata.FromIncomingContext(ctx)
+	cll := &call{method: info.FullMethod, md: mdt}
+	if prr, ok := peer.FromContext(ctx); ok {
+		cll.addr = prr.Addr.String()
+	}
+	rec.mu.Lock()
+	rec.calls = append(rec.calls, cll)
+	rec.mu.Unlock()
+	if rec.deny != nil {
+		return nil, rec.deny
+	}
+
+	return handler(ctx, req)
+}
+
+func (rec *recorder) last() *call {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.calls) == 0 {
+		return nil
+	}
+
+	return rec.calls[len(rec.calls)-1]
+}
+
+func serve(
+	t *testing.T,
+	srv Service,
+	rec *recorder,
+	method, target, body string,
+	header map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.10:52100"
+	for key, val := range header {
+		req.Header.Set(key, val)
+	}
+	rsp := httptest.NewRecorder()
+	icp := &Interceptors{Unary: []grpc.UnaryServerInterceptor{rec.intercept}}
+	NewHandler(srv, icp).ServeHTTP(rsp, req)
+
+	return rsp
+}
+
+func TestUnaryMapping(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		rpc    string
+		status int
+		req    proto.Message
+	}{
+		{
+			name:   "get",
+			method: http.MethodGet,
+			target: "/annotations/4589",
+			rpc:    "GetAnnotation",
+			status: http.StatusOK,
+			req:    &annotation.AnnotationId{Id: "4589"},
+		},
+		{
+			name:   "create",
+			method: http.MethodPost,
+			target: "/annotations",
+			body: `{"data": {"type": "annotations", "attributes": {
+				"value": "ameboid", "created_by": "pfey@gmail.com", "tag": "phenotype",
+				"entry_id": "DDB_G0267474", "ontology": "dicty_annotation"}}}`,
+			rpc:    "CreateAnnotation",
+			status: http.StatusCreated,
+			req: &annotation.NewTaggedAnnotation{
+				Data: &annotation.NewTaggedAnnotation_Data{
+					Type: "annotations",
+					Attributes: &annotation.NewTaggedAnnotationAttributes{
+						Value:     "ameboid",
+						CreatedBy: "pfey@gmail.com",
+						Tag:       "phenotype",
+						EntryId:   "DDB_G0267474",
+						Ontology:  "dicty_annotation",
+					},
+				},
+			},
+		},
+		{
+			name:   "purge",
+			method: http.MethodDelete,
+			target: "/annotations/4589?purge=true",
+			rpc:    "DeleteAnnotation",
+			status: http.StatusNoContent,
+			req:    &annotation.DeleteAnnotationRequest{Id: "4589", Purge: true},
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			srv, rec := &fakeService{}, &recorder{}
+			rsp := serve(t, srv, rec, tst.method, tst.target, tst.body, map[string]string{
+				"X-Api-Key":        "s3cret",
+				"Expected-Version": "3",
+			})
+			assert.Equal(tst.status, rsp.Code, "should match the http status")
+			assert.True(proto.Equal(tst.req, srv.received().(proto.Message)), "should map the request")
+			cll := rec.last()
+			assert.Equal(fullMethod(tst.rpc), cll.method, "should run as the rpc")
+			assert.Equal([]string{"s3cret"}, cll.md.Get("x-api-key"), "should pass headers as metadata")
+			assert.Equal([]string{"3"}, cll.md.Get("expected-version"), "should pass headers as metadata")
+			assert.Equal("192.0.2.10:52100", cll.addr, "should pass the client as peer")
+		})
+	}
+}
+
+func TestUnaryResponse(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	rsp := serve(t, &fakeService{}, &recorder{}, http.MethodGet, "/annotations/4589", "", nil)
+	assert.Equal(http.StatusOK, rsp.Code)
+	assert.Equal(contentType, rsp.Header().Get("Content-Type"), "should be a JSON:API document")
+	doc := make(map[string]map[string]interface{})
+	assert.NoError(json.Unmarshal(rsp.Body.Bytes(), &doc), "expect a json body")
+	assert.Equal("4589", doc["data"]["id"], "should match the id")
+	attrs := doc["data"]["attributes"].(map[string]interface{})
+	assert.Equal("DDB_G0267474", attrs["entry_id"], "should use the proto field names")
+	hdr, err := base64.StdEncoding.DecodeString(rsp.Header().Get("Group-Metadata-Bin"))
+	assert.NoError(err, "expect a base64 encoded binary header")
+	assert.JSONEq(`{"name":"cluster"}`, string(hdr), "should send the headers set by the rpc")
+}
+
+func TestOperationMapping(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		op     string
+		req    interface{}
+		status int
+	}{
+		{
+			name:   "entry profile",
+			method: http.MethodGet,
+			target: "/entries/DDB_G0267474/profile",
+			op:     "GetEntryProfile",
+			req:    "DDB_G0267474",
+			status: http.StatusOK,
+		},
+		{
+			name:   "obsolete annotations",
+			method: http.MethodGet,
+			target: "/annotations/obsolete?cursor=1772618400000&limit=20&filter=tag===curation",
+			op:     "ListObsoleteAnnotations",
+			req:    []interface{}{"tag===curation", int64(1772618400000), int64(20)},
+			status: http.StatusOK,
+		},
+		{
+			name:   "member move",
+			method: http.MethodPatch,
+			target: "/groups/g1/annotations/4589",
+			body:   `{"position": 2}`,
+			op:     "MoveAnnotationGroupMember",
+			req:    []interface{}{"g1", "4589", int64(2)},
+			status: http.StatusOK,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			srv, rec := &fakeService{}, &recorder{}
+			rsp := serve(t, srv, rec, tst.method, tst.target, tst.body, map[string]string{
+				"Authorization": "Bearer token",
+			})
+			assert.Equal(tst.status, rsp.Code, "should match the http status")
+			assert.Equal(tst.req, srv.received(), "should map the request")
+			cll := rec.last()
+			assert.Equal(fullMethod(tst.op), cll.method, "should run as the operation")
+			assert.Equal([]string{"Bearer token"}, cll.md.Get("authorization"), "should pass headers as metadata")
+			assert.Equal("192.0.2.10:52100", cll.addr, "should pass the client as peer")
+		})
+	}
+}
+
+func TestObsoleteAnnotationsResponse(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	rsp := serve(t, &fakeService{}, &recorder{}, http.MethodGet, "/annotations/obsolete", "", nil)
+	assert.Equal(http.StatusOK, rsp.Code, "should match the http status")
+	var page struct {
+		Annotations []map[string]interface{} `json:"annotations"`
+	}
+	assert.NoError(json.Unmarshal(rsp.Body.Bytes(), &page), "expect a json page")
+	assert.Len(page.Annotations, 1, "should list the obsolete annotation")
+	ann := page.Annotations[0]
+	assert.Equal("pfey@gmail.com", ann["deleted_by"], "should have who removed it")
+	assert.Equal("2026-03-04T10:00:00Z", ann["deleted_at"], "should have when it was removed")
+	assert.Equal("duplicate curation", ann["delete_reason"], "should have why it was removed")
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	limited, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+	)
+	require.NoError(t, err)
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		srvErr     error
+		deny       error
+		status     int
+		retryAfter string
+		called     bool
+	}{
+		{
+			name:   "not found",
+			method: http.MethodGet,
+			target: "/annotations/4589",
+			srvErr: status.Error(codes.NotFound, "no annotation 4589"),
+			status: http.StatusNotFound,
+			called: true,
+		},
+		{
+			name:   "conflict",
+			method: http.MethodDelete,
+			target: "/annotations/4589",
+			srvErr: status.Error(codes.Aborted, "stale version"),
+			status: http.StatusConflict,
+			called: true,
+		},
+		{
+			name:       "rate limited",
+			method:     http.MethodGet,
+			target:     "/annotations/4589",
+			deny:       limited.Err(),
+			status:     http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+		{
+			name:   "denied",
+			method: http.MethodGet,
+			target: "/entries/DDB_G0267474/profile",
+			deny:   status.Error(codes.PermissionDenied, "needs the reader role"),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "bad parameter",
+			method: http.MethodDelete,
+			target: "/annotations/4589?purge=maybe",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "bad body",
+			method: http.MethodPatch,
+			target: "/groups/g1/annotations/4589",
+			body:   `{"position": 2, "rank": 1}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing position",
+			method: http.MethodPatch,
+			target: "/groups/g1/annotations/4589",
+			body:   `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "no endpoint",
+			method: http.MethodGet,
+			target: "/stocks",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPut,
+			target: "/annotations/4589",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			srv, rec := &fakeService{err: tst.srvErr}, &recorder{deny: tst.deny}
+			rsp := serve(t, srv, rec, tst.method, tst.target, tst.body, nil)
+			assert.Equal(tst.status, rsp.Code, "should match the http status")
+			assert.Equal(tst.retryAfter, rsp.Header().Get("Retry-After"), "should match the retry delay")
+			assert.Equal(tst.called, srv.received() != nil, "should only call the service when allowed")
+			doc := &errorDoc{}
+			assert.NoError(json.Unmarshal(rsp.Body.Bytes(), doc), "expect a JSON:API error document")
+			assert.Len(doc.Errors, 1, "should have one error")
+			assert.Equal(http.StatusText(tst.status), doc.Errors[0].Title, "should match the title")
+		})
+	}
+}
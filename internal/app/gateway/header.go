@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerStream collects the response headers set by an rpc, which has no
+// grpc transport behind the gateway.
+type headerStream struct {
+	method string
+	header metadata.MD
+}
+
+// withHeaderStream returns a copy of the context in which grpc.SetHeader
+// and grpc.SendHeader reach the returned headerStream.
+func withHeaderStream(ctx context.Context, method string) (context.Context, *headerStream) {
+	hst := &headerStream{method: method, header: metadata.MD{}}
+
+	return grpc.NewContextWithServerTransportStream(ctx, hst), hst
+}
+
+func (hst *headerStream) Method() string {
+	return hst.method
+}
+
+func (hst *headerStream) SetHeader(mdt metadata.MD) error {
+	hst.header = metadata.Join(hst.header, mdt)
+
+	return nil
+}
+
+func (hst *headerStream) SendHeader(mdt metadata.MD) error {
+	return hst.SetHeader(mdt)
+}
+
+func (hst *headerStream) SetTrailer(metadata.MD) error {
+	return nil
+}
+
+// writeTo adds the collected headers to the http response, binary values
+// are base64 encoded as in grpc-web.
+func (hst *headerStream) writeTo(w http.ResponseWriter) {
+	for key, vals := range hst.header {
+		for _, val := range vals {
+			if strings.HasSuffix(key, "-bin") {
+				val = base64.StdEncoding.EncodeToString([]byte(val))
+			}
+			w.Header().Add(key, val)
+		}
+	}
+}
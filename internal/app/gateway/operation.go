@@ -0,0 +1,312 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
+)
+
+// operationFunc builds the call of a service operation without an rpc from
+// the path, query parameters and body of a http request.
+type operationFunc func(srv Service, r *http.Request) (grpc.UnaryHandler, error)
+
+// operation runs a service operation without an rpc behind the interceptors
+// as the method of the same name, so it is authorized, logged and limited
+// like the rpcs. The result is written with the given status.
+func (gtw *gateway) operation(
+	method string,
+	status int,
+	opFn operationFunc,
+) http.HandlerFunc {
+	info := &grpc.UnaryServerInfo{Server: gtw.srv, FullMethod: fullMethod(method)}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		call, err := opFn(gtw.srv, r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+		ctx, hst := withHeaderStream(IncomingContext(r), info.FullMethod)
+		resp, err := gtw.unary(ctx, nil, info, call)
+		hst.writeTo(w)
+		if err != nil {
+			writeStatusError(w, err)
+
+			return
+		}
+		writeResult(w, status, resp)
+	}
+}
+
+func entryProfile(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	entryID := chi.URLParam(r, "entry_id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.GetEntryProfile(ctx, entryID)
+	}, nil
+}
+
+func entryGroups(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	entryID := chi.URLParam(r, "entry_id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ListGroupsByEntry(ctx, entryID)
+	}, nil
+}
+
+func annotationGroups(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	req := &annotation.AnnotationId{Id: chi.URLParam(r, "id")}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ListGroupsByAnnotation(ctx, req)
+	}, nil
+}
+
+// restoreAnnotation reads the optional curator of a restore from the body,
+// the caller is used when it is missing.
+func restoreAnnotation(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		RestoredBy string `json:"restored_by"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, body); err != nil {
+			return nil, err
+		}
+	}
+	id := chi.URLParam(r, "id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.RestoreAnnotation(ctx, id, body.RestoredBy)
+	}, nil
+}
+
+// typedGroup reads the group type, name, description and members of a new
+// group from the body.
+func typedGroup(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		GroupOntology string   `json:"group_ontology"`
+		GroupType     string   `json:"group_type"`
+		Name          string   `json:"name"`
+		Description   string   `json:"description"`
+		CreatedBy     string   `json:"created_by"`
+		Ids           []string `json:"ids"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	params := &model.GroupParams{
+		Ontology:    body.GroupOntology,
+		Tag:         body.GroupType,
+		Name:        body.Name,
+		Description: body.Description,
+		CreatedBy:   body.CreatedBy,
+	}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.CreateTypedAnnotationGroup(ctx, params, body.Ids...)
+	}, nil
+}
+
+func groupEdit(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		UpdatedBy   string `json:"updated_by"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	groupID := chi.URLParam(r, "id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.EditAnnotationGroup(ctx, groupID, body.Name, body.Description, body.UpdatedBy)
+	}, nil
+}
+
+// groupOrder reads the members to place first in the group from the body.
+func groupOrder(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Ids []string `json:"ids"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	groupID := chi.URLParam(r, "id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ReorderAnnotationGroup(ctx, groupID, body.Ids...)
+	}, nil
+}
+
+// memberMove reads the zero based position of the member from the body.
+func memberMove(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Position *int64 `json:"position"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	if body.Position == nil {
+		return nil, fmt.Errorf("position is missing in the body")
+	}
+	groupID, annoID := chi.URLParam(r, "id"), chi.URLParam(r, "anno_id")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.MoveAnnotationGroupMember(ctx, groupID, annoID, *body.Position)
+	}, nil
+}
+
+func groupVersions(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	rid := &annotation.GroupEntryId{GroupId: chi.URLParam(r, "id")}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ListAnnotationGroupVersions(ctx, rid)
+	}, nil
+}
+
+func groupTypeKey(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	ontology, tag := chi.URLParam(r, "ontology"), chi.URLParam(r, "tag")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.GetGroupType(ctx, ontology, tag)
+	}, nil
+}
+
+// groupType reads the rules of a group type from the body, its ontology
+// and tag from the path.
+func groupType(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	gtp := &model.GroupType{}
+	if err := decodeJSON(r, gtp); err != nil {
+		return nil, err
+	}
+	gtp.Ontology, gtp.Tag = chi.URLParam(r, "ontology"), chi.URLParam(r, "tag")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.SetGroupType(ctx, gtp)
+	}, nil
+}
+
+// batch reads the operations of a batch from the body, the annotation
+// attributes of an operation use the json names of their messages.
+func batch(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Operations []*model.BatchOperation `json:"operations"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ApplyBatch(ctx, body.Operations)
+	}, nil
+}
+
+// bulkEdit reads the filter and the edit of a bulk change from the body.
+func bulkEdit(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Filter      string `json:"filter"`
+		CreatedBy   string `json:"created_by"`
+		Pattern     string `json:"pattern"`
+		Replacement string `json:"replacement"`
+		DryRun      bool   `json:"dry_run"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	edit := &model.BulkEdit{
+		CreatedBy:   body.CreatedBy,
+		Pattern:     body.Pattern,
+		Replacement: body.Replacement,
+	}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.BulkUpdateAnnotations(ctx, body.Filter, edit, body.DryRun)
+	}, nil
+}
+
+// bulkObsolete reads the filter and the reason of a bulk removal from the
+// body.
+func bulkObsolete(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	body := &struct {
+		Filter    string `json:"filter"`
+		DeletedBy string `json:"deleted_by"`
+		Reason    string `json:"reason"`
+		DryRun    bool   `json:"dry_run"`
+	}{}
+	if err := decodeJSON(r, body); err != nil {
+		return nil, err
+	}
+	info := &model.DeleteInfo{DeletedBy: body.DeletedBy, Reason: body.Reason}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.BulkObsoleteAnnotations(ctx, body.Filter, info, body.DryRun)
+	}, nil
+}
+
+// auditEntries reads the filters of the audit log from the query
+// parameters, since and until are RFC3339 times.
+func auditEntries(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filter := &model.AuditFilter{
+		Actor:     r.URL.Query().Get("actor"),
+		EntryId:   r.URL.Query().Get("entry_id"),
+		Cursor:    cursor,
+		CursorKey: r.URL.Query().Get("cursor_key"),
+		Limit:     limit,
+	}
+	for param, tptr := range map[string]*time.Time{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	} {
+		val := r.URL.Query().Get(param)
+		if len(val) == 0 {
+			continue
+		}
+		tm, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, fmt.Errorf("error in parsing %s %s", param, err)
+		}
+		*tptr = tm
+	}
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ListAuditEntries(ctx, filter)
+	}, nil
+}
+
+// obsoleteAnnotations reads the page and the filter of the obsolete
+// annotations from the query parameters.
+func obsoleteAnnotations(srv Service, r *http.Request) (grpc.UnaryHandler, error) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	filter := r.URL.Query().Get("filter")
+
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return srv.ListObsoleteAnnotations(ctx, filter, cursor, limit)
+	}, nil
+}
+
+// decodeJSON reads the json body of an operation, unknown fields are
+// rejected.
+func decodeJSON(r *http.Request, val interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(val); err != nil {
+		return fmt.Errorf("error in decoding the body %s", err)
+	}
+
+	return nil
+}
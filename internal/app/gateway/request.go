@@ -0,0 +1,189 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func listParams(r *http.Request) (proto.Message, error) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &annotation.ListParameters{
+		Cursor: cursor,
+		Limit:  limit,
+		Filter: r.URL.Query().Get("filter"),
+	}, nil
+}
+
+func listGroupParams(r *http.Request) (proto.Message, error) {
+	cursor, limit, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &annotation.ListGroupParameters{
+		Cursor: cursor,
+		Limit:  limit,
+		Filter: r.URL.Query().Get("filter"),
+	}, nil
+}
+
+func newAnnotation(r *http.Request) (proto.Message, error) {
+	nta := &annotation.NewTaggedAnnotation{}
+
+	return nta, decodeBody(r, nta)
+}
+
+// annotationUpdate takes the id of the annotation from the path, an id in
+// the body has to match it.
+func annotationUpdate(r *http.Request) (proto.Message, error) {
+	tau := &annotation.TaggedAnnotationUpdate{}
+	if err := decodeBody(r, tau); err != nil {
+		return nil, err
+	}
+	if tau.Data == nil {
+		return nil, fmt.Errorf("data is missing in the body")
+	}
+	pid := chi.URLParam(r, "id")
+	if len(tau.Data.Id) > 0 && tau.Data.Id != pid {
+		return nil, fmt.Errorf("id %s in the body does not match the path", tau.Data.Id)
+	}
+	tau.Data.Id = pid
+
+	return tau, nil
+}
+
+func annotationID(r *http.Request) (proto.Message, error) {
+	return &annotation.AnnotationId{Id: chi.URLParam(r, "id")}, nil
+}
+
+func deleteAnnotation(r *http.Request) (proto.Message, error) {
+	purge, err := boolParam(r, "purge")
+	if err != nil {
+		return nil, err
+	}
+
+	return &annotation.DeleteAnnotationRequest{
+		Id:    chi.URLParam(r, "id"),
+		Purge: purge,
+	}, nil
+}
+
+func entryAnnotation(r *http.Request) (proto.Message, error) {
+	rank, err := intParam(r, "rank")
+	if err != nil {
+		return nil, err
+	}
+	obsolete, err := boolParam(r, "is_obsolete")
+	if err != nil {
+		return nil, err
+	}
+	qry := r.URL.Query()
+
+	return &annotation.EntryAnnotationRequest{
+		EntryId:    chi.URLParam(r, "entry_id"),
+		Tag:        qry.Get("tag"),
+		Ontology:   qry.Get("ontology"),
+		Rank:       rank,
+		IsObsolete: obsolete,
+	}, nil
+}
+
+func annotationIDList(r *http.Request) (proto.Message, error) {
+	ids := &annotation.AnnotationIdList{}
+
+	return ids, decodeBody(r, ids)
+}
+
+func groupID(r *http.Request) (proto.Message, error) {
+	return &annotation.GroupEntryId{GroupId: chi.URLParam(r, "id")}, nil
+}
+
+// groupMember reads the id of the annotation from the body and the id of
+// the group from the path.
+func groupMember(r *http.Request) (proto.Message, error) {
+	agi := &annotation.AnnotationGroupId{}
+	if err := decodeBody(r, agi); err != nil {
+		return nil, err
+	}
+	agi.GroupId = chi.URLParam(r, "id")
+
+	return agi, nil
+}
+
+func tagRequest(r *http.Request) (proto.Message, error) {
+	obsolete, err := boolParam(r, "is_obsolete")
+	if err != nil {
+		return nil, err
+	}
+	qry := r.URL.Query()
+
+	return &annotation.TagRequest{
+		Name:       qry.Get("name"),
+		Ontology:   qry.Get("ontology"),
+		IsObsolete: obsolete,
+	}, nil
+}
+
+func pageParams(r *http.Request) (int64, int64, error) {
+	cursor, err := intParam(r, "cursor")
+	if err != nil {
+		return 0, 0, err
+	}
+	limit, err := intParam(r, "limit")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cursor, limit, nil
+}
+
+// decodeBody reads the json body into the request message, fields are
+// accepted by either their proto or json names.
+func decodeBody(r *http.Request, msg proto.Message) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("error in reading the body %s", err)
+	}
+	if err := protojson.Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("error in decoding the body %s", err)
+	}
+
+	return nil
+}
+
+func intParam(r *http.Request, name string) (int64, error) {
+	val := r.URL.Query().Get(name)
+	if len(val) == 0 {
+		return 0, nil
+	}
+	num, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is not a number %s", name, val)
+	}
+
+	return num, nil
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	val := r.URL.Query().Get(name)
+	if len(val) == 0 {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("parameter %s is not a boolean %s", name, val)
+	}
+
+	return flag, nil
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const contentType = "application/vnd.api+json"
+
+var marshaler = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+type apiError struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type errorDoc struct {
+	Errors []*apiError `json:"errors"`
+}
+
+// writeMessage writes the response of an rpc, the body is left out for no
+// content.
+func writeMessage(w http.ResponseWriter, status int, msg proto.Message) {
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+
+		return
+	}
+	body, err := marshaler.Marshal(msg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error in encoding response "+err.Error())
+
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// writeResult writes the result of a service operation, a protocol buffer
+// message in its json form and a model structure as it is.
+func writeResult(w http.ResponseWriter, status int, res interface{}) {
+	if msg, ok := res.(proto.Message); ok {
+		writeMessage(w, status, msg)
+
+		return
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error in encoding response "+err.Error())
+
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// writeStatusError writes the grpc status of an rpc error as a JSON:API
+// error document, a retry delay of the status is sent as Retry-After.
+func writeStatusError(w http.ResponseWriter, err error) {
+	sts := status.Convert(err)
+	for _, dtl := range sts.Details() {
+		if rti, ok := dtl.(*errdetails.RetryInfo); ok {
+			secs := math.Ceil(rti.RetryDelay.AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(secs)))
+		}
+	}
+	writeError(w, HTTPStatus(sts.Code()), sts.Message())
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	body, _ := json.Marshal(&errorDoc{
+		Errors: []*apiError{{
+			Status: strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: detail,
+		}},
+	})
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// HTTPStatus maps a grpc code to the closest http status.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
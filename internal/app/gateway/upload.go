@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/go-genproto/dictybaseapis/api/upload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	uploadField = "file"
+	chunkSize   = 64 * 1024
+)
+
+// uploadStream is the client stream of OboJSONFileUpload fed by the file
+// part of a multipart request, the file is sent in chunks as it is read.
+type uploadStream struct {
+	ctx  context.Context
+	part *multipart.Part
+	buf  []byte
+	resp *upload.FileUploadResponse
+}
+
+func (ups *uploadStream) Context() context.Context {
+	return ups.ctx
+}
+
+func (ups *uploadStream) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (ups *uploadStream) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (ups *uploadStream) SetTrailer(metadata.MD) {}
+
+func (ups *uploadStream) SendMsg(msg interface{}) error {
+	resp, ok := msg.(*upload.FileUploadResponse)
+	if !ok {
+		return fmt.Errorf("unexpected response %T", msg)
+	}
+	ups.resp = resp
+
+	return nil
+}
+
+func (ups *uploadStream) RecvMsg(msg interface{}) error {
+	req, ok := msg.(*upload.FileUploadRequest)
+	if !ok {
+		return fmt.Errorf("unexpected request %T", msg)
+	}
+	num, err := ups.part.Read(ups.buf)
+	if num > 0 {
+		req.Name = ups.part.FileName()
+		req.Content = append(req.Content[:0], ups.buf[:num]...)
+
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("empty read of the uploaded file")
+	}
+
+	return err
+}
+
+// uploadHandler streams the file field of a multipart form to
+// OboJSONFileUpload.
+func (gtw *gateway) uploadHandler(w http.ResponseWriter, r *http.Request) {
+	part, err := filePart(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+	defer part.Close()
+	ups := &uploadStream{
+		ctx:  IncomingContext(r),
+		part: part,
+		buf:  make([]byte, chunkSize),
+	}
+	sdc := streamDesc("OboJSONFileUpload")
+	err = gtw.stream(
+		gtw.srv,
+		ups,
+		&grpc.StreamServerInfo{
+			FullMethod:     fullMethod(sdc.StreamName),
+			IsClientStream: sdc.ClientStreams,
+			IsServerStream: sdc.ServerStreams,
+		},
+		sdc.Handler,
+	)
+	if err != nil {
+		writeStatusError(w, err)
+
+		return
+	}
+	if ups.resp == nil {
+		writeError(w, http.StatusInternalServerError, "upload finished without a response")
+
+		return
+	}
+	status := http.StatusOK
+	if ups.resp.Status == upload.FileUploadResponse_CREATED {
+		status = http.StatusCreated
+	}
+	writeMessage(w, status, ups.resp)
+}
+
+func streamDesc(name string) grpc.StreamDesc {
+	for _, sdc := range annotation.TaggedAnnotationService_ServiceDesc.Streams {
+		if sdc.StreamName == name {
+			return sdc
+		}
+	}
+	panic("no stream " + name + " in the annotation service")
+}
+
+// filePart returns the first part of the form holding a file.
+func filePart(r *http.Request) (*multipart.Part, error) {
+	mrd, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("error in reading multipart form %s", err)
+	}
+	for {
+		part, err := mrd.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("form field %s is missing", uploadField)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error in reading multipart form %s", err)
+		}
+		if part.FormName() == uploadField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// annotationBatch is shared by the annotations of one list, the tags and
+// the groups of all of them are fetched in one query when the first one is
+// resolved.
+type annotationBatch struct {
+	repo      repository.TaggedAnnotationRepository
+	docs      []*model.AnnoDoc
+	tagOnce   sync.Once
+	tags      map[model.TagKey]*tagResolver
+	tagErr    error
+	groupOnce sync.Once
+	groups    map[string][]*groupResolver
+	groupErr  error
+}
+
+type annotationResolver struct {
+	doc   *model.AnnoDoc
+	batch *annotationBatch
+}
+
+// newAnnotationResolvers creates the resolvers of a list of annotations
+// sharing one batch.
+func newAnnotationResolvers(
+	repo repository.TaggedAnnotationRepository,
+	docs []*model.AnnoDoc,
+) []*annotationResolver {
+	abt := &annotationBatch{repo: repo, docs: docs}
+	anrs := make([]*annotationResolver, 0, len(docs))
+	for _, doc := range docs {
+		anrs = append(anrs, &annotationResolver{doc: doc, batch: abt})
+	}
+
+	return anrs
+}
+
+func (anr *annotationResolver) ID() gql.ID {
+	return gql.ID(anr.doc.Key)
+}
+
+func (anr *annotationResolver) Value() string {
+	return anr.doc.Value
+}
+
+func (anr *annotationResolver) EditableValue() string {
+	return anr.doc.EditableValue
+}
+
+func (anr *annotationResolver) CreatedBy() string {
+	return anr.doc.CreatedBy
+}
+
+func (anr *annotationResolver) CreatedAt() gql.Time {
+	return gql.Time{Time: anr.doc.CreatedAt}
+}
+
+func (anr *annotationResolver) EntryID() string {
+	return anr.doc.EnrtyId
+}
+
+func (anr *annotationResolver) Rank() int32 {
+	return int32(anr.doc.Rank)
+}
+
+func (anr *annotationResolver) Version() int32 {
+	return int32(anr.doc.Version)
+}
+
+func (anr *annotationResolver) IsObsolete() bool {
+	return anr.doc.IsObsolete
+}
+
+func (anr *annotationResolver) Tag(ctx context.Context) (*tagResolver, error) {
+	abt := anr.batch
+	abt.tagOnce.Do(func() { abt.loadTags(ctx) })
+	if abt.tagErr != nil {
+		return nil, abt.tagErr
+	}
+	key := model.TagKey{Name: anr.doc.Tag, Ontology: anr.doc.Ontology}
+	tgr, ok := abt.tags[key]
+	if !ok {
+		return nil, fmt.Errorf("tag %s of ontology %s is not found", key.Name, key.Ontology)
+	}
+
+	return tgr, nil
+}
+
+func (anr *annotationResolver) Groups(ctx context.Context) ([]*groupResolver, error) {
+	abt := anr.batch
+	abt.groupOnce.Do(func() { abt.loadGroups(ctx) })
+	if abt.groupErr != nil {
+		return nil, abt.groupErr
+	}
+	if grs, ok := abt.groups[anr.doc.Key]; ok {
+		return grs, nil
+	}
+
+	return []*groupResolver{}, nil
+}
+
+func (abt *annotationBatch) loadTags(ctx context.Context) {
+	seen := make(map[model.TagKey]bool)
+	keys := make([]*model.TagKey, 0)
+	for _, doc := range abt.docs {
+		key := model.TagKey{Name: doc.Tag, Ontology: doc.Ontology}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, &key)
+	}
+	tags, err := abt.repo.GetAnnotationTags(ctx, keys)
+	if err != nil {
+		abt.tagErr = fmt.Errorf("error in fetching tags %s", err)
+
+		return
+	}
+	abt.tags = make(map[model.TagKey]*tagResolver)
+	for _, tgr := range newTagResolvers(abt.repo, tags) {
+		abt.tags[model.TagKey{Name: tgr.tag.Name, Ontology: tgr.tag.Ontology}] = tgr
+	}
+}
+
+// loadGroups fetches the groups of all annotations of the batch and
+// assigns every group to its members.
+func (abt *annotationBatch) loadGroups(ctx context.Context) {
+	ids := make([]string, 0, len(abt.docs))
+	for _, doc := range abt.docs {
+		ids = append(ids, doc.Key)
+	}
+	abt.groups = make(map[string][]*groupResolver)
+	grps, err := abt.repo.ListGroupsByAnnotations(ctx, ids)
+	if err != nil {
+		if repository.IsAnnotationGroupListNotFound(err) {
+			return
+		}
+		abt.groupErr = fmt.Errorf("error in fetching groups %s", err)
+
+		return
+	}
+	for _, grr := range newGroupResolvers(abt.repo, grps) {
+		for _, doc := range grr.group.AnnoDocs {
+			abt.groups[doc.Key] = append(abt.groups[doc.Key], grr)
+		}
+	}
+}
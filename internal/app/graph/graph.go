@@ -0,0 +1,99 @@
+// Package graph serves a GraphQL read api of annotations, their tags,
+// ontologies and groups. The resolvers read from the repository, the
+// tags, ontologies and groups of the items of a list are fetched with one
+// query per list instead of one per item.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/app/gateway"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	gql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"google.golang.org/grpc/status"
+)
+
+// maxDepth limits the nesting of queries, an annotation and its groups
+// can otherwise be nested without end.
+const maxDepth = 8
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type handler struct {
+	schema *gql.Schema
+	icp    *gateway.Interceptors
+}
+
+// NewHandler creates the http handler of the GraphQL endpoint. Every query
+// runs behind the unary interceptors of the grpc server as
+// auth.GraphQLMethod. The connections take at most maxPage items, zero
+// allows any.
+func NewHandler(
+	repo repository.TaggedAnnotationRepository,
+	icp *gateway.Interceptors,
+	maxPage int64,
+) http.Handler {
+	return &handler{
+		schema: gql.MustParseSchema(
+			schema,
+			&queryResolver{repo: repo, maxPage: maxPage},
+			gql.MaxDepth(maxDepth),
+		),
+		icp: icp,
+	}
+}
+
+func (hdl *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrors(w, http.StatusMethodNotAllowed, "only POST is allowed")
+
+		return
+	}
+	req := &request{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeErrors(w, http.StatusBadRequest, "error in decoding the body "+err.Error())
+
+		return
+	}
+	resp, err := hdl.icp.Invoke(
+		gateway.IncomingContext(r),
+		auth.GraphQLMethod,
+		req,
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return hdl.schema.Exec(ctx, req.Query, req.OperationName, req.Variables), nil
+		},
+	)
+	if err != nil {
+		sts := status.Convert(err)
+		writeErrors(w, gateway.HTTPStatus(sts.Code()), sts.Message())
+
+		return
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeErrors(w, http.StatusInternalServerError, "error in encoding response "+err.Error())
+
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// writeErrors writes a GraphQL response with a single error for requests
+// that fail before the query runs.
+func writeErrors(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(&gql.Response{
+		Errors: []*gqlerrors.QueryError{{Message: msg}},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,399 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/app/gateway"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var created = time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+// fakeRepo serves fixed annotations and counts the calls of every method.
+type fakeRepo struct {
+	repository.TaggedAnnotationRepository
+	docs   []*model.AnnoDoc
+	groups []*model.AnnoGroup
+	mu     sync.Mutex
+	calls  map[string]int
+}
+
+func newAnnoDoc(key, tag, entryID string, age time.Duration) *model.AnnoDoc {
+	doc := &model.AnnoDoc{
+		Value:     "value of " + key,
+		CreatedBy: "pfey@gmail.com",
+		CreatedAt: created.Add(-age),
+		EnrtyId:   entryID,
+		Tag:       tag,
+		Ontology:  "dicty_annotation",
+		Version:   1,
+	}
+	doc.Key = key
+
+	return doc
+}
+
+func newFakeRepo() *fakeRepo {
+	docs := []*model.AnnoDoc{
+		newAnnoDoc("1", "phenotype", "DDB_G0267474", 0),
+		newAnnoDoc("2", "phenotype", "DDB_G0267474", time.Minute),
+		newAnnoDoc("3", "note", "DDB_G0286429", 2*time.Minute),
+	}
+
+	return &fakeRepo{
+		docs: docs,
+		groups: []*model.AnnoGroup{{
+			GroupId:   "g1",
+			Name:      "cluster",
+			AnnoDocs:  docs[:2],
+			CreatedAt: created,
+			UpdatedAt: created,
+		}},
+		calls: make(map[string]int),
+	}
+}
+
+func (fr *fakeRepo) count(method string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.calls[method]++
+}
+
+func (fr *fakeRepo) called(method string) int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	return fr.calls[method]
+}
+
+func (fr *fakeRepo) GetAnnotationByID(_ context.Context, id string) (*model.AnnoDoc, error) {
+	fr.count("GetAnnotationByID")
+	for _, doc := range fr.docs {
+		if doc.Key == id {
+			return doc, nil
+		}
+	}
+
+	return nil, &repository.AnnoNotFoundError{Id: id}
+}
+
+// ListAnnotations returns one more annotation than the limit when there
+// is one, like the repository.
+func (fr *fakeRepo) ListAnnotations(
+	_ context.Context,
+	cursor, limit int64,
+	_ string,
+) ([]*model.AnnoDoc, error) {
+	fr.count("ListAnnotations")
+	docs := make([]*model.AnnoDoc, 0)
+	for _, doc := range fr.docs {
+		if cursor == 0 || doc.CreatedAt.UnixMilli() <= cursor {
+			docs = append(docs, doc)
+		}
+	}
+	if len(docs) == 0 {
+		return nil, &repository.AnnoListNotFoundError{}
+	}
+	if int64(len(docs)) > limit+1 {
+		docs = docs[:limit+1]
+	}
+
+	return docs, nil
+}
+
+func (fr *fakeRepo) GetAnnotationTags(_ context.Context, keys []*model.TagKey) ([]*model.AnnoTag, error) {
+	fr.count("GetAnnotationTags")
+	tags := make([]*model.AnnoTag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, &model.AnnoTag{ID: key.Name + "-id", Name: key.Name, Ontology: key.Ontology})
+	}
+
+	return tags, nil
+}
+
+func (fr *fakeRepo) GetOntologies(_ context.Context, names []string) ([]*model.Ontology, error) {
+	fr.count("GetOntologies")
+	ontos := make([]*model.Ontology, 0, len(names))
+	for _, name := range names {
+		ontos = append(ontos, &model.Ontology{ID: name + "-id", Namespace: name, Version: "1.0"})
+	}
+
+	return ontos, nil
+}
+
+func (fr *fakeRepo) ListGroupsByAnnotations(_ context.Context, _ []string) ([]*model.AnnoGroup, error) {
+	fr.count("ListGroupsByAnnotations")
+
+	return fr.groups, nil
+}
+
+func (fr *fakeRepo) GetEntryProfile(_ context.Context, entryID string) (*model.EntryProfile, error) {
+	fr.count("GetEntryProfile")
+	prof := &model.EntryProfile{EntryId: entryID}
+	for _, doc := range fr.docs {
+		if doc.EnrtyId == entryID {
+			prof.Tags = append(prof.Tags, &model.TagAnnotations{
+				Ontology: doc.Ontology,
+				Tag:      doc.Tag,
+				AnnoDocs: []*model.AnnoDoc{doc},
+			})
+		}
+	}
+	if len(prof.Tags) == 0 {
+		return nil, &repository.AnnoNotFoundError{Id: entryID}
+	}
+
+	return prof, nil
+}
+
+func (fr *fakeRepo) ListGroupsByEntry(_ context.Context, _ string) ([]*model.AnnoGroup, error) {
+	fr.count("ListGroupsByEntry")
+
+	return fr.groups, nil
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(
+	t *testing.T,
+	hdl http.Handler,
+	method, body string,
+) (int, *gqlResponse) {
+	t.Helper()
+	rsp := httptest.NewRecorder()
+	hdl.ServeHTTP(rsp, httptest.NewRequest(method, "/graphql", strings.NewReader(body)))
+	res := &gqlResponse{}
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), res), "expect a json response")
+
+	return rsp.Code, res
+}
+
+func gqlBody(t *testing.T, qry string, vars map[string]interface{}) string {
+	t.Helper()
+	body, err := json.Marshal(&request{Query: qry, Variables: vars})
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+const annotationsQuery = `query($first: Int, $after: String) {
+	annotations(first: $first, after: $after) {
+		edges { node { id entryId tag { name ontology { name version } } groups { id name } } }
+		pageInfo { hasNextPage endCursor }
+	}
+}`
+
+type annotationsData struct {
+	Annotations struct {
+		Edges []struct {
+			Node struct {
+				ID      string `json:"id"`
+				EntryID string `json:"entryId"`
+				Tag     struct {
+					Name     string `json:"name"`
+					Ontology struct {
+						Name    string `json:"name"`
+						Version string `json:"version"`
+					} `json:"ontology"`
+				} `json:"tag"`
+				Groups []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"groups"`
+			} `json:"node"`
+		} `json:"edges"`
+		PageInfo struct {
+			HasNextPage bool    `json:"hasNextPage"`
+			EndCursor   *string `json:"endCursor"`
+		} `json:"pageInfo"`
+	} `json:"annotations"`
+}
+
+func TestAnnotationsPage(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	repo := newFakeRepo()
+	hdl := NewHandler(repo, &gateway.Interceptors{}, 10)
+	code, res := query(t, hdl, http.MethodPost, gqlBody(t, annotationsQuery, map[string]interface{}{"first": 2}))
+	assert.Equal(http.StatusOK, code)
+	assert.Empty(res.Errors, "expect no error")
+	data := &annotationsData{}
+	assert.NoError(json.Unmarshal(res.Data, data))
+	conn := data.Annotations
+	assert.Len(conn.Edges, 2, "should cut the page at first")
+	assert.True(conn.PageInfo.HasNextPage, "should have a next page")
+	assert.Equal(
+		"1772618280000", *conn.PageInfo.EndCursor,
+		"should point the cursor at the first annotation of the next page",
+	)
+	node := conn.Edges[0].Node
+	assert.Equal("1", node.ID)
+	assert.Equal("phenotype", node.Tag.Name, "should resolve the tag")
+	assert.Equal("dicty_annotation", node.Tag.Ontology.Name, "should resolve the ontology")
+	assert.Len(node.Groups, 1, "should resolve the groups")
+	assert.Equal("cluster", node.Groups[0].Name)
+	assert.Equal(1, repo.called("GetAnnotationTags"), "should fetch the tags of the page at once")
+	assert.Equal(1, repo.called("GetOntologies"), "should fetch the ontologies of the page at once")
+	assert.Equal(1, repo.called("ListGroupsByAnnotations"), "should fetch the groups of the page at once")
+
+	code, res = query(t, hdl, http.MethodPost, gqlBody(t, annotationsQuery, map[string]interface{}{
+		"first": 2,
+		"after": *conn.PageInfo.EndCursor,
+	}))
+	assert.Equal(http.StatusOK, code)
+	assert.Empty(res.Errors, "expect no error")
+	last := &annotationsData{}
+	assert.NoError(json.Unmarshal(res.Data, last))
+	assert.Len(last.Annotations.Edges, 1, "should have the rest on the last page")
+	assert.Equal("3", last.Annotations.Edges[0].Node.ID)
+	assert.False(last.Annotations.PageInfo.HasNextPage, "should have no next page")
+	assert.Nil(last.Annotations.PageInfo.EndCursor, "should have no cursor on the last page")
+}
+
+func TestPageArguments(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		maxPage int64
+		vars    map[string]interface{}
+		message string
+	}{
+		{
+			name:    "over the maximum page size",
+			maxPage: 2,
+			vars:    map[string]interface{}{"first": 3},
+			message: "over the maximum page size",
+		},
+		{
+			name:    "default over the maximum page size",
+			maxPage: 5,
+			vars:    map[string]interface{}{},
+			message: "over the maximum page size",
+		},
+		{
+			name:    "zero first",
+			vars:    map[string]interface{}{"first": 0},
+			message: "first should be positive",
+		},
+		{
+			name:    "invalid cursor",
+			vars:    map[string]interface{}{"after": "yesterday"},
+			message: "not a valid cursor",
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			repo := newFakeRepo()
+			hdl := NewHandler(repo, &gateway.Interceptors{}, tst.maxPage)
+			_, res := query(t, hdl, http.MethodPost, gqlBody(t, annotationsQuery, tst.vars))
+			assert.Len(res.Errors, 1, "expect one error")
+			assert.Contains(res.Errors[0].Message, tst.message, "should match the error")
+			assert.Zero(repo.called("ListAnnotations"), "should not query the repository")
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	hdl := NewHandler(newFakeRepo(), &gateway.Interceptors{}, 0)
+	_, res := query(t, hdl, http.MethodPost, gqlBody(t, `{ annotation(id: "404") { id } }`, nil))
+	assert.Empty(res.Errors, "expect no error for a missing annotation")
+	assert.JSONEq(`{"annotation": null}`, string(res.Data), "should resolve to null")
+	_, res = query(t, hdl, http.MethodPost, gqlBody(t, `{ entry(id: "DDB_G0000000") { id annotations { id } } }`, nil))
+	assert.Empty(res.Errors, "expect no error for an entry without annotations")
+	assert.JSONEq(
+		`{"entry": {"id": "DDB_G0000000", "annotations": []}}`,
+		string(res.Data),
+		"should resolve to no annotations",
+	)
+}
+
+func TestEntry(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	repo := newFakeRepo()
+	hdl := NewHandler(repo, &gateway.Interceptors{}, 0)
+	_, res := query(t, hdl, http.MethodPost, gqlBody(t, `{
+		entry(id: "DDB_G0267474") {
+			annotations { id tag { name } }
+			groups { id annotations { id } }
+		}
+	}`, nil))
+	assert.Empty(res.Errors, "expect no error")
+	assert.JSONEq(`{"entry": {
+		"annotations": [{"id": "1", "tag": {"name": "phenotype"}}, {"id": "2", "tag": {"name": "phenotype"}}],
+		"groups": [{"id": "g1", "annotations": [{"id": "1"}, {"id": "2"}]}]
+	}}`, string(res.Data), "should resolve the annotations and groups of the entry")
+	assert.Equal(1, repo.called("GetAnnotationTags"), "should fetch the tags of the entry at once")
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	var method string
+	deny := func(
+		_ context.Context,
+		_ interface{},
+		info *grpc.UnaryServerInfo,
+		_ grpc.UnaryHandler,
+	) (interface{}, error) {
+		method = info.FullMethod
+
+		return nil, status.Error(codes.Unauthenticated, "no credential in request")
+	}
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		icp    *gateway.Interceptors
+		status int
+	}{
+		{
+			name:   "get",
+			method: http.MethodGet,
+			icp:    &gateway.Interceptors{},
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "bad body",
+			method: http.MethodPost,
+			body:   "{query",
+			icp:    &gateway.Interceptors{},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unauthenticated",
+			method: http.MethodPost,
+			body:   `{"query": "{ annotation(id: \"1\") { id } }"}`,
+			icp:    &gateway.Interceptors{Unary: []grpc.UnaryServerInterceptor{deny}},
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tst := range tests {
+		code, res := query(t, NewHandler(newFakeRepo(), tst.icp, 0), tst.method, tst.body)
+		require.Equal(t, tst.status, code, "should match the http status of %s", tst.name)
+		require.Len(t, res.Errors, 1, "should have one error for %s", tst.name)
+	}
+	require.Equal(t, auth.GraphQLMethod, method, "should authorize as the GraphQL method")
+}
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// groupBatch is shared by the groups of one list, the members of all of
+// them resolve their tags and groups together.
+type groupBatch struct {
+	repo    repository.TaggedAnnotationRepository
+	groups  []*model.AnnoGroup
+	once    sync.Once
+	members map[string][]*annotationResolver
+}
+
+type groupResolver struct {
+	group *model.AnnoGroup
+	batch *groupBatch
+}
+
+// newGroupResolvers creates the resolvers of a list of groups sharing one
+// batch.
+func newGroupResolvers(
+	repo repository.TaggedAnnotationRepository,
+	grps []*model.AnnoGroup,
+) []*groupResolver {
+	gbt := &groupBatch{repo: repo, groups: grps}
+	grrs := make([]*groupResolver, 0, len(grps))
+	for _, grp := range grps {
+		grrs = append(grrs, &groupResolver{group: grp, batch: gbt})
+	}
+
+	return grrs
+}
+
+func (grr *groupResolver) ID() gql.ID {
+	return gql.ID(grr.group.GroupId)
+}
+
+func (grr *groupResolver) Name() string {
+	return grr.group.Name
+}
+
+func (grr *groupResolver) Description() string {
+	return grr.group.Description
+}
+
+func (grr *groupResolver) GroupType() string {
+	return grr.group.GroupType
+}
+
+func (grr *groupResolver) GroupOntology() string {
+	return grr.group.GroupOntology
+}
+
+func (grr *groupResolver) CreatedBy() string {
+	return grr.group.CreatedBy
+}
+
+func (grr *groupResolver) UpdatedBy() string {
+	return grr.group.UpdatedBy
+}
+
+func (grr *groupResolver) CreatedAt() gql.Time {
+	return gql.Time{Time: grr.group.CreatedAt}
+}
+
+func (grr *groupResolver) UpdatedAt() gql.Time {
+	return gql.Time{Time: grr.group.UpdatedAt}
+}
+
+func (grr *groupResolver) Version() int32 {
+	return int32(grr.group.Version)
+}
+
+// Annotations resolves the members of the group, the members of every
+// group of the batch share one annotation batch.
+func (grr *groupResolver) Annotations() []*annotationResolver {
+	gbt := grr.batch
+	gbt.once.Do(gbt.loadMembers)
+
+	return gbt.members[grr.group.GroupId]
+}
+
+func (gbt *groupBatch) loadMembers() {
+	var docs []*model.AnnoDoc
+	for _, grp := range gbt.groups {
+		docs = append(docs, grp.AnnoDocs...)
+	}
+	anrs := newAnnotationResolvers(gbt.repo, docs)
+	gbt.members = make(map[string][]*annotationResolver)
+	for _, grp := range gbt.groups {
+		gbt.members[grp.GroupId] = anrs[:len(grp.AnnoDocs):len(grp.AnnoDocs)]
+		anrs = anrs[len(grp.AnnoDocs):]
+	}
+}
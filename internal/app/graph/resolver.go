@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/dictyBase/modware-annotation/internal/app/service"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// defaultLimit is the page size of the connections, same as the list rpcs.
+const defaultLimit = 10
+
+type queryResolver struct {
+	repo    repository.TaggedAnnotationRepository
+	maxPage int64
+}
+
+type idArgs struct {
+	ID gql.ID
+}
+
+type pageArgs struct {
+	First  *int32
+	After  *string
+	Filter *string
+}
+
+type tagArgs struct {
+	Name     string
+	Ontology string
+}
+
+type nameArgs struct {
+	Name string
+}
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+type annotationEdgeResolver struct {
+	node *annotationResolver
+}
+
+type annotationConnectionResolver struct {
+	edges    []*annotationEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+type groupEdgeResolver struct {
+	node *groupResolver
+}
+
+type groupConnectionResolver struct {
+	edges    []*groupEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+type entryResolver struct {
+	id   string
+	repo repository.TaggedAnnotationRepository
+}
+
+func (qrr *queryResolver) Annotation(ctx context.Context, args idArgs) (*annotationResolver, error) {
+	doc, err := qrr.repo.GetAnnotationByID(ctx, string(args.ID))
+	if err != nil {
+		if repository.IsAnnotationNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error in fetching annotation %s", err)
+	}
+
+	return newAnnotationResolvers(qrr.repo, []*model.AnnoDoc{doc})[0], nil
+}
+
+// Annotations pages through the live annotations, the repository fetches
+// one more annotation than the limit to tell whether there is a next page.
+func (qrr *queryResolver) Annotations(
+	ctx context.Context,
+	args pageArgs,
+) (*annotationConnectionResolver, error) {
+	cursor, limit, err := qrr.pageParams(args)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := service.FilterToQuery(stringArg(args.Filter))
+	if err != nil {
+		return nil, err
+	}
+	acr := &annotationConnectionResolver{pageInfo: &pageInfoResolver{}}
+	docs, err := qrr.repo.ListAnnotations(ctx, cursor, limit, stmt)
+	if err != nil {
+		if repository.IsAnnotationListNotFound(err) {
+			return acr, nil
+		}
+
+		return nil, fmt.Errorf("error in listing annotations %s", err)
+	}
+	if int64(len(docs)) > limit {
+		acr.pageInfo = nextPage(docs[limit].CreatedAt.UnixMilli())
+		docs = docs[:limit]
+	}
+	for _, anr := range newAnnotationResolvers(qrr.repo, docs) {
+		acr.edges = append(acr.edges, &annotationEdgeResolver{node: anr})
+	}
+
+	return acr, nil
+}
+
+func (qrr *queryResolver) Entry(args idArgs) *entryResolver {
+	return &entryResolver{id: string(args.ID), repo: qrr.repo}
+}
+
+func (qrr *queryResolver) Group(ctx context.Context, args idArgs) (*groupResolver, error) {
+	grp, err := qrr.repo.GetAnnotationGroup(ctx, string(args.ID))
+	if err != nil {
+		if repository.IsGroupNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error in fetching group %s", err)
+	}
+
+	return newGroupResolvers(qrr.repo, []*model.AnnoGroup{grp})[0], nil
+}
+
+// Groups pages through the annotation groups, one more group than the
+// limit is fetched to tell whether there is a next page.
+func (qrr *queryResolver) Groups(
+	ctx context.Context,
+	args pageArgs,
+) (*groupConnectionResolver, error) {
+	cursor, limit, err := qrr.pageParams(args)
+	if err != nil {
+		return nil, err
+	}
+	gfl, err := service.GroupFilterToQuery(stringArg(args.Filter))
+	if err != nil {
+		return nil, err
+	}
+	gcr := &groupConnectionResolver{pageInfo: &pageInfoResolver{}}
+	grps, err := qrr.repo.ListAnnotationGroupByType(
+		ctx,
+		cursor, limit+1, gfl.Stmt, gfl.Ontology, gfl.Tag,
+	)
+	if err != nil {
+		if repository.IsAnnotationGroupListNotFound(err) {
+			return gcr, nil
+		}
+
+		return nil, fmt.Errorf("error in listing groups %s", err)
+	}
+	if int64(len(grps)) > limit {
+		gcr.pageInfo = nextPage(grps[limit].CreatedAt.UnixMilli())
+		grps = grps[:limit]
+	}
+	for _, grr := range newGroupResolvers(qrr.repo, grps) {
+		gcr.edges = append(gcr.edges, &groupEdgeResolver{node: grr})
+	}
+
+	return gcr, nil
+}
+
+func (qrr *queryResolver) Tag(ctx context.Context, args tagArgs) (*tagResolver, error) {
+	tag, err := qrr.repo.GetAnnotationTag(ctx, args.Name, args.Ontology)
+	if err != nil {
+		if repository.IsAnnoTagNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error in fetching tag %s", err)
+	}
+
+	return newTagResolvers(qrr.repo, []*model.AnnoTag{tag})[0], nil
+}
+
+func (qrr *queryResolver) Ontology(ctx context.Context, args nameArgs) (*ontologyResolver, error) {
+	ontos, err := qrr.repo.GetOntologies(ctx, []string{args.Name})
+	if err != nil {
+		return nil, fmt.Errorf("error in fetching ontology %s", err)
+	}
+	if len(ontos) == 0 {
+		return nil, nil
+	}
+
+	return &ontologyResolver{onto: ontos[0]}, nil
+}
+
+func (enr *entryResolver) ID() gql.ID {
+	return gql.ID(enr.id)
+}
+
+// Annotations resolves the live annotations of the entry.
+func (enr *entryResolver) Annotations(ctx context.Context) ([]*annotationResolver, error) {
+	prof, err := enr.repo.GetEntryProfile(ctx, enr.id)
+	if err != nil {
+		if repository.IsAnnotationNotFound(err) {
+			return []*annotationResolver{}, nil
+		}
+
+		return nil, fmt.Errorf("error in fetching annotations of entry %s", err)
+	}
+	var docs []*model.AnnoDoc
+	for _, tga := range prof.Tags {
+		docs = append(docs, tga.AnnoDocs...)
+	}
+
+	return newAnnotationResolvers(enr.repo, docs), nil
+}
+
+// Groups resolves the groups having any annotation of the entry.
+func (enr *entryResolver) Groups(ctx context.Context) ([]*groupResolver, error) {
+	grps, err := enr.repo.ListGroupsByEntry(ctx, enr.id)
+	if err != nil {
+		if repository.IsAnnotationGroupListNotFound(err) {
+			return []*groupResolver{}, nil
+		}
+
+		return nil, fmt.Errorf("error in fetching groups of entry %s", err)
+	}
+
+	return newGroupResolvers(enr.repo, grps), nil
+}
+
+func (pir *pageInfoResolver) HasNextPage() bool {
+	return pir.hasNext
+}
+
+func (pir *pageInfoResolver) EndCursor() *string {
+	return pir.endCursor
+}
+
+func (acr *annotationConnectionResolver) Edges() []*annotationEdgeResolver {
+	return acr.edges
+}
+
+func (acr *annotationConnectionResolver) PageInfo() *pageInfoResolver {
+	return acr.pageInfo
+}
+
+func (aer *annotationEdgeResolver) Node() *annotationResolver {
+	return aer.node
+}
+
+func (gcr *groupConnectionResolver) Edges() []*groupEdgeResolver {
+	return gcr.edges
+}
+
+func (gcr *groupConnectionResolver) PageInfo() *pageInfoResolver {
+	return gcr.pageInfo
+}
+
+func (ger *groupEdgeResolver) Node() *groupResolver {
+	return ger.node
+}
+
+// nextPage is the page info with the cursor of the next page, the creation
+// time in epoch milliseconds of its first item.
+func nextPage(cursor int64) *pageInfoResolver {
+	end := strconv.FormatInt(cursor, 10)
+
+	return &pageInfoResolver{hasNext: true, endCursor: &end}
+}
+
+func (qrr *queryResolver) pageParams(args pageArgs) (int64, int64, error) {
+	limit := int64(defaultLimit)
+	if args.First != nil {
+		if *args.First <= 0 {
+			return 0, 0, fmt.Errorf("first should be positive")
+		}
+		limit = int64(*args.First)
+	}
+	if qrr.maxPage > 0 && limit > qrr.maxPage {
+		return 0, 0, fmt.Errorf("first %d is over the maximum page size, use at most %d", limit, qrr.maxPage)
+	}
+	var cursor int64
+	if args.After != nil {
+		crs, err := strconv.ParseInt(*args.After, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("after %s is not a valid cursor", *args.After)
+		}
+		cursor = crs
+	}
+
+	return cursor, limit, nil
+}
+
+func stringArg(arg *string) string {
+	if arg == nil {
+		return ""
+	}
+
+	return *arg
+}
@@ -0,0 +1,94 @@
+package graph
+
+// schema is the GraphQL read api of annotations. The connections page the
+// same way as the list rpcs, newest first, endCursor is the cursor of the
+// next page and is passed back as after.
+const schema = `
+schema {
+	query: Query
+}
+
+scalar Time
+
+type Query {
+	annotation(id: ID!): Annotation
+	annotations(first: Int, after: String, filter: String): AnnotationConnection!
+	entry(id: ID!): Entry!
+	group(id: ID!): Group
+	groups(first: Int, after: String, filter: String): GroupConnection!
+	tag(name: String!, ontology: String!): Tag
+	ontology(name: String!): Ontology
+}
+
+type Annotation {
+	id: ID!
+	value: String!
+	editableValue: String!
+	createdBy: String!
+	createdAt: Time!
+	entryId: String!
+	rank: Int!
+	version: Int!
+	isObsolete: Boolean!
+	tag: Tag!
+	groups: [Group!]!
+}
+
+type Tag {
+	id: ID!
+	name: String!
+	isObsolete: Boolean!
+	ontology: Ontology!
+}
+
+type Ontology {
+	id: ID!
+	name: String!
+	iri: String!
+	label: String!
+	version: String!
+}
+
+type Group {
+	id: ID!
+	name: String!
+	description: String!
+	groupType: String!
+	groupOntology: String!
+	createdBy: String!
+	updatedBy: String!
+	createdAt: Time!
+	updatedAt: Time!
+	version: Int!
+	annotations: [Annotation!]!
+}
+
+type Entry {
+	id: ID!
+	annotations: [Annotation!]!
+	groups: [Group!]!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+
+type AnnotationEdge {
+	node: Annotation!
+}
+
+type AnnotationConnection {
+	edges: [AnnotationEdge!]!
+	pageInfo: PageInfo!
+}
+
+type GroupEdge {
+	node: Group!
+}
+
+type GroupConnection {
+	edges: [GroupEdge!]!
+	pageInfo: PageInfo!
+}
+`
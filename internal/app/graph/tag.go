@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// tagBatch is shared by the tags of one list, the ontologies of all of
+// them are fetched in one query when the first one is resolved.
+type tagBatch struct {
+	repo    repository.TaggedAnnotationRepository
+	tags    []*model.AnnoTag
+	once    sync.Once
+	ontos   map[string]*ontologyResolver
+	ontoErr error
+}
+
+type tagResolver struct {
+	tag   *model.AnnoTag
+	batch *tagBatch
+}
+
+type ontologyResolver struct {
+	onto *model.Ontology
+}
+
+// newTagResolvers creates the resolvers of a list of tags sharing one batch.
+func newTagResolvers(
+	repo repository.TaggedAnnotationRepository,
+	tags []*model.AnnoTag,
+) []*tagResolver {
+	tbt := &tagBatch{repo: repo, tags: tags}
+	tgrs := make([]*tagResolver, 0, len(tags))
+	for _, tag := range tags {
+		tgrs = append(tgrs, &tagResolver{tag: tag, batch: tbt})
+	}
+
+	return tgrs
+}
+
+func (tgr *tagResolver) ID() gql.ID {
+	return gql.ID(tgr.tag.ID)
+}
+
+func (tgr *tagResolver) Name() string {
+	return tgr.tag.Name
+}
+
+func (tgr *tagResolver) IsObsolete() bool {
+	return tgr.tag.IsObsolete
+}
+
+func (tgr *tagResolver) Ontology(ctx context.Context) (*ontologyResolver, error) {
+	tbt := tgr.batch
+	tbt.once.Do(func() { tbt.loadOntologies(ctx) })
+	if tbt.ontoErr != nil {
+		return nil, tbt.ontoErr
+	}
+	onr, ok := tbt.ontos[tgr.tag.Ontology]
+	if !ok {
+		return nil, fmt.Errorf("ontology %s is not found", tgr.tag.Ontology)
+	}
+
+	return onr, nil
+}
+
+func (tbt *tagBatch) loadOntologies(ctx context.Context) {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, tag := range tbt.tags {
+		if seen[tag.Ontology] {
+			continue
+		}
+		seen[tag.Ontology] = true
+		names = append(names, tag.Ontology)
+	}
+	ontos, err := tbt.repo.GetOntologies(ctx, names)
+	if err != nil {
+		tbt.ontoErr = fmt.Errorf("error in fetching ontologies %s", err)
+
+		return
+	}
+	tbt.ontos = make(map[string]*ontologyResolver)
+	for _, onto := range ontos {
+		tbt.ontos[onto.Namespace] = &ontologyResolver{onto: onto}
+	}
+}
+
+func (onr *ontologyResolver) ID() gql.ID {
+	return gql.ID(onr.onto.ID)
+}
+
+func (onr *ontologyResolver) Name() string {
+	return onr.onto.Namespace
+}
+
+func (onr *ontologyResolver) Iri() string {
+	return onr.onto.IRI
+}
+
+func (onr *ontologyResolver) Label() string {
+	return onr.onto.Label
+}
+
+func (onr *ontologyResolver) Version() string {
+	return onr.onto.Version
+}
@@ -0,0 +1,141 @@
+// Package health implements the grpc.health.v1 service with a liveness
+// status that only reflects the running process and a readiness status
+// that checks the backends of the server.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Liveness is the service name of the liveness status
+	Liveness = "liveness"
+	// Readiness is the service name of the readiness status
+	Readiness = "readiness"
+)
+
+// Checker reports whether a backend is usable.
+type Checker func(ctx context.Context) error
+
+// Server answers health checks. The empty service name and Liveness are
+// always serving, Readiness and every ready service are serving only when
+// all checks pass.
+type Server struct {
+	healthpb.UnimplementedHealthServer
+	checks   map[string]Checker
+	services map[string]bool
+	timeout  time.Duration
+	interval time.Duration
+	// shutdown is set to one once the server starts shutting down
+	shutdown int32
+}
+
+// NewServer returns a Server running the named checks with a timeout for
+// readiness. The services are the names, besides Readiness, that report
+// the readiness status.
+func NewServer(
+	checks map[string]Checker,
+	timeout time.Duration,
+	services ...string,
+) *Server {
+	srv := &Server{
+		checks:   checks,
+		services: map[string]bool{Readiness: true},
+		timeout:  timeout,
+		interval: timeout,
+	}
+	for _, name := range services {
+		srv.services[name] = true
+	}
+
+	return srv
+}
+
+func (s *Server) Check(
+	ctx context.Context,
+	req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+	st, err := s.status(ctx, req.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch checks the status at every interval and sends it whenever it
+// changes.
+func (s *Server) Watch(
+	req *healthpb.HealthCheckRequest,
+	stream healthpb.Health_WatchServer,
+) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		st, err := s.status(stream.Context(), req.Service)
+		if err != nil {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) status(
+	ctx context.Context,
+	service string,
+) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	switch {
+	case service == "" || service == Liveness:
+		return healthpb.HealthCheckResponse_SERVING, nil
+	case s.services[service]:
+		if err := s.Ready(ctx); err != nil {
+			return healthpb.HealthCheckResponse_NOT_SERVING, nil
+		}
+
+		return healthpb.HealthCheckResponse_SERVING, nil
+	}
+
+	return healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+		status.Errorf(codes.NotFound, "unknown service %s", service)
+}
+
+// Shutdown makes the readiness not serving for good, so that no new
+// requests are routed to a server that is draining.
+func (s *Server) Shutdown() {
+	atomic.StoreInt32(&s.shutdown, 1)
+}
+
+// Ready runs all checks and returns the first failure.
+func (s *Server) Ready(ctx context.Context) error {
+	if atomic.LoadInt32(&s.shutdown) == 1 {
+		return errors.New("server is shutting down")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	for name, check := range s.checks {
+		if err := check(ctx); err != nil {
+			return fmt.Errorf("%s is not ready %s", name, err)
+		}
+	}
+
+	return nil
+}
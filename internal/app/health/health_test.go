@@ -0,0 +1,176 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func passing(context.Context) error { return nil }
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		checks   map[string]Checker
+		service  string
+		shutdown bool
+		status   healthpb.HealthCheckResponse_ServingStatus
+		code     codes.Code
+	}{
+		{
+			name:    "server with failing backend",
+			checks:  map[string]Checker{"arangodb": failing},
+			service: "",
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:    "liveness with failing backend",
+			checks:  map[string]Checker{"arangodb": failing},
+			service: Liveness,
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:    "readiness",
+			checks:  map[string]Checker{"arangodb": passing, "nats": passing},
+			service: Readiness,
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:    "readiness with one failing backend",
+			checks:  map[string]Checker{"arangodb": passing, "nats": failing},
+			service: Readiness,
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:    "ready service",
+			checks:  map[string]Checker{"arangodb": failing},
+			service: "dictybase.annotation.TaggedAnnotationService",
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:     "readiness while shutting down",
+			checks:   map[string]Checker{"arangodb": passing},
+			service:  Readiness,
+			shutdown: true,
+			status:   healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:     "liveness while shutting down",
+			checks:   map[string]Checker{"arangodb": passing},
+			service:  Liveness,
+			shutdown: true,
+			status:   healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:    "unknown service",
+			checks:  map[string]Checker{"arangodb": passing},
+			service: "dictybase.stock.StockService",
+			code:    codes.NotFound,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			srv := NewServer(tst.checks, time.Second, "dictybase.annotation.TaggedAnnotationService")
+			if tst.shutdown {
+				srv.Shutdown()
+			}
+			res, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tst.service})
+			assert.Equal(tst.code, status.Code(err), "should match the status code")
+			if err == nil {
+				assert.Equal(tst.status, res.Status, "should match the serving status")
+			}
+		})
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+	srv := NewServer(map[string]Checker{"arangodb": slow}, 10*time.Millisecond)
+	start := time.Now()
+	err := srv.Ready(context.Background())
+	assert.Error(err, "expect error from a backend past the timeout")
+	assert.Contains(err.Error(), "arangodb is not ready", "should name the backend")
+	assert.Less(time.Since(start), time.Second, "should not wait past the timeout")
+}
+
+// watchStream collects the statuses sent to a watcher.
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	mu   sync.Mutex
+	sent []healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (ws *watchStream) Context() context.Context {
+	return ws.ctx
+}
+
+func (ws *watchStream) Send(res *healthpb.HealthCheckResponse) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.sent = append(ws.sent, res.Status)
+
+	return nil
+}
+
+func (ws *watchStream) statuses() []healthpb.HealthCheckResponse_ServingStatus {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return append([]healthpb.HealthCheckResponse_ServingStatus{}, ws.sent...)
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	var down int32
+	check := func(context.Context) error {
+		if atomic.LoadInt32(&down) == 1 {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	}
+	srv := NewServer(map[string]Checker{"nats": check}, time.Second)
+	srv.interval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Watch(&healthpb.HealthCheckRequest{Service: Readiness}, stream)
+	}()
+	assert.Eventually(func() bool { return len(stream.statuses()) == 1 }, time.Second, time.Millisecond)
+	atomic.StoreInt32(&down, 1)
+	assert.Eventually(func() bool { return len(stream.statuses()) == 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(codes.Canceled, status.Code(<-done), "should end with the stream")
+	assert.Equal(
+		[]healthpb.HealthCheckResponse_ServingStatus{
+			healthpb.HealthCheckResponse_SERVING,
+			healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		stream.statuses(),
+		"should only send changes of the status",
+	)
+}
@@ -0,0 +1,199 @@
+// Package ratelimit limits the rate of requests of every client address and
+// every authenticated caller of the grpc server.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// idleTimeout is how long the bucket of a caller is kept after its last
+// request.
+const idleTimeout = 10 * time.Minute
+
+// RetryAfterHeader is the response header carrying the seconds to wait
+// before retrying a limited request.
+const RetryAfterHeader = "retry-after"
+
+// Params sets the rates of the limiter, a zero rate turns off its limit.
+type Params struct {
+	// IPRate is the requests per second of a client address
+	IPRate float64
+	// IdentityRate is the requests per second of an authenticated caller
+	IdentityRate float64
+	// Burst is the number of requests allowed at once above the rates
+	Burst int
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// Limiter keeps a token bucket for every client address and caller. The
+// address is always limited, the caller only after authentication, so it
+// has to run after the authentication interceptor.
+type Limiter struct {
+	params    *Params
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	// now is the clock of the buckets, replaced in tests
+	now func() time.Time
+}
+
+// NewLimiter creates a Limiter with the given rates.
+func NewLimiter(params *Params) *Limiter {
+	return &Limiter{
+		params:    params,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Unary returns the interceptor for unary rpcs.
+func (lmt *Limiter) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := lmt.allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the interceptor for streaming rpcs.
+func (lmt *Limiter) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := lmt.allow(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// allow takes a token from the bucket of the client address and of the
+// caller, public methods are never limited.
+func (lmt *Limiter) allow(ctx context.Context, method string) error {
+	if auth.IsPublic(method) {
+		return nil
+	}
+	now := lmt.now()
+	if lmt.params.IPRate > 0 {
+		if prr, ok := peer.FromContext(ctx); ok && prr.Addr != nil {
+			key := "ip:" + host(prr.Addr)
+			if delay := lmt.reserve(key, lmt.params.IPRate, now); delay > 0 {
+				return exhausted(ctx, "client address", delay)
+			}
+		}
+	}
+	if lmt.params.IdentityRate > 0 {
+		if idn, ok := auth.FromContext(ctx); ok {
+			key := "identity:" + idn.Subject
+			if delay := lmt.reserve(key, lmt.params.IdentityRate, now); delay > 0 {
+				return exhausted(ctx, "caller", delay)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reserve takes a token from a bucket, it returns how long to wait for the
+// token when the bucket is empty and leaves the bucket untouched then.
+func (lmt *Limiter) reserve(key string, rps float64, now time.Time) time.Duration {
+	lmt.mu.Lock()
+	defer lmt.mu.Unlock()
+	lmt.sweep(now)
+	bkt, ok := lmt.buckets[key]
+	if !ok {
+		bkt = &bucket{limiter: rate.NewLimiter(rate.Limit(rps), lmt.params.Burst)}
+		lmt.buckets[key] = bkt
+	}
+	bkt.seen = now
+	rsv := bkt.limiter.ReserveN(now, 1)
+	delay := rsv.DelayFrom(now)
+	if delay > 0 {
+		rsv.CancelAt(now)
+	}
+
+	return delay
+}
+
+// sweep drops the buckets of callers that went idle, at most once in the
+// idle timeout.
+func (lmt *Limiter) sweep(now time.Time) {
+	if now.Sub(lmt.lastSweep) < idleTimeout {
+		return
+	}
+	for key, bkt := range lmt.buckets {
+		if now.Sub(bkt.seen) >= idleTimeout {
+			delete(lmt.buckets, key)
+		}
+	}
+	lmt.lastSweep = now
+}
+
+// exhausted is the error of a limited request, it carries the delay as
+// retry info and in the retry-after header.
+func exhausted(ctx context.Context, subject string, delay time.Duration) error {
+	secs := strconv.Itoa(int(math.Ceil(delay.Seconds())))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, secs))
+	sts := status.New(
+		codes.ResourceExhausted,
+		fmt.Sprintf("rate limit of the %s is exceeded, retry after %ss", subject, secs),
+	)
+	dts, err := sts.WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)},
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     subject,
+				Description: "requests per second",
+			}},
+		},
+	)
+	if err != nil {
+		return sts.Err()
+	}
+
+	return dts.Err()
+}
+
+func host(addr net.Addr) string {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP.String()
+	}
+	hst, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return hst
+}
@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const getMethod = "/dictybase.annotation.TaggedAnnotationService/GetAnnotation"
+
+// clock is a manual clock for the limiter.
+type clock struct {
+	now time.Time
+}
+
+func (clk *clock) Now() time.Time {
+	return clk.now
+}
+
+func (clk *clock) advance(dur time.Duration) {
+	clk.now = clk.now.Add(dur)
+}
+
+// headerStream collects the headers set by the interceptor.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (hst *headerStream) Method() string {
+	return getMethod
+}
+
+func (hst *headerStream) SetHeader(mdt metadata.MD) error {
+	hst.header = metadata.Join(hst.header, mdt)
+
+	return nil
+}
+
+func (hst *headerStream) SendHeader(mdt metadata.MD) error {
+	return hst.SetHeader(mdt)
+}
+
+func (hst *headerStream) SetTrailer(metadata.MD) error {
+	return nil
+}
+
+func newTestLimiter(params *Params) (*Limiter, *clock) {
+	clk := &clock{now: time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)}
+	lmt := NewLimiter(params)
+	lmt.now = clk.Now
+	lmt.lastSweep = clk.now
+
+	return lmt, clk
+}
+
+func callerContext(ip, subject string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 52100},
+	})
+	if len(subject) > 0 {
+		ctx = auth.NewContext(ctx, &auth.Identity{Subject: subject, Role: auth.RoleReader})
+	}
+
+	return ctx
+}
+
+// call runs a request through the interceptor, it returns the retry-after
+// header and the error.
+func call(ctx context.Context, lmt *Limiter, method string) (string, error) {
+	hst := &headerStream{header: metadata.MD{}}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, hst)
+	_, err := lmt.Unary()(
+		ctx,
+		nil,
+		&grpc.UnaryServerInfo{FullMethod: method},
+		func(context.Context, interface{}) (interface{}, error) { return "done", nil },
+	)
+	retry := ""
+	if vals := hst.header.Get(RetryAfterHeader); len(vals) > 0 {
+		retry = vals[0]
+	}
+
+	return retry, err
+}
+
+func TestReserve(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	lmt, clk := newTestLimiter(&Params{IPRate: 0.5, Burst: 2})
+	ctx := callerContext("192.0.2.10", "")
+	for i := 0; i < 2; i++ {
+		_, err := call(ctx, lmt, getMethod)
+		assert.NoError(err, "should allow the burst")
+	}
+	retry, err := call(ctx, lmt, getMethod)
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "should limit past the burst")
+	assert.Equal("2", retry, "should ask to retry once a token is back")
+	var rti *errdetails.RetryInfo
+	for _, dtl := range status.Convert(err).Details() {
+		if val, ok := dtl.(*errdetails.RetryInfo); ok {
+			rti = val
+		}
+	}
+	assert.NotNil(rti, "should carry the retry info")
+	assert.Equal(2*time.Second, rti.RetryDelay.AsDuration(), "should match the delay")
+
+	clk.advance(time.Second)
+	retry, err = call(ctx, lmt, getMethod)
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "should still limit half way")
+	assert.Equal("1", retry, "should round the rest of the delay up")
+
+	clk.advance(time.Second)
+	_, err = call(ctx, lmt, getMethod)
+	assert.NoError(err, "should allow once the token is back, limited requests take no token")
+	_, err = call(ctx, lmt, getMethod)
+	assert.Equal(codes.ResourceExhausted, status.Code(err), "should limit again")
+
+	_, err = call(callerContext("192.0.2.11", ""), lmt, getMethod)
+	assert.NoError(err, "should keep a bucket per client address")
+	_, err = call(ctx, lmt, "/grpc.health.v1.Health/Check")
+	assert.NoError(err, "should never limit public methods")
+}
+
+func TestReserveIdentity(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		params  *Params
+		first   context.Context
+		second  context.Context
+		limited bool
+	}{
+		{
+			name:    "caller from two addresses",
+			params:  &Params{IdentityRate: 1, Burst: 1},
+			first:   callerContext("192.0.2.10", "pfey@gmail.com"),
+			second:  callerContext("192.0.2.11", "pfey@gmail.com"),
+			limited: true,
+		},
+		{
+			name:   "two callers",
+			params: &Params{IdentityRate: 1, Burst: 1},
+			first:  callerContext("192.0.2.10", "pfey@gmail.com"),
+			second: callerContext("192.0.2.10", "loader"),
+		},
+		{
+			name:   "unauthenticated callers",
+			params: &Params{IdentityRate: 1, Burst: 1},
+			first:  callerContext("192.0.2.10", ""),
+			second: callerContext("192.0.2.10", ""),
+		},
+		{
+			name:    "caller behind a limited address",
+			params:  &Params{IPRate: 1, IdentityRate: 100, Burst: 1},
+			first:   callerContext("192.0.2.10", "pfey@gmail.com"),
+			second:  callerContext("192.0.2.10", "loader"),
+			limited: true,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			lmt, _ := newTestLimiter(tst.params)
+			_, err := call(tst.first, lmt, getMethod)
+			assert.NoError(err, "should allow the first request")
+			_, err = call(tst.second, lmt, getMethod)
+			if tst.limited {
+				assert.Equal(codes.ResourceExhausted, status.Code(err), "should share the bucket")
+
+				return
+			}
+			assert.NoError(err, "should not share the bucket")
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	lmt, clk := newTestLimiter(&Params{IPRate: 1, Burst: 1})
+	_, err := call(callerContext("192.0.2.10", ""), lmt, getMethod)
+	assert.NoError(err)
+	clk.advance(idleTimeout / 2)
+	_, err = call(callerContext("192.0.2.11", ""), lmt, getMethod)
+	assert.NoError(err)
+	assert.Len(lmt.buckets, 2, "should keep the buckets before the idle timeout")
+
+	clk.advance(idleTimeout / 2)
+	_, err = call(callerContext("192.0.2.12", ""), lmt, getMethod)
+	assert.NoError(err)
+	assert.Len(lmt.buckets, 2, "should drop the bucket idle for the timeout")
+	assert.NotContains(lmt.buckets, "ip:192.0.2.10", "should drop the idle address")
+	assert.Contains(lmt.buckets, "ip:192.0.2.11", "should keep the recent address")
+
+	clk.advance(idleTimeout / 2)
+	_, err = call(callerContext("192.0.2.13", ""), lmt, getMethod)
+	assert.NoError(err)
+	assert.Len(lmt.buckets, 3, "should sweep at most once in the idle timeout")
+
+	clk.advance(idleTimeout / 2)
+	_, err = call(callerContext("192.0.2.12", ""), lmt, getMethod)
+	assert.NoError(err, "should allow a request after the bucket refilled")
+	assert.Equal(
+		[]string{"ip:192.0.2.12", "ip:192.0.2.13"},
+		sortedKeys(lmt),
+		"should drop every bucket idle for the timeout",
+	)
+}
+
+func sortedKeys(lmt *Limiter) []string {
+	keys := make([]string, 0, len(lmt.buckets))
+	for key := range lmt.buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository/arangodb"
+	"github.com/urfave/cli"
+)
+
+const exportPageSize = 1000
+
+// ExportAudit writes the audit log entries matching the command line
+// filters as json lines, newest first. SIGTERM or SIGINT aborts the export.
+func ExportAudit(clt *cli.Context) error {
+	filter, err := auditFilter(clt)
+	if err != nil {
+		return cli.NewExitError(err.Error(), errCode)
+	}
+	anrepo, err := arangodb.NewTaggedAnnotationRepo(allParams(clt))
+	if err != nil {
+		return cli.NewExitError(
+			fmt.Sprintf("cannot connect to arangodb annotation repository %s", err),
+			errCode,
+		)
+	}
+	var out io.Writer = os.Stdout
+	if output := clt.String("output"); output != "-" {
+		fhr, err := os.Create(output)
+		if err != nil {
+			return cli.NewExitError(
+				fmt.Sprintf("error in creating output file %s", err),
+				errCode,
+			)
+		}
+		defer fhr.Close()
+		out = fhr
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	enc := json.NewEncoder(out)
+	for {
+		entries, err := anrepo.ListAuditEntries(ctx, filter)
+		if err != nil {
+			return cli.NewExitError(err.Error(), errCode)
+		}
+		page := entries
+		if int64(len(entries)) > filter.Limit {
+			page = entries[:filter.Limit]
+		}
+		for _, ent := range page {
+			if err := enc.Encode(ent); err != nil {
+				return cli.NewExitError(
+					fmt.Sprintf("error in writing audit entry %s", err),
+					errCode,
+				)
+			}
+		}
+		if int64(len(entries)) <= filter.Limit {
+			break
+		}
+		filter.Cursor, filter.CursorKey = model.AuditCursor(page[len(page)-1])
+	}
+
+	return nil
+}
+
+func auditFilter(clt *cli.Context) (*model.AuditFilter, error) {
+	filter := &model.AuditFilter{
+		Actor:   clt.String("actor"),
+		EntryId: clt.String("entry-id"),
+		Limit:   exportPageSize,
+	}
+	for flag, tptr := range map[string]*time.Time{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	} {
+		if len(clt.String(flag)) == 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, clt.String(flag))
+		if err != nil {
+			return filter, fmt.Errorf("error in parsing %s %s", flag, err)
+		}
+		*tptr = t
+	}
+
+	return filter, nil
+}
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
@@ -12,17 +15,30 @@ import (
 	manager "github.com/dictyBase/arangomanager"
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 	ontoarango "github.com/dictyBase/go-obograph/storage/arangodb"
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/app/gateway"
+	"github.com/dictyBase/modware-annotation/internal/app/graph"
+	"github.com/dictyBase/modware-annotation/internal/app/health"
+	"github.com/dictyBase/modware-annotation/internal/app/ratelimit"
 	"github.com/dictyBase/modware-annotation/internal/app/service"
 	"github.com/dictyBase/modware-annotation/internal/message"
 	"github.com/dictyBase/modware-annotation/internal/message/nats"
+	"github.com/dictyBase/modware-annotation/internal/metrics"
 	"github.com/dictyBase/modware-annotation/internal/repository"
 	"github.com/dictyBase/modware-annotation/internal/repository/arangodb"
+	"github.com/dictyBase/modware-annotation/internal/repository/audit"
+	"github.com/dictyBase/modware-annotation/internal/tracing"
 	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	gnats "github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -35,27 +51,69 @@ type serverParams struct {
 }
 
 func RunServer(clt *cli.Context) error {
-	spn, err := repoAndNatsConn(clt)
+	var reg *prometheus.Registry
+	if len(clt.String("metrics-port")) > 0 {
+		reg = prometheus.NewRegistry()
+		reg.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), &tracing.Params{
+		Exporter: clt.String("tracing-exporter"),
+		Endpoint: clt.String("otlp-endpoint"),
+		Insecure: clt.Bool("otlp-insecure"),
+	})
 	if err != nil {
 		return cli.NewExitError(err.Error(), errCode)
 	}
-	grpcS := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			grpc_ctxtags.UnaryServerInterceptor(),
-			grpc_logrus.UnaryServerInterceptor(getLogger(clt)),
-		),
-	)
+	logger := getLogger(clt)
+	defer flushSpans(shutdownTracing, clt.Duration("shutdown-timeout"), logger)
+	spn, err := repoAndNatsConn(clt, reg, logger)
+	if err != nil {
+		return cli.NewExitError(err.Error(), errCode)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	icp, err := interceptors(clt, logger, reg)
+	if err != nil {
+		return cli.NewExitError(err.Error(), errCode)
+	}
+	opts, tlsConf, err := serverOptions(clt, icp, logger, done)
+	if err != nil {
+		return cli.NewExitError(err.Error(), errCode)
+	}
+	if reg != nil {
+		msrv := serveMetrics(clt, spn.repo, reg, done, logger)
+		defer msrv.Close()
+	}
+	grpcS := grpc.NewServer(opts...)
 	srv, err := service.NewAnnotationService(
 		&service.Params{
-			Repository: spn.repo,
-			Publisher:  spn.msg,
-			Group:      "groups",
-			Options:    getGrpcOpt(),
+			Repository:    spn.repo,
+			Publisher:     spn.msg,
+			Group:         "groups",
+			Options:       getGrpcOpt(),
+			MaxPageSize:   clt.Int64("max-page-size"),
+			MaxUploadSize: clt.Int64("max-upload-size"),
 		})
 	if err != nil {
 		return cli.NewExitError(err.Error(), errCode)
 	}
 	annotation.RegisterTaggedAnnotationServiceServer(grpcS, srv)
+	hsrv := health.NewServer(
+		map[string]health.Checker{
+			"arangodb": func(ctx context.Context) error {
+				_, err := spn.repo.Dbh().Handler().Info(ctx)
+
+				return err
+			},
+			"nats": spn.msg.Check,
+		},
+		clt.Duration("health-timeout"),
+		annotation.TaggedAnnotationService_ServiceDesc.ServiceName,
+	)
+	healthpb.RegisterHealthServer(grpcS, hsrv)
 	reflection.Register(grpcS)
 	// create listener
 	endP := fmt.Sprintf(":%s", clt.String("port"))
@@ -65,14 +123,204 @@ func RunServer(clt *cli.Context) error {
 			fmt.Sprintf("failed to listen %s", err), errCode,
 		)
 	}
+	var gtwS *http.Server
+	if len(clt.String("http-port")) > 0 {
+		gtwS = serveGateway(clt, httpHandler(clt, srv, spn.repo, icp), tlsConf, logger)
+	}
 	log.Printf("starting grpc server on %s", endP)
-	if err := grpcS.Serve(lis); err != nil {
+	if err := serveUntilSignal(grpcS, gtwS, lis, hsrv, clt.Duration("shutdown-timeout"), logger); err != nil {
+		_ = closeBackends(spn)
+
+		return cli.NewExitError(err.Error(), errCode)
+	}
+	if err := closeBackends(spn); err != nil {
 		return cli.NewExitError(err.Error(), errCode)
 	}
 
 	return nil
 }
 
+// interceptors builds the interceptors shared by the grpc server and the
+// http gateway. Request metrics are recorded unless the registry is nil,
+// requests are rate limited when any rate is set.
+func interceptors(
+	clt *cli.Context,
+	logger *logrus.Entry,
+	reg *prometheus.Registry,
+) (*gateway.Interceptors, error) {
+	icp := &gateway.Interceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			grpc_ctxtags.UnaryServerInterceptor(),
+			grpc_logrus.UnaryServerInterceptor(logger),
+		},
+	}
+	if reg != nil {
+		smt := metrics.NewServerMetrics(reg)
+		icp.Unary = append(icp.Unary, smt.Unary())
+		icp.Stream = append(icp.Stream, smt.Stream())
+	}
+	authn, err := authInterceptor(clt)
+	if err != nil {
+		return nil, err
+	}
+	if authn != nil {
+		icp.Unary = append(icp.Unary, authn.Unary())
+		icp.Stream = append(icp.Stream, authn.Stream())
+	}
+	// the limiter runs after authentication to know the caller
+	if clt.Float64("rate-limit-ip") > 0 || clt.Float64("rate-limit-identity") > 0 {
+		lmt := ratelimit.NewLimiter(&ratelimit.Params{
+			IPRate:       clt.Float64("rate-limit-ip"),
+			IdentityRate: clt.Float64("rate-limit-identity"),
+			Burst:        clt.Int("rate-limit-burst"),
+		})
+		icp.Unary = append(icp.Unary, lmt.Unary())
+		icp.Stream = append(icp.Stream, lmt.Stream())
+	}
+
+	return icp, nil
+}
+
+// serverOptions builds the tracing handler, the interceptors and the
+// transport credentials of the grpc server. The TLS configuration is nil
+// without a certificate, reloading of certificates stops when done is closed.
+func serverOptions(
+	clt *cli.Context,
+	icp *gateway.Interceptors,
+	logger *logrus.Entry,
+	done <-chan struct{},
+) ([]grpc.ServerOption, *tls.Config, error) {
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(icp.Unary...),
+		grpc.ChainStreamInterceptor(icp.Stream...),
+	}
+	if len(clt.String("tls-cert")) == 0 {
+		return opts, nil, nil
+	}
+	crl, err := newCertReloader(
+		clt.String("tls-cert"),
+		clt.String("tls-key"),
+		clt.String("tls-client-ca"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	go crl.watch(clt.Duration("tls-reload-interval"), done, logger)
+	tlsConf := crl.config()
+
+	return append(opts, grpc.Creds(credentials.NewTLS(tlsConf))), tlsConf, nil
+}
+
+// httpHandler serves the REST endpoints along with the GraphQL api when it
+// is enabled.
+func httpHandler(
+	clt *cli.Context,
+	srv *service.AnnotationService,
+	repo repository.TaggedAnnotationRepository,
+	icp *gateway.Interceptors,
+) http.Handler {
+	gtw := gateway.NewHandler(srv, icp)
+	if !clt.Bool("graphql") {
+		return gtw
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", graph.NewHandler(repo, icp, clt.Int64("max-page-size")))
+	mux.Handle("/", gtw)
+
+	return mux
+}
+
+// serveGateway starts the http server of the REST endpoints, it uses TLS
+// when the configuration is not nil.
+func serveGateway(
+	clt *cli.Context,
+	handler http.Handler,
+	tlsConf *tls.Config,
+	logger *logrus.Entry,
+) *http.Server {
+	gtwS := &http.Server{
+		Addr:              fmt.Sprintf(":%s", clt.String("http-port")),
+		Handler:           handler,
+		TLSConfig:         tlsConf,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		var err error
+		if tlsConf != nil {
+			err = gtwS.ListenAndServeTLS("", "")
+		} else {
+			err = gtwS.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Errorf("http gateway has failed %s", err)
+		}
+	}()
+	log.Printf("starting http gateway on %s", gtwS.Addr)
+
+	return gtwS
+}
+
+// serveMetrics starts the http server of the metrics endpoint along with
+// the refresh of the annotation counts, which stops when done is closed.
+func serveMetrics(
+	clt *cli.Context,
+	repo repository.TaggedAnnotationRepository,
+	reg *prometheus.Registry,
+	done <-chan struct{},
+	logger *logrus.Entry,
+) *http.Server {
+	occ := metrics.NewOntologyCounts(repo, reg)
+	go occ.Watch(clt.Duration("metrics-count-interval"), done, logger)
+	msrv := metrics.NewServer(fmt.Sprintf(":%s", clt.String("metrics-port")), reg)
+	go func() {
+		if err := metrics.ListenAndServe(msrv); err != nil {
+			logger.Errorf("metrics server has failed %s", err)
+		}
+	}()
+	log.Printf("starting metrics server on %s", msrv.Addr)
+
+	return msrv
+}
+
+// authInterceptor builds the authentication interceptor from the jwks and
+// api key files and the client CA of mutual TLS, it is nil when none is
+// given. Client certificates are tried last as every mutual TLS request
+// carries one.
+func authInterceptor(clt *cli.Context) (*auth.Interceptor, error) {
+	var authrs []auth.Authenticator
+	if len(clt.String("jwks-file")) > 0 {
+		jwta, err := auth.NewJWTAuthenticator(
+			clt.String("jwks-file"),
+			clt.String("jwt-issuer"),
+			clt.String("jwt-audience"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		authrs = append(authrs, jwta)
+	}
+	if len(clt.String("api-keys-file")) > 0 {
+		apia, err := auth.NewAPIKeyAuthenticator(clt.String("api-keys-file"))
+		if err != nil {
+			return nil, err
+		}
+		authrs = append(authrs, apia)
+	}
+	if len(clt.String("tls-client-ca")) > 0 {
+		role, err := auth.ParseRole(clt.String("client-cert-role"))
+		if err != nil {
+			return nil, err
+		}
+		authrs = append(authrs, auth.NewCertAuthenticator(role))
+	}
+	if len(authrs) == 0 {
+		return nil, nil
+	}
+
+	return auth.NewInterceptor(authrs...), nil
+}
+
 func getLogger(clt *cli.Context) *logrus.Entry {
 	log := logrus.New()
 	log.Out = os.Stderr
@@ -116,13 +364,16 @@ func allParams(
 			Port:     arPort,
 			Istls:    clt.Bool("is-secure"),
 		}, &arangodb.CollectionParams{
-			Annotation:   clt.String("anno-collection"),
-			AnnoTerm:     clt.String("annoterm-collection"),
-			AnnoVersion:  clt.String("annover-collection"),
-			AnnoTagGraph: clt.String("annoterm-graph"),
-			AnnoVerGraph: clt.String("annover-graph"),
-			AnnoGroup:    clt.String("annogroup-collection"),
-			AnnoIndexes:  clt.StringSlice("annotation-index-fields"),
+			Annotation:       clt.String("anno-collection"),
+			AnnoTerm:         clt.String("annoterm-collection"),
+			AnnoVersion:      clt.String("annover-collection"),
+			AnnoTagGraph:     clt.String("annoterm-graph"),
+			AnnoVerGraph:     clt.String("annover-graph"),
+			AnnoGroup:        clt.String("annogroup-collection"),
+			AnnoGroupType:    clt.String("annogrouptype-collection"),
+			AnnoGroupVersion: clt.String("annogroupversion-collection"),
+			AnnoAudit:        clt.String("annoaudit-collection"),
+			AnnoIndexes:      clt.StringSlice("annotation-index-fields"),
 		}, &ontoarango.CollectionParams{
 			GraphInfo:    clt.String("cv-collection"),
 			OboGraph:     clt.String("obograph"),
@@ -134,14 +385,22 @@ func allParams(
 func getGrpcOpt() []aphgrpc.Option {
 	return []aphgrpc.Option{
 		aphgrpc.TopicsOption(map[string]string{
-			"annotationCreate": "AnnotationService.Create",
-			"annotationDelete": "AnnotationService.Delete",
-			"annotationUpdate": "AnnotationService.Update",
+			"annotationCreate":  "AnnotationService.Create",
+			"annotationDelete":  "AnnotationService.Delete",
+			"annotationRestore": "AnnotationService.Restore",
+			"annotationUpdate":  "AnnotationService.Update",
 		}),
 	}
 }
 
-func repoAndNatsConn(clt *cli.Context) (*serverParams, error) {
+// repoAndNatsConn connects the repository and the publisher, which are
+// wrapped to record metrics unless the registry is nil. Failures to write the
+// audit log are reported to the logger.
+func repoAndNatsConn(
+	clt *cli.Context,
+	reg *prometheus.Registry,
+	logger *logrus.Entry,
+) (*serverParams, error) {
 	anrepo, err := arangodb.NewTaggedAnnotationRepo(allParams(clt))
 	if err != nil {
 		return &serverParams{},
@@ -159,8 +418,13 @@ func repoAndNatsConn(clt *cli.Context) (*serverParams, error) {
 			fmt.Errorf("cannot connect to messaging server %s", err)
 	}
 
+	if reg != nil {
+		anrepo = metrics.NewTaggedAnnotationRepo(anrepo, reg)
+		msp = metrics.NewPublisher(msp, reg)
+	}
+
 	return &serverParams{
-		repo: anrepo,
+		repo: audit.NewTaggedAnnotationRepo(anrepo, logger),
 		msg:  msp,
 	}, nil
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/app/health"
+	"github.com/dictyBase/modware-annotation/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// serveUntilSignal serves grpc requests until SIGTERM or SIGINT. On a
+// signal the readiness turns to not serving, new connections are refused
+// and the in-flight requests are given the timeout to finish before the
+// remaining ones are cut. The http gateway, when not nil, is drained along
+// with the grpc server.
+func serveUntilSignal(
+	grpcS *grpc.Server,
+	gtwS *http.Server,
+	lis net.Listener,
+	hsrv *health.Server,
+	timeout time.Duration,
+	logger *logrus.Entry,
+) error {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sigc)
+
+	return serveUntil(grpcS, gtwS, lis, hsrv, timeout, logger, sigc)
+}
+
+// serveUntil serves grpc requests and drains them once a signal is
+// received on sigc.
+func serveUntil(
+	grpcS *grpc.Server,
+	gtwS *http.Server,
+	lis net.Listener,
+	hsrv *health.Server,
+	timeout time.Duration,
+	logger *logrus.Entry,
+	sigc <-chan os.Signal,
+) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- grpcS.Serve(lis)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sigc:
+		logger.Infof("received %s, draining requests", sig)
+	}
+	hsrv.Shutdown()
+	expired := time.After(timeout)
+	stopped := make(chan struct{})
+	go func() {
+		grpcS.GracefulStop()
+		close(stopped)
+	}()
+	if gtwS != nil {
+		drainGateway(gtwS, timeout, logger)
+	}
+	select {
+	case <-stopped:
+	case <-expired:
+		logger.Warnf("requests are still running after %s, stopping", timeout)
+		grpcS.Stop()
+	}
+
+	return <-errc
+}
+
+// drainGateway waits for the in-flight http requests within the timeout
+// and closes the remaining connections after it.
+func drainGateway(gtwS *http.Server, timeout time.Duration, logger *logrus.Entry) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := gtwS.Shutdown(ctx); err != nil {
+		logger.Warnf("http requests are still running after %s, closing %s", timeout, err)
+		_ = gtwS.Close()
+	}
+}
+
+// flushSpans exports the spans that are still pending within the timeout.
+func flushSpans(
+	shutdown tracing.ShutdownFunc,
+	timeout time.Duration,
+	logger *logrus.Entry,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		logger.Errorf("error in flushing spans %s", err)
+	}
+}
+
+// closeBackends flushes and closes the messaging connection and closes the
+// repository, the repository is closed even when the messaging connection
+// fails to.
+func closeBackends(spn *serverParams) error {
+	var errs []error
+	if err := spn.msg.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("error in closing messaging connection %s", err))
+	}
+	if err := spn.repo.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("error in closing repository %s", err))
+	}
+
+	return errors.Join(errs...)
+}
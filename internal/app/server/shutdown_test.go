@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/app/health"
+	"github.com/dictyBase/modware-annotation/internal/message"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// slowCheck is a readiness check that signals its start and runs for the
+// duration unless its request is cut.
+func slowCheck(started chan<- struct{}, dur time.Duration) health.Checker {
+	return func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-time.After(dur):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestServeUntilDrain(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		check   time.Duration
+		timeout time.Duration
+		code    codes.Code
+	}{
+		{
+			name:    "request finishing within the timeout",
+			check:   200 * time.Millisecond,
+			timeout: 5 * time.Second,
+			code:    codes.OK,
+		},
+		{
+			name:    "request cut after the timeout",
+			check:   time.Minute,
+			timeout: 200 * time.Millisecond,
+			code:    codes.Unavailable,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			started := make(chan struct{})
+			hsrv := health.NewServer(
+				map[string]health.Checker{"slow": slowCheck(started, tst.check)},
+				2*time.Minute,
+			)
+			grpcS := grpc.NewServer()
+			healthpb.RegisterHealthServer(grpcS, hsrv)
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(err, "expect no error from listening")
+			sigc := make(chan os.Signal, 1)
+			served := make(chan error, 1)
+			go func() {
+				served <- serveUntil(
+					grpcS, nil, lis, hsrv, tst.timeout,
+					logrus.NewEntry(logrus.New()), sigc,
+				)
+			}()
+			conn, err := grpc.NewClient(
+				lis.Addr().String(),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			assert.NoError(err, "expect no error from dialing")
+			defer conn.Close()
+			checked := make(chan error, 1)
+			go func() {
+				_, err := healthpb.NewHealthClient(conn).Check(
+					context.Background(),
+					&healthpb.HealthCheckRequest{Service: health.Readiness},
+				)
+				checked <- err
+			}()
+			<-started
+			sigc <- syscall.SIGTERM
+			select {
+			case err := <-checked:
+				assert.Equal(tst.code, status.Code(err), "should match the outcome of the request")
+			case <-time.After(10 * time.Second):
+				assert.Fail("should end the in-flight request")
+			}
+			select {
+			case err := <-served:
+				assert.NoError(err, "expect no error from a drained server")
+			case <-time.After(10 * time.Second):
+				assert.Fail("should stop serving")
+			}
+			assert.Error(hsrv.Ready(context.Background()), "should not be ready after the signal")
+		})
+	}
+}
+
+func TestDrainGateway(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		handler time.Duration
+		timeout time.Duration
+		fail    bool
+	}{
+		{
+			name:    "request finishing within the timeout",
+			handler: 200 * time.Millisecond,
+			timeout: 5 * time.Second,
+		},
+		{
+			name:    "request cut after the timeout",
+			handler: time.Minute,
+			timeout: 200 * time.Millisecond,
+			fail:    true,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			started := make(chan struct{})
+			gtwS := &http.Server{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					close(started)
+					select {
+					case <-time.After(tst.handler):
+						w.WriteHeader(http.StatusOK)
+					case <-r.Context().Done():
+					}
+				}),
+				ReadHeaderTimeout: time.Second,
+			}
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(err, "expect no error from listening")
+			go func() {
+				_ = gtwS.Serve(lis)
+			}()
+			requested := make(chan error, 1)
+			go func() {
+				res, err := http.Get("http://" + lis.Addr().String())
+				if err == nil {
+					res.Body.Close()
+				}
+				requested <- err
+			}()
+			<-started
+			begin := time.Now()
+			drainGateway(gtwS, tst.timeout, logrus.NewEntry(logrus.New()))
+			assert.Less(time.Since(begin), 10*time.Second, "should drain within the timeout")
+			select {
+			case err := <-requested:
+				assert.Equal(tst.fail, err != nil, "should match the outcome of the request")
+			case <-time.After(10 * time.Second):
+				assert.Fail("should end the in-flight request")
+			}
+		})
+	}
+}
+
+type closeRepo struct {
+	repository.TaggedAnnotationRepository
+	closed bool
+}
+
+func (cr *closeRepo) Close() error {
+	cr.closed = true
+
+	return nil
+}
+
+type closePublisher struct {
+	message.Publisher
+	closed bool
+	err    error
+}
+
+func (cp *closePublisher) Close() error {
+	cp.closed = true
+
+	return cp.err
+}
+
+func TestCloseBackends(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "both closed"},
+		{name: "failed messaging connection", err: errors.New("nats is gone")},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			repo := &closeRepo{}
+			msg := &closePublisher{err: tst.err}
+			err := closeBackends(&serverParams{repo: repo, msg: msg})
+			assert.Equal(tst.err != nil, err != nil, "should report the failed close")
+			assert.True(msg.closed, "should close the messaging connection")
+			assert.True(repo.closed, "should close the repository")
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// certReloader serves the server certificate and the client CA pool from
+// files, reloading them whenever the files change on disk.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile, caFile string) (*certReloader, error) {
+	crl := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := crl.reload(); err != nil {
+		return crl, err
+	}
+
+	return crl, nil
+}
+
+// reload reads the files again when any of them is newer than the last
+// load.
+func (crl *certReloader) reload() error {
+	latest, err := crl.latestModTime()
+	if err != nil {
+		return err
+	}
+	crl.mu.RLock()
+	current := crl.modTime
+	crl.mu.RUnlock()
+	if !latest.After(current) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(crl.certFile, crl.keyFile)
+	if err != nil {
+		return fmt.Errorf("error in loading server certificate %s", err)
+	}
+	var pool *x509.CertPool
+	if len(crl.caFile) > 0 {
+		pem, err := os.ReadFile(crl.caFile)
+		if err != nil {
+			return fmt.Errorf("error in reading client ca file %s", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate in client ca file %s", crl.caFile)
+		}
+	}
+	crl.mu.Lock()
+	defer crl.mu.Unlock()
+	crl.cert = &cert
+	crl.caPool = pool
+	crl.modTime = latest
+
+	return nil
+}
+
+func (crl *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{crl.certFile, crl.keyFile, crl.caFile} {
+		if len(file) == 0 {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, fmt.Errorf("error in reading file information %s", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// watch checks the files at every interval until done is closed. A failed
+// reload keeps the certificates loaded earlier.
+func (crl *certReloader) watch(
+	interval time.Duration,
+	done <-chan struct{},
+	logger *logrus.Entry,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := crl.reload(); err != nil {
+				logger.Errorf("unable to reload certificates %s", err)
+			}
+		}
+	}
+}
+
+// config returns the TLS configuration of the server. The certificate and
+// client CA pool are picked for every handshake, so reloaded files are used
+// by new connections. A client certificate is required when there is a
+// client CA.
+func (crl *certReloader) config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			crl.mu.RLock()
+			defer crl.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*crl.cert},
+			}
+			if crl.caPool != nil {
+				cfg.ClientCAs = crl.caPool
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+
+			return cfg, nil
+		},
+	}
+}
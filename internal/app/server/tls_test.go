@@ -0,0 +1,107 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self signed certificate and its key for the common
+// name, with the given modification time.
+func writeCert(t *testing.T, dir, name string, mtime time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "expect no error from generating a key")
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err, "expect no error from creating the certificate")
+	kder, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err, "expect no error from encoding the key")
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	for file, blk := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: kder},
+	} {
+		require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(blk), 0o600))
+		require.NoError(t, os.Chtimes(file, mtime, mtime))
+	}
+
+	return certFile, keyFile
+}
+
+func serverName(t *testing.T, crl *certReloader) string {
+	t.Helper()
+	cfg, err := crl.config().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err, "expect no error from the handshake configuration")
+	require.Len(t, cfg.Certificates, 1, "should serve one certificate")
+	leaf, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	require.NoError(t, err, "expect no error from parsing the served certificate")
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertReload(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	certFile, keyFile := writeCert(t, dir, "first", start)
+	crl, err := newCertReloader(certFile, keyFile, "")
+	assert.NoError(err, "expect no error from loading the certificate")
+	assert.Equal("first", serverName(t, crl), "should serve the loaded certificate")
+	cfg, err := crl.config().GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(err)
+	assert.Equal(tls.NoClientCert, cfg.ClientAuth, "should not ask for client certificates")
+
+	assert.NoError(crl.reload(), "expect no error from reloading unchanged files")
+	assert.Equal("first", serverName(t, crl), "should keep the certificate of unchanged files")
+
+	writeCert(t, dir, "second", start.Add(time.Minute))
+	assert.NoError(crl.reload(), "expect no error from reloading changed files")
+	assert.Equal("second", serverName(t, crl), "should serve the new certificate")
+
+	broken := start.Add(2 * time.Minute)
+	assert.NoError(os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	assert.NoError(os.Chtimes(certFile, broken, broken))
+	assert.Error(crl.reload(), "expect error from reloading a broken certificate")
+	assert.Equal("second", serverName(t, crl), "should keep the certificate loaded earlier")
+}
+
+func TestCertReloadClientCA(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server", time.Now())
+	crl, err := newCertReloader(certFile, keyFile, certFile)
+	assert.NoError(err, "expect no error from loading the client ca")
+	cfg, err := crl.config().GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(err)
+	assert.Equal(tls.RequireAndVerifyClientCert, cfg.ClientAuth, "should require client certificates")
+	assert.NotNil(cfg.ClientCAs, "should verify against the client ca")
+
+	caFile := filepath.Join(dir, "ca.crt")
+	assert.NoError(os.WriteFile(caFile, []byte("no pem here"), 0o600))
+	_, err = newCertReloader(certFile, keyFile, caFile)
+	assert.Error(err, "expect error from a client ca without certificates")
+	_, err = newCertReloader(certFile, filepath.Join(dir, "missing.key"), "")
+	assert.Error(err, "expect error from a missing key")
+}
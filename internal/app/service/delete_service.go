@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/dictyBase/aphgrpc"
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/message"
+	"github.com/dictyBase/modware-annotation/internal/model"
 	"github.com/dictyBase/modware-annotation/internal/repository"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 )
@@ -14,25 +18,64 @@ func (s *AnnotationService) DeleteAnnotationGroup(ctx context.Context, r *annota
 	if err := r.Validate(); err != nil {
 		return emt, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	if err := s.repo.RemoveAnnotationGroup(r.GroupId); err != nil {
+	if err := s.repo.RemoveAnnotationGroup(ctx, r.GroupId); err != nil {
 		return emt, aphgrpc.HandleDeleteError(ctx, err)
 	}
 
 	return emt, nil
 }
 
-func (s *AnnotationService) DeleteAnnotation(ctx context.Context, r *annotation.DeleteAnnotationRequest) (*empty.Empty, error) {
+// DeleteAnnotation removes an annotation, records the caller and the reason
+// given in the delete-reason request header, and publishes a delete event.
+func (s *AnnotationService) DeleteAnnotation(
+	ctx context.Context,
+	r *annotation.DeleteAnnotationRequest,
+) (*empty.Empty, error) {
 	emt := &empty.Empty{}
 	if err := r.Validate(); err != nil {
 		return emt, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	if err := s.repo.RemoveAnnotation(r.Id, r.Purge); err != nil {
+	info := &model.DeleteInfo{
+		DeletedBy: auth.Actor(ctx, ""),
+		Reason:    deleteReason(ctx),
+	}
+	mda, err := s.repo.RemoveAnnotationBy(ctx, r.Id, r.Purge, info)
+	if err != nil {
 		if repository.IsAnnotationNotFound(err) {
 			return emt, aphgrpc.HandleNotFoundError(ctx, err)
 		}
 
 		return emt, aphgrpc.HandleDeleteError(ctx, err)
 	}
+	if err := s.publishDelete(ctx, mda, info, r.Purge); err != nil {
+		return emt, aphgrpc.HandleDeleteError(ctx, err)
+	}
 
 	return emt, nil
 }
+
+// publishDelete publishes the delete event of a removed annotation along
+// with the details of its deletion.
+func (s *AnnotationService) publishDelete(
+	ctx context.Context,
+	mda *model.AnnoDoc,
+	info *model.DeleteInfo,
+	purge bool,
+) error {
+	del := &message.Deletion{
+		DeletedBy: info.DeletedBy,
+		DeletedAt: time.Now(),
+		Reason:    info.Reason,
+		Purge:     purge,
+	}
+	if mda.DeletedAt != nil {
+		del.DeletedAt = *mda.DeletedAt
+	}
+
+	return s.publisher.PublishDelete(
+		ctx,
+		s.Topics["annotationDelete"],
+		&annotation.TaggedAnnotation{Data: s.getAnnoData(mda)},
+		del,
+	)
+}
@@ -2,15 +2,23 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
 	"github.com/dictyBase/aphgrpc"
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 	"github.com/dictyBase/modware-annotation/internal/model"
 	"github.com/dictyBase/modware-annotation/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 const limit = 10
 
+// groupMetadataKey is the response header with the metadata of the groups
+// of a response.
+const groupMetadataKey = "group-metadata-bin"
+
 type Validatable interface {
 	Validate() error
 }
@@ -23,7 +31,7 @@ func (srv *AnnotationService) GetAnnotation(
 	if err != nil {
 		return tna, err
 	}
-	mid, err := srv.repo.GetAnnotationByID(req.Id)
+	mid, err := srv.repo.GetAnnotationByID(ctx, req.Id)
 	if err != nil {
 		if repository.IsAnnotationNotFound(err) {
 			return tna, aphgrpc.HandleNotFoundError(ctx, err)
@@ -46,7 +54,7 @@ func (srv *AnnotationService) GetEntryAnnotation(
 	if err != nil {
 		return tna, err
 	}
-	mne, err := srv.repo.GetAnnotationByEntry(rea)
+	mne, err := srv.repo.GetAnnotationByEntry(ctx, rea)
 	if err != nil {
 		if repository.IsAnnotationNotFound(err) {
 			return tna, aphgrpc.HandleNotFoundError(ctx, err)
@@ -59,6 +67,28 @@ func (srv *AnnotationService) GetEntryAnnotation(
 	return tna, nil
 }
 
+// GetEntryProfile retrieves every live annotation of an entry grouped by
+// ontology and tag, it is served by the http gateway only.
+func (srv *AnnotationService) GetEntryProfile(
+	ctx context.Context, entryID string,
+) (*model.EntryProfile, error) {
+	if len(entryID) == 0 {
+		return &model.EntryProfile{}, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("entry id must not be empty"),
+		)
+	}
+	prof, err := srv.repo.GetEntryProfile(ctx, entryID)
+	if err != nil {
+		if repository.IsAnnotationNotFound(err) {
+			return prof, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return prof, aphgrpc.HandleGetError(ctx, err)
+	}
+
+	return prof, nil
+}
+
 func (srv *AnnotationService) GetAnnotationGroup(
 	ctx context.Context, rid *annotation.GroupEntryId,
 ) (*annotation.TaggedAnnotationGroup, error) {
@@ -66,7 +96,7 @@ func (srv *AnnotationService) GetAnnotationGroup(
 	if err != nil {
 		return gta, err
 	}
-	mga, err := srv.repo.GetAnnotationGroup(rid.GroupId)
+	mga, err := srv.repo.GetAnnotationGroup(ctx, rid.GroupId)
 	if err != nil {
 		if repository.IsGroupNotFound(err) {
 			return gta, aphgrpc.HandleNotFoundError(ctx, err)
@@ -75,9 +105,52 @@ func (srv *AnnotationService) GetAnnotationGroup(
 		return gta, aphgrpc.HandleGetError(ctx, err)
 	}
 
+	setGroupMetadata(ctx, mga)
+
 	return srv.getGroup(mga), nil
 }
 
+// GetGroupType retrieves a group type along with its rules.
+func (srv *AnnotationService) GetGroupType(
+	ctx context.Context, ontology, tag string,
+) (*model.GroupType, error) {
+	if len(ontology) == 0 || len(tag) == 0 {
+		return &model.GroupType{}, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("ontology and tag of the group type are required"),
+		)
+	}
+	gtp, err := srv.repo.GetGroupType(ctx, ontology, tag)
+	if err != nil {
+		if repository.IsGroupTypeNotFound(err) {
+			return gtp, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return gtp, aphgrpc.HandleGetError(ctx, err)
+	}
+
+	return gtp, nil
+}
+
+// ListAnnotationGroupVersions retrieves the membership history of a group,
+// oldest version first.
+func (srv *AnnotationService) ListAnnotationGroupVersions(
+	ctx context.Context, rid *annotation.GroupEntryId,
+) ([]*model.GroupVersion, error) {
+	if err := rid.Validate(); err != nil {
+		return nil, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	gvl, err := srv.repo.ListAnnotationGroupVersions(ctx, rid.GroupId)
+	if err != nil {
+		if repository.IsGroupNotFound(err) {
+			return gvl, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return gvl, aphgrpc.HandleGetError(ctx, err)
+	}
+
+	return gvl, nil
+}
+
 func (srv *AnnotationService) ListAnnotationGroups(
 	ctx context.Context, rgp *annotation.ListGroupParameters,
 ) (*annotation.TaggedAnnotationGroupCollection, error) {
@@ -87,11 +160,17 @@ func (srv *AnnotationService) ListAnnotationGroups(
 	if rgp.Limit > 0 {
 		limit = rgp.Limit
 	}
-	astmt, err := filterStrToQuery(rgp.Filter)
+	if err := srv.checkPageSize(limit); err != nil {
+		return gac, err
+	}
+	gfl, err := GroupFilterToQuery(rgp.Filter)
 	if err != nil {
 		return gac, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mgc, err := srv.repo.ListAnnotationGroup(rgp.Cursor, limit, astmt)
+	mgc, err := srv.repo.ListAnnotationGroupByType(
+		ctx,
+		rgp.Cursor, limit, gfl.Stmt, gfl.Ontology, gfl.Tag,
+	)
 	if err != nil {
 		if repository.IsAnnotationGroupListNotFound(err) {
 			return gac, aphgrpc.HandleNotFoundError(ctx, err)
@@ -99,25 +178,8 @@ func (srv *AnnotationService) ListAnnotationGroups(
 
 		return gac, aphgrpc.HandleGetError(ctx, err)
 	}
-	gcdata := make([]*annotation.TaggedAnnotationGroupCollection_Data, 0)
-	for _, mgs := range mgc {
-		var gdata []*annotation.TaggedAnnotationGroup_Data
-		for _, m := range mgs.AnnoDocs {
-			gdata = append(gdata, srv.getAnnoGroupData(m))
-		}
-		gcdata = append(
-			gcdata,
-			&annotation.TaggedAnnotationGroupCollection_Data{
-				Type: srv.GetGroupResourceName(),
-				Group: &annotation.TaggedAnnotationGroup{
-					Data:      gdata,
-					GroupId:   mgs.GroupId,
-					CreatedAt: aphgrpc.TimestampProto(mgs.CreatedAt),
-					UpdatedAt: aphgrpc.TimestampProto(mgs.UpdatedAt),
-				},
-			},
-		)
-	}
+	setGroupMetadata(ctx, mgc...)
+	gcdata := srv.getGroupCollectionData(mgc)
 	if len(gcdata) < int(limit)-2 {
 		return &annotation.TaggedAnnotationGroupCollection{
 			Data: gcdata,
@@ -134,6 +196,53 @@ func (srv *AnnotationService) ListAnnotationGroups(
 	}, nil
 }
 
+// ListGroupsByAnnotation retrieves all groups containing an annotation.
+func (srv *AnnotationService) ListGroupsByAnnotation(
+	ctx context.Context, req *annotation.AnnotationId,
+) (*annotation.TaggedAnnotationGroupCollection, error) {
+	gac := &annotation.TaggedAnnotationGroupCollection{}
+	if err := req.Validate(); err != nil {
+		return gac, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	mgc, err := srv.repo.ListGroupsByAnnotation(ctx, req.Id)
+	if err != nil {
+		if repository.IsAnnotationGroupListNotFound(err) {
+			return gac, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return gac, aphgrpc.HandleGetError(ctx, err)
+	}
+	setGroupMetadata(ctx, mgc...)
+	gac.Data = srv.getGroupCollectionData(mgc)
+
+	return gac, nil
+}
+
+// ListGroupsByEntry retrieves all groups containing any annotation of an
+// entry.
+func (srv *AnnotationService) ListGroupsByEntry(
+	ctx context.Context, entryID string,
+) (*annotation.TaggedAnnotationGroupCollection, error) {
+	gac := &annotation.TaggedAnnotationGroupCollection{}
+	if len(entryID) == 0 {
+		return gac, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("entry id must not be empty"),
+		)
+	}
+	mgc, err := srv.repo.ListGroupsByEntry(ctx, entryID)
+	if err != nil {
+		if repository.IsAnnotationGroupListNotFound(err) {
+			return gac, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return gac, aphgrpc.HandleGetError(ctx, err)
+	}
+	setGroupMetadata(ctx, mgc...)
+	gac.Data = srv.getGroupCollectionData(mgc)
+
+	return gac, nil
+}
+
 func (srv *AnnotationService) ListAnnotations(
 	ctx context.Context, ral *annotation.ListParameters,
 ) (*annotation.TaggedAnnotationCollection, error) {
@@ -143,11 +252,14 @@ func (srv *AnnotationService) ListAnnotations(
 	if ral.Limit > 0 {
 		limit = ral.Limit
 	}
-	astmt, err := filterStrToQuery(ral.Filter)
+	if err := srv.checkPageSize(limit); err != nil {
+		return tac, err
+	}
+	astmt, err := FilterToQuery(ral.Filter)
 	if err != nil {
 		return tac, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mlc, err := srv.repo.ListAnnotations(ral.Cursor, limit, astmt)
+	mlc, err := srv.repo.ListAnnotations(ctx, ral.Cursor, limit, astmt)
 	if err != nil {
 		if repository.IsAnnotationListNotFound(err) {
 			return tac, aphgrpc.HandleNotFoundError(ctx, err)
@@ -178,6 +290,39 @@ func (srv *AnnotationService) ListAnnotations(
 	return tac, nil
 }
 
+// ListObsoleteAnnotations retrieves a page of obsolete annotations, newest
+// first, along with who removed them, when and why.
+func (srv *AnnotationService) ListObsoleteAnnotations(
+	ctx context.Context, filter string, cursor, lmt int64,
+) (*model.AnnotationPage, error) {
+	page := &model.AnnotationPage{Annotations: make([]*model.AnnoDoc, 0)}
+	if lmt <= 0 {
+		lmt = limit
+	}
+	if err := srv.checkPageSize(lmt); err != nil {
+		return page, err
+	}
+	astmt, err := FilterToQuery(filter)
+	if err != nil {
+		return page, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	mlc, err := srv.repo.ListObsoleteAnnotations(ctx, cursor, lmt, astmt)
+	if err != nil {
+		if repository.IsAnnotationListNotFound(err) {
+			return page, nil
+		}
+
+		return page, aphgrpc.HandleGetError(ctx, err)
+	}
+	if int64(len(mlc)) > lmt {
+		page.NextCursor = genNextCursorVal(mlc[lmt].CreatedAt)
+		mlc = mlc[:lmt]
+	}
+	page.Annotations = mlc
+
+	return page, nil
+}
+
 func (srv *AnnotationService) GetAnnotationTag(
 	ctx context.Context, rta *annotation.TagRequest,
 ) (*annotation.AnnotationTag, error) {
@@ -185,7 +330,7 @@ func (srv *AnnotationService) GetAnnotationTag(
 	if err := rta.Validate(); err != nil {
 		return tag, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mta, err := srv.repo.GetAnnotationTag(rta.Name, rta.Ontology)
+	mta, err := srv.repo.GetAnnotationTag(ctx, rta.Name, rta.Ontology)
 	if err != nil {
 		if repository.IsAnnoTagNotFound(err) {
 			return tag, aphgrpc.HandleNotFoundError(ctx, err)
@@ -213,6 +358,71 @@ func (srv *AnnotationService) getGroup(
 	return gta
 }
 
+// groupMetadata is the json value of a group in the group-metadata-bin
+// response header.
+type groupMetadata struct {
+	GroupId       string `json:"group_id"`
+	GroupType     string `json:"group_type,omitempty"`
+	GroupOntology string `json:"group_ontology,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Description   string `json:"description,omitempty"`
+	CreatedBy     string `json:"created_by,omitempty"`
+	UpdatedBy     string `json:"updated_by,omitempty"`
+	Version       int64  `json:"version"`
+}
+
+// setGroupMetadata sends the type, name, description and curators of the
+// groups, for which the group messages have no fields, as the
+// group-metadata-bin response header with one json value per group.
+func setGroupMetadata(ctx context.Context, grps ...*model.AnnoGroup) {
+	mdt := metadata.MD{}
+	for _, grp := range grps {
+		gmd, err := json.Marshal(&groupMetadata{
+			GroupId:       grp.GroupId,
+			GroupType:     grp.GroupType,
+			GroupOntology: grp.GroupOntology,
+			Name:          grp.Name,
+			Description:   grp.Description,
+			CreatedBy:     grp.CreatedBy,
+			UpdatedBy:     grp.UpdatedBy,
+			Version:       grp.Version,
+		})
+		if err != nil {
+			continue
+		}
+		mdt.Append(groupMetadataKey, string(gmd))
+	}
+	if mdt.Len() > 0 {
+		_ = grpc.SetHeader(ctx, mdt)
+	}
+}
+
+func (srv *AnnotationService) getGroupCollectionData(
+	mgc []*model.AnnoGroup,
+) []*annotation.TaggedAnnotationGroupCollection_Data {
+	gcdata := make([]*annotation.TaggedAnnotationGroupCollection_Data, 0)
+	for _, mgs := range mgc {
+		var gdata []*annotation.TaggedAnnotationGroup_Data
+		for _, m := range mgs.AnnoDocs {
+			gdata = append(gdata, srv.getAnnoGroupData(m))
+		}
+		gcdata = append(
+			gcdata,
+			&annotation.TaggedAnnotationGroupCollection_Data{
+				Type: srv.GetGroupResourceName(),
+				Group: &annotation.TaggedAnnotationGroup{
+					Data:      gdata,
+					GroupId:   mgs.GroupId,
+					CreatedAt: aphgrpc.TimestampProto(mgs.CreatedAt),
+					UpdatedAt: aphgrpc.TimestampProto(mgs.UpdatedAt),
+				},
+			},
+		)
+	}
+
+	return gcdata
+}
+
 func (srv *AnnotationService) getAnnoGroupData(
 	m *model.AnnoDoc,
 ) *annotation.TaggedAnnotationGroup_Data {
@@ -267,3 +477,27 @@ func (srv *AnnotationService) getGroupData(
 
 	return gdata
 }
+
+// ListAuditEntries retrieves a page of the audit log, newest first.
+func (srv *AnnotationService) ListAuditEntries(
+	ctx context.Context, filter *model.AuditFilter,
+) (*model.AuditPage, error) {
+	page := &model.AuditPage{Entries: make([]*model.AuditEntry, 0)}
+	if filter.Limit <= 0 {
+		filter.Limit = limit
+	}
+	if err := srv.checkPageSize(filter.Limit); err != nil {
+		return page, err
+	}
+	entries, err := srv.repo.ListAuditEntries(ctx, filter)
+	if err != nil {
+		return page, aphgrpc.HandleGetError(ctx, err)
+	}
+	if int64(len(entries)) > filter.Limit {
+		entries = entries[:filter.Limit]
+		page.NextCursor, page.NextKey = model.AuditCursor(entries[filter.Limit-1])
+	}
+	page.Entries = entries
+
+	return page, nil
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/dictyBase/aphgrpc"
@@ -17,16 +19,44 @@ import (
 	"github.com/dictyBase/modware-annotation/internal/repository/arangodb"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const dividerVal = 1000000
 
+// expectedVersionKey is the request header with the version an annotation
+// is expected to be at when it is updated.
+const expectedVersionKey = "expected-version"
+
+// deleteReasonKey is the request header with the reason an annotation is
+// removed.
+const deleteReasonKey = "delete-reason"
+
+// errUploadTooLarge stops an upload that goes over the size limit.
+var errUploadTooLarge = errors.New("upload is over the size limit")
+
+// GroupFilter is a group list filter split into the group type and an AQL
+// statement for the group members.
+type GroupFilter struct {
+	Stmt     string
+	Ontology string
+	Tag      string
+}
+
 type oboStreamHandler struct {
 	writer *io.PipeWriter
 	stream annotation.TaggedAnnotationService_OboJSONFileUploadServer
+	// limit is the most bytes accepted, zero accepts any size
+	limit int64
+	size  int64
 }
 
-// Write write the content of the stream to a writer.
+// Write write the content of the stream to a writer. The writer is closed
+// with errUploadTooLarge once the content goes over the limit.
 func (oh *oboStreamHandler) Write() error {
 	defer oh.writer.Close()
 	for {
@@ -38,6 +68,12 @@ func (oh *oboStreamHandler) Write() error {
 
 			return fmt.Errorf("error in handling stream %s", err)
 		}
+		oh.size += int64(len(req.Content))
+		if oh.limit > 0 && oh.size > oh.limit {
+			oh.writer.CloseWithError(errUploadTooLarge)
+
+			return errUploadTooLarge
+		}
 		if _, err := oh.writer.Write(req.Content); err != nil {
 			return fmt.Errorf("error in writing the content from request %s", err)
 		}
@@ -53,6 +89,8 @@ type AnnotationService struct {
 	repo      repository.TaggedAnnotationRepository
 	publisher message.Publisher
 	group     string
+	maxPage   int64
+	maxUpload int64
 	annotation.UnimplementedTaggedAnnotationServiceServer
 }
 
@@ -62,6 +100,10 @@ type Params struct {
 	Publisher  message.Publisher                     `validate:"required"`
 	Options    []aphgrpc.Option                      `validate:"required"`
 	Group      string                                `validate:"required"`
+	// MaxPageSize is the largest limit of the list rpcs, zero allows any
+	MaxPageSize int64 `validate:"gte=0"`
+	// MaxUploadSize is the most bytes of an uploaded ontology, zero allows any
+	MaxUploadSize int64 `validate:"gte=0"`
 }
 
 func defaultOptions() *aphgrpc.ServiceOptions {
@@ -85,6 +127,8 @@ func NewAnnotationService(srvP *Params) (*AnnotationService, error) {
 		repo:      srvP.Repository,
 		publisher: srvP.Publisher,
 		group:     srvP.Group,
+		maxPage:   srvP.MaxPageSize,
+		maxUpload: srvP.MaxUploadSize,
 	}, nil
 }
 
@@ -94,17 +138,27 @@ func (s *AnnotationService) GetGroupResourceName() string {
 
 // OboJSONFileUpload uploads a obojson formatted file to the server.
 func (s *AnnotationService) OboJSONFileUpload(stream annotation.TaggedAnnotationService_OboJSONFileUploadServer) error {
+	ctx := stream.Context()
 	in, out := io.Pipe()
 	grp := new(errgroup.Group)
 	defer in.Close()
-	oh := &oboStreamHandler{writer: out, stream: stream}
+	oh := &oboStreamHandler{writer: out, stream: stream, limit: s.maxUpload}
 	grp.Go(oh.Write)
-	info, err := s.repo.LoadOboJSON(in)
+	info, err := s.repo.LoadOboJSON(ctx, in)
 	if err != nil {
-		return aphgrpc.HandleGenericError(context.Background(), fmt.Errorf("error with loading obo %s", err))
+		// unblock the writer before waiting for it
+		in.Close()
+		if errors.Is(grp.Wait(), errUploadTooLarge) {
+			return handleExhaustedError(
+				"upload size",
+				fmt.Sprintf("the ontology has to be at most %d bytes", s.maxUpload),
+			)
+		}
+
+		return aphgrpc.HandleGenericError(ctx, fmt.Errorf("error with loading obo %s", err))
 	}
 	if err := grp.Wait(); err != nil {
-		return aphgrpc.HandleGenericError(context.Background(), fmt.Errorf("error in waiting for the write to finish %s", err))
+		return aphgrpc.HandleGenericError(ctx, fmt.Errorf("error in waiting for the write to finish %s", err))
 	}
 
 	err = stream.SendAndClose(&upload.FileUploadResponse{
@@ -147,7 +201,113 @@ func getAnnoAttributes(annom *model.AnnoDoc) *annotation.TaggedAnnotationAttribu
 	}
 }
 
-func filterStrToQuery(filter string) (string, error) {
+// GroupFilterToQuery separates the group type from the rest of a group
+// list filter. The group type is matched through the group_ontology and
+// group_type fields that only support equality.
+func GroupFilterToQuery(filter string) (*GroupFilter, error) {
+	gfl := &GroupFilter{}
+	if len(filter) == 0 {
+		return gfl, nil
+	}
+	p, err := query.ParseFilterString(filter)
+	if err != nil {
+		return gfl, fmt.Errorf("error in parsing filter string")
+	}
+	var rest []*query.Filter
+	for _, flt := range p {
+		switch flt.Field {
+		case "group_type", "group_ontology":
+			if flt.Operator != "==" {
+				return gfl, fmt.Errorf("only == operator is supported for %s", flt.Field)
+			}
+			if flt.Field == "group_type" {
+				gfl.Tag = flt.Value
+			} else {
+				gfl.Ontology = flt.Value
+			}
+		default:
+			rest = append(rest, flt)
+		}
+	}
+	if len(rest) == 0 {
+		return gfl, nil
+	}
+	// the last filter should not be joined with another one
+	rest[len(rest)-1].Logic = ""
+	q, err := query.GenQualifiedAQLFilterStatement(arangodb.FilterMap(), rest)
+	if err != nil {
+		return gfl, fmt.Errorf("error in generating aql statement")
+	}
+	gfl.Stmt = q
+
+	return gfl, nil
+}
+
+// handleConflictError reports an edit that lost to a concurrent one, the
+// client is expected to fetch the latest version and retry.
+func handleConflictError(ctx context.Context, err error) error {
+	grpc.SetTrailer(ctx, aphgrpc.ErrDatabaseUpdate)
+
+	return status.Error(codes.Aborted, err.Error())
+}
+
+// expectedVersion reads the version an annotation has to be at for an update
+// from the expected-version request header, zero when it is absent.
+func expectedVersion(ctx context.Context) (int64, error) {
+	vals := metadata.ValueFromIncomingContext(ctx, expectedVersionKey)
+	if len(vals) == 0 {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(vals[0], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%s %s is not a positive number", expectedVersionKey, vals[0])
+	}
+
+	return version, nil
+}
+
+// deleteReason reads the reason of a removal from the delete-reason request
+// header, empty when it is absent.
+func deleteReason(ctx context.Context) string {
+	vals := metadata.ValueFromIncomingContext(ctx, deleteReasonKey)
+	if len(vals) == 0 {
+		return ""
+	}
+
+	return vals[0]
+}
+
+// checkPageSize rejects a list limit above the maximum page size.
+func (s *AnnotationService) checkPageSize(limit int64) error {
+	if s.maxPage == 0 || limit <= s.maxPage {
+		return nil
+	}
+
+	return handleExhaustedError(
+		"limit",
+		fmt.Sprintf("limit %d is over the maximum page size, use at most %d", limit, s.maxPage),
+	)
+}
+
+// handleExhaustedError reports a request over a limit of the server, the
+// quota failure tells the client what to change before retrying.
+func handleExhaustedError(subject, desc string) error {
+	sts := status.New(codes.ResourceExhausted, desc)
+	dts, err := sts.WithDetails(&errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{
+			Subject:     subject,
+			Description: desc,
+		}},
+	})
+	if err != nil {
+		return sts.Err()
+	}
+
+	return dts.Err()
+}
+
+// FilterToQuery converts an annotation list filter to an AQL statement.
+func FilterToQuery(filter string) (string, error) {
 	var empty string
 	if len(filter) == 0 {
 		return empty, nil
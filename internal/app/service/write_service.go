@@ -2,29 +2,49 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/dictyBase/aphgrpc"
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/model"
 	"github.com/dictyBase/modware-annotation/internal/repository"
 )
 
+// UpdateAnnotation updates an annotation, with an expected-version request
+// header it is updated only if it is still at that version and a stale edit
+// is rejected with an Aborted status.
 func (s *AnnotationService) UpdateAnnotation(
 	ctx context.Context,
 	rta *annotation.TaggedAnnotationUpdate,
 ) (*annotation.TaggedAnnotation, error) {
 	tga := &annotation.TaggedAnnotation{}
+	if attr := rta.GetData().GetAttributes(); attr != nil {
+		attr.CreatedBy = auth.Actor(ctx, attr.CreatedBy)
+	}
 	if err := rta.Validate(); err != nil {
 		return tga, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mde, err := s.repo.EditAnnotation(rta)
+	version, err := expectedVersion(ctx)
 	if err != nil {
+		return tga, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	mde, err := s.repo.EditAnnotationAtVersion(ctx, rta, version)
+	if err != nil {
+		if repository.IsAnnotationConflict(err) {
+			return tga, handleConflictError(ctx, err)
+		}
+		if repository.IsAnnotationNotFound(err) {
+			return tga, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
 		return tga, aphgrpc.HandleUpdateError(ctx, err)
 	}
 	if mde.NotFound {
 		return tga, aphgrpc.HandleNotFoundError(ctx, err)
 	}
 	tga.Data = s.getAnnoData(mde)
-	err = s.publisher.Publish(s.Topics["annotationUpdate"], tga)
+	err = s.publisher.Publish(ctx, s.Topics["annotationUpdate"], tga)
 	if err != nil {
 		return tga, aphgrpc.HandleUpdateError(ctx, err)
 	}
@@ -37,15 +57,18 @@ func (s *AnnotationService) CreateAnnotation(
 	rta *annotation.NewTaggedAnnotation,
 ) (*annotation.TaggedAnnotation, error) {
 	tga := &annotation.TaggedAnnotation{}
+	if attr := rta.GetData().GetAttributes(); attr != nil {
+		attr.CreatedBy = auth.Actor(ctx, attr.CreatedBy)
+	}
 	if err := rta.Validate(); err != nil {
 		return tga, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	m, err := s.repo.AddAnnotation(rta)
+	m, err := s.repo.AddAnnotation(ctx, rta)
 	if err != nil {
 		return tga, aphgrpc.HandleInsertError(ctx, err)
 	}
 	tga.Data = s.getAnnoData(m)
-	err = s.publisher.Publish(s.Topics["annotationCreate"], tga)
+	err = s.publisher.Publish(ctx, s.Topics["annotationCreate"], tga)
 	if err != nil {
 		return tga, aphgrpc.HandleInsertError(ctx, err)
 	}
@@ -60,9 +83,59 @@ func (s *AnnotationService) AddToAnnotationGroup(
 	if err := rta.Validate(); err != nil {
 		return gta, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mga, err := s.repo.AppendToAnnotationGroup(rta.GroupId, rta.Id)
+	mga, err := s.repo.AppendToAnnotationGroup(ctx, rta.GroupId, rta.Id)
 	if err != nil {
-		if repository.IsGroupNotFound(err) {
+		if repository.IsGroupNotFound(err) || repository.IsAnnotationNotFound(err) {
+			return gta, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+		if repository.IsObsoleteMember(err) || repository.IsGroupRuleViolation(err) {
+			return gta, aphgrpc.HandleInvalidParamError(ctx, err)
+		}
+
+		return gta, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return s.getGroup(mga), nil
+}
+
+// ReorderAnnotationGroup places the given annotations at the start of a
+// group in the requested order, the other members follow in their current
+// order.
+func (s *AnnotationService) ReorderAnnotationGroup(
+	ctx context.Context, groupID string, ids ...string,
+) (*annotation.TaggedAnnotationGroup, error) {
+	gta := &annotation.TaggedAnnotationGroup{}
+	if len(groupID) == 0 || len(ids) == 0 {
+		return gta, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("group id and annotation ids are required"),
+		)
+	}
+	mga, err := s.repo.ReorderAnnotationGroup(ctx, groupID, ids...)
+	if err != nil {
+		if repository.IsGroupNotFound(err) || repository.IsAnnotationNotFound(err) {
+			return gta, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return gta, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return s.getGroup(mga), nil
+}
+
+// MoveAnnotationGroupMember moves an annotation of a group to a zero based
+// position.
+func (s *AnnotationService) MoveAnnotationGroupMember(
+	ctx context.Context, groupID, annoID string, position int64,
+) (*annotation.TaggedAnnotationGroup, error) {
+	gta := &annotation.TaggedAnnotationGroup{}
+	if len(groupID) == 0 || len(annoID) == 0 || position < 0 {
+		return gta, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("group id, annotation id and a non negative position are required"),
+		)
+	}
+	mga, err := s.repo.MoveAnnotationGroupMember(ctx, groupID, annoID, position)
+	if err != nil {
+		if repository.IsGroupNotFound(err) || repository.IsAnnotationNotFound(err) {
 			return gta, aphgrpc.HandleNotFoundError(ctx, err)
 		}
 
@@ -79,14 +152,293 @@ func (s *AnnotationService) CreateAnnotationGroup(
 	if err := rta.Validate(); err != nil {
 		return gta, aphgrpc.HandleInvalidParamError(ctx, err)
 	}
-	mga, err := s.repo.AddAnnotationGroup(rta.Ids...)
+	mga, err := s.repo.AddAnnotationGroup(ctx, rta.Ids...)
 	if err != nil {
 		if repository.IsAnnotationNotFound(err) {
 			return gta, aphgrpc.HandleNotFoundError(ctx, err)
 		}
+		if repository.IsObsoleteMember(err) || repository.IsGroupRuleViolation(err) {
+			return gta, aphgrpc.HandleInvalidParamError(ctx, err)
+		}
 
 		return gta, aphgrpc.HandleInsertError(ctx, err)
 	}
 
 	return s.getGroup(mga), nil
 }
+
+// CreateTypedAnnotationGroup creates an annotation group with a group type,
+// name and description, the group type decides which members are allowed.
+func (s *AnnotationService) CreateTypedAnnotationGroup(
+	ctx context.Context, params *model.GroupParams, ids ...string,
+) (*model.AnnoGroup, error) {
+	params.CreatedBy = auth.Actor(ctx, params.CreatedBy)
+	if len(params.Ontology) == 0 || len(params.Tag) == 0 || len(ids) == 0 {
+		return &model.AnnoGroup{}, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("group ontology, group type and annotation ids are required"),
+		)
+	}
+	mga, err := s.repo.AddTypedAnnotationGroup(ctx, params, ids...)
+	if err != nil {
+		switch {
+		case repository.IsAnnotationNotFound(err), repository.IsGroupTypeNotFound(err):
+			return mga, aphgrpc.HandleNotFoundError(ctx, err)
+		case repository.IsObsoleteMember(err), repository.IsGroupRuleViolation(err):
+			return mga, aphgrpc.HandleInvalidParamError(ctx, err)
+		}
+
+		return mga, aphgrpc.HandleInsertError(ctx, err)
+	}
+
+	return mga, nil
+}
+
+// EditAnnotationGroup changes the name and description of a group, an
+// empty value leaves it unchanged.
+func (s *AnnotationService) EditAnnotationGroup(
+	ctx context.Context, groupID, name, description, updatedBy string,
+) (*model.AnnoGroup, error) {
+	updatedBy = auth.Actor(ctx, updatedBy)
+	if len(groupID) == 0 || len(updatedBy) == 0 {
+		return &model.AnnoGroup{}, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("group id and updated by are required"),
+		)
+	}
+	mga, err := s.repo.EditAnnotationGroup(ctx, groupID, name, description, updatedBy)
+	if err != nil {
+		if repository.IsGroupNotFound(err) {
+			return mga, aphgrpc.HandleNotFoundError(ctx, err)
+		}
+
+		return mga, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return mga, nil
+}
+
+// SetGroupType creates or updates a group type along with the rules for
+// the members of its groups.
+func (s *AnnotationService) SetGroupType(
+	ctx context.Context, gtp *model.GroupType,
+) (*model.GroupType, error) {
+	switch {
+	case len(gtp.Ontology) == 0 || len(gtp.Tag) == 0:
+		return gtp, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("ontology and tag of the group type are required"),
+		)
+	case gtp.MinMembers < 0 || gtp.MaxMembers < 0,
+		gtp.MaxMembers > 0 && gtp.MinMembers > gtp.MaxMembers:
+		return gtp, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("member limits must not be negative and the minimum must not exceed the maximum"),
+		)
+	}
+	ngt, err := s.repo.SetGroupType(ctx, gtp)
+	if err != nil {
+		return ngt, aphgrpc.HandleInsertError(ctx, err)
+	}
+
+	return ngt, nil
+}
+
+// ApplyBatch applies an ordered list of annotation and group changes in a
+// single transaction, an event is published for every annotation created,
+// updated or deleted by it.
+func (s *AnnotationService) ApplyBatch(
+	ctx context.Context, ops []*model.BatchOperation,
+) ([]*model.BatchResult, error) {
+	for idx, op := range ops {
+		if err := prepareBatchOp(ctx, op); err != nil {
+			return []*model.BatchResult{}, aphgrpc.HandleInvalidParamError(
+				ctx, &repository.BatchError{Index: idx, Reason: err.Error()},
+			)
+		}
+	}
+	res, err := s.repo.ApplyBatch(ctx, ops)
+	if err != nil {
+		if repository.IsBatchError(err) {
+			return res, aphgrpc.HandleInvalidParamError(ctx, err)
+		}
+
+		return res, aphgrpc.HandleUpdateError(ctx, err)
+	}
+	var errs []error
+	for _, r := range res {
+		switch r.Action {
+		case model.BatchCreate:
+			errs = append(errs, s.publishAnnotations(ctx, "annotationCreate", r.Id))
+		case model.BatchUpdate:
+			errs = append(errs, s.publishAnnotations(ctx, "annotationUpdate", r.Id))
+		case model.BatchDelete:
+			errs = append(errs, s.publishDeletions(ctx, r.Id))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return res, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return res, nil
+}
+
+// prepareBatchOp records the caller as the author of a batch operation and
+// validates the attributes of the annotation it creates or updates.
+func prepareBatchOp(ctx context.Context, op *model.BatchOperation) error {
+	switch op.Action {
+	case model.BatchCreate:
+		if op.Create != nil {
+			op.Create.CreatedBy = auth.Actor(ctx, op.Create.CreatedBy)
+			return op.Create.Validate()
+		}
+	case model.BatchUpdate:
+		if op.Update != nil {
+			op.Update.CreatedBy = auth.Actor(ctx, op.Update.CreatedBy)
+			return op.Update.Validate()
+		}
+	case model.BatchDelete:
+		if op.Delete == nil {
+			op.Delete = &model.DeleteInfo{}
+		}
+		op.Delete.DeletedBy = auth.Actor(ctx, op.Delete.DeletedBy)
+	}
+
+	return nil
+}
+
+// BulkUpdateAnnotations applies an edit to every live annotation matching
+// a filter string, an update event is published for every new version.
+// The edit is applied in chunks, the events of the chunks committed before a
+// failure are published as well.
+func (s *AnnotationService) BulkUpdateAnnotations(
+	ctx context.Context, filter string, edit *model.BulkEdit, dryRun bool,
+) (*model.BulkResult, error) {
+	astmt, err := bulkFilter(filter)
+	if err != nil {
+		return &model.BulkResult{}, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	edit.CreatedBy = auth.Actor(ctx, edit.CreatedBy)
+	res, err := s.repo.BulkEditAnnotations(ctx, astmt, edit, dryRun)
+	if !dryRun {
+		if perr := s.publishAnnotations(ctx, "annotationUpdate", res.NewIds...); perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	if err != nil {
+		if repository.IsAnnotationConflict(err) {
+			return res, handleConflictError(ctx, err)
+		}
+
+		return res, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return res, nil
+}
+
+// BulkObsoleteAnnotations obsoletes every live annotation matching a
+// filter string and publishes a delete event for each of them. The
+// annotations are obsoleted in chunks, the events of the chunks committed
+// before a failure are published as well.
+func (s *AnnotationService) BulkObsoleteAnnotations(
+	ctx context.Context, filter string, info *model.DeleteInfo, dryRun bool,
+) (*model.BulkResult, error) {
+	astmt, err := bulkFilter(filter)
+	if err != nil {
+		return &model.BulkResult{}, aphgrpc.HandleInvalidParamError(ctx, err)
+	}
+	info.DeletedBy = auth.Actor(ctx, info.DeletedBy)
+	res, err := s.repo.BulkObsoleteAnnotations(ctx, astmt, info, dryRun)
+	if !dryRun {
+		if perr := s.publishDeletions(ctx, res.Ids...); perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	if err != nil {
+		return res, aphgrpc.HandleDeleteError(ctx, err)
+	}
+
+	return res, nil
+}
+
+// bulkFilter converts the filter of a bulk change, which is required so
+// that a change never applies to every annotation by accident.
+func bulkFilter(filter string) (string, error) {
+	if len(filter) == 0 {
+		return "", errors.New("filter is required for bulk changes")
+	}
+
+	return FilterToQuery(filter)
+}
+
+// RestoreAnnotation brings back an obsolete annotation and publishes a
+// restore event, the caller is recorded as the curator when authenticated.
+func (s *AnnotationService) RestoreAnnotation(
+	ctx context.Context, id, restoredBy string,
+) (*annotation.TaggedAnnotation, error) {
+	tga := &annotation.TaggedAnnotation{}
+	restoredBy = auth.Actor(ctx, restoredBy)
+	if len(id) == 0 || len(restoredBy) == 0 {
+		return tga, aphgrpc.HandleInvalidParamError(
+			ctx, errors.New("annotation id and restored by are required"),
+		)
+	}
+	mda, err := s.repo.RestoreAnnotation(ctx, id, restoredBy)
+	if err != nil {
+		switch {
+		case repository.IsAnnotationNotFound(err):
+			return tga, aphgrpc.HandleNotFoundError(ctx, err)
+		case repository.IsAnnotationSlotTaken(err):
+			return tga, aphgrpc.HandleExistError(ctx, err)
+		case repository.IsAnnotationConflict(err):
+			return tga, handleConflictError(ctx, err)
+		}
+
+		return tga, aphgrpc.HandleUpdateError(ctx, err)
+	}
+	tga.Data = s.getAnnoData(mda)
+	if err := s.publisher.Publish(ctx, s.Topics["annotationRestore"], tga); err != nil {
+		return tga, aphgrpc.HandleUpdateError(ctx, err)
+	}
+
+	return tga, nil
+}
+
+// publishAnnotations publishes an event of the topic for every annotation,
+// a failure for one annotation does not keep the events of the others from
+// being published.
+func (s *AnnotationService) publishAnnotations(
+	ctx context.Context, topic string, ids ...string,
+) error {
+	var errs []error
+	for _, id := range ids {
+		mda, err := s.repo.GetAnnotationByID(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		tga := &annotation.TaggedAnnotation{Data: s.getAnnoData(mda)}
+		if err := s.publisher.Publish(ctx, s.Topics[topic], tga); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// publishDeletions publishes a delete event for every obsolete annotation
+// along with the deletion details recorded in it.
+func (s *AnnotationService) publishDeletions(ctx context.Context, ids ...string) error {
+	var errs []error
+	for _, id := range ids {
+		mda, err := s.repo.GetAnnotationByID(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		info := &model.DeleteInfo{DeletedBy: mda.DeletedBy, Reason: mda.DeleteReason}
+		if err := s.publishDelete(ctx, mda, info, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,7 +2,9 @@ package validate
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/dictyBase/modware-annotation/internal/tracing"
 	"github.com/urfave/cli"
 )
 
@@ -23,6 +25,58 @@ func ServerArgs(clt *cli.Context) error {
 			)
 		}
 	}
+	if clt.Duration("health-timeout") <= 0 {
+		return cli.NewExitError("health-timeout must be positive", errNo)
+	}
+	if len(clt.String("metrics-port")) > 0 && clt.Duration("metrics-count-interval") <= 0 {
+		return cli.NewExitError("metrics-count-interval must be positive", errNo)
+	}
+	if clt.Float64("rate-limit-ip") < 0 || clt.Float64("rate-limit-identity") < 0 {
+		return cli.NewExitError("rate limits can not be negative", errNo)
+	}
+	if (clt.Float64("rate-limit-ip") > 0 || clt.Float64("rate-limit-identity") > 0) &&
+		clt.Int("rate-limit-burst") < 1 {
+		return cli.NewExitError("rate-limit-burst must be positive", errNo)
+	}
+	if clt.Int64("max-page-size") < 0 || clt.Int64("max-upload-size") < 0 {
+		return cli.NewExitError("max-page-size and max-upload-size can not be negative", errNo)
+	}
+	if clt.Bool("graphql") && len(clt.String("http-port")) == 0 {
+		return cli.NewExitError("graphql needs http-port", errNo)
+	}
+	if !slices.Contains(tracing.Exporters, clt.String("tracing-exporter")) {
+		return cli.NewExitError(
+			fmt.Sprintf("unknown tracing-exporter %s", clt.String("tracing-exporter")),
+			errNo,
+		)
+	}
+	hasCert := len(clt.String("tls-cert")) > 0
+	if hasCert != (len(clt.String("tls-key")) > 0) {
+		return cli.NewExitError("tls-cert and tls-key must be given together", errNo)
+	}
+	if !hasCert && len(clt.String("tls-client-ca")) > 0 {
+		return cli.NewExitError("tls-client-ca needs tls-cert and tls-key", errNo)
+	}
+	if hasCert && clt.Duration("tls-reload-interval") <= 0 {
+		return cli.NewExitError("tls-reload-interval must be positive", errNo)
+	}
+
+	return nil
+}
+
+func ExportAuditArgs(clt *cli.Context) error {
+	for _, param := range []string{
+		"arangodb-pass",
+		"arangodb-database",
+		"arangodb-user",
+	} {
+		if len(clt.String(param)) == 0 {
+			return cli.NewExitError(
+				fmt.Sprintf("argument %s is missing", param),
+				errNo,
+			)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,119 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DeletionField is the field number of the deletion details appended to the
+// payload of a delete event. It is outside of the TaggedAnnotation message,
+// so subscribers that do not know about it skip it as an unknown field.
+const DeletionField protowire.Number = 1000
+
+const (
+	deletedByField protowire.Number = iota + 1
+	deletedAtField
+	reasonField
+	purgeField
+)
+
+// AppendDeletion appends the deletion details to the encoded payload of a
+// delete event.
+func AppendDeletion(data []byte, del *Deletion) []byte {
+	var buf []byte
+	buf = protowire.AppendTag(buf, deletedByField, protowire.BytesType)
+	buf = protowire.AppendString(buf, del.DeletedBy)
+	buf = protowire.AppendTag(buf, deletedAtField, protowire.BytesType)
+	buf = protowire.AppendString(buf, del.DeletedAt.UTC().Format(time.RFC3339Nano))
+	buf = protowire.AppendTag(buf, reasonField, protowire.BytesType)
+	buf = protowire.AppendString(buf, del.Reason)
+	buf = protowire.AppendTag(buf, purgeField, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, protowire.EncodeBool(del.Purge))
+	data = protowire.AppendTag(data, DeletionField, protowire.BytesType)
+
+	return protowire.AppendBytes(data, buf)
+}
+
+// ParseDeletion reads the deletion details from the payload of a delete
+// event, it returns false when the payload has none.
+func ParseDeletion(data []byte) (*Deletion, bool, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, false, fmt.Errorf("error in reading payload %s", protowire.ParseError(n))
+		}
+		data = data[n:]
+		if num == DeletionField && typ == protowire.BytesType {
+			val, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, false, fmt.Errorf("error in reading deletion %s", protowire.ParseError(n))
+			}
+			del, err := parseDeletion(val)
+
+			return del, err == nil, err
+		}
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			return nil, false, fmt.Errorf("error in reading payload %s", protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+
+	return nil, false, nil
+}
+
+func parseDeletion(data []byte) (*Deletion, error) {
+	del := &Deletion{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("error in reading deletion %s", protowire.ParseError(n))
+		}
+		data = data[n:]
+		switch {
+		case num == purgeField && typ == protowire.VarintType:
+			val, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return nil, fmt.Errorf("error in reading purge %s", protowire.ParseError(m))
+			}
+			del.Purge, n = protowire.DecodeBool(val), m
+		case typ == protowire.BytesType:
+			val, m := protowire.ConsumeString(data)
+			if m < 0 {
+				return nil, fmt.Errorf("error in reading deletion %s", protowire.ParseError(m))
+			}
+			if err := del.setString(num, val); err != nil {
+				return nil, err
+			}
+			n = m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, errors.New("error in skipping an unknown deletion field")
+			}
+		}
+		data = data[n:]
+	}
+
+	return del, nil
+}
+
+func (del *Deletion) setString(num protowire.Number, val string) error {
+	switch num {
+	case deletedByField:
+		del.DeletedBy = val
+	case deletedAtField:
+		tm, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return fmt.Errorf("error in parsing deletion time %s", err)
+		}
+		del.DeletedAt = tm
+	case reasonField:
+		del.Reason = val
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package message
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeletionPayload(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	tga := &annotation.TaggedAnnotation{
+		Data: &annotation.TaggedAnnotation_Data{
+			Type: "annotations",
+			Id:   "4589",
+			Attributes: &annotation.TaggedAnnotationAttributes{
+				Value:      "ameboid",
+				Tag:        "phenotype",
+				IsObsolete: true,
+			},
+		},
+	}
+	data, err := proto.Marshal(tga)
+	assert.NoError(err, "expect no error from encoding the annotation")
+	del, ok, err := ParseDeletion(data)
+	assert.NoError(err, "expect no error from a payload without deletion")
+	assert.False(ok, "should not find deletion details")
+	assert.Nil(del, "should have no deletion")
+
+	want := &Deletion{
+		DeletedBy: "pfey@gmail.com",
+		DeletedAt: time.Date(2026, 3, 4, 10, 11, 12, 0, time.UTC),
+		Reason:    "retracted paper",
+		Purge:     true,
+	}
+	payload := AppendDeletion(data, want)
+	del, ok, err = ParseDeletion(payload)
+	assert.NoError(err, "expect no error from parsing the deletion")
+	assert.True(ok, "should find deletion details")
+	assert.Equal(want.DeletedBy, del.DeletedBy, "should match the curator")
+	assert.True(want.DeletedAt.Equal(del.DeletedAt), "should match the time of deletion")
+	assert.Equal(want.Reason, del.Reason, "should match the reason")
+	assert.True(del.Purge, "should match purge")
+
+	got := &annotation.TaggedAnnotation{}
+	assert.NoError(proto.Unmarshal(payload, got), "expect old subscribers to decode the payload")
+	assert.Equal("4589", got.Data.Id, "should match the annotation id")
+	assert.Equal("ameboid", got.Data.Attributes.Value, "should match the value")
+
+	_, _, err = ParseDeletion([]byte{0xff})
+	assert.Error(err, "expect error from a malformed payload")
+}
@@ -1,13 +1,38 @@
 package message
 
 import (
+	"context"
+	"time"
+
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 )
 
+// Deletion describes who removed an annotation, when and why.
+type Deletion struct {
+	DeletedBy string
+	DeletedAt time.Time
+	Reason    string
+	Purge     bool
+}
+
 // Publisher manages publishing of message.
 type Publisher interface {
-	// Publis publishes the annotation object using the given subject
-	Publish(subject string, ann *annotation.TaggedAnnotation) error
-	// Close closes the connection to the underlying messaging server
+	// Publis publishes the annotation object using the given subject, the
+	// trace context of ctx travels along with the message
+	Publish(ctx context.Context, subject string, ann *annotation.TaggedAnnotation) error
+	// PublishDelete publishes the removed annotation object using the
+	// given subject along with the details of its deletion, which are
+	// read back from the payload with ParseDeletion
+	PublishDelete(
+		ctx context.Context,
+		subject string,
+		ann *annotation.TaggedAnnotation,
+		del *Deletion,
+	) error
+	// Check reports whether the connection to the messaging server is
+	// usable
+	Check(ctx context.Context) error
+	// Close flushes pending messages and closes the connection to the
+	// underlying messaging server
 	Close() error
 }
@@ -1,14 +1,26 @@
 package nats
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 	"github.com/dictyBase/modware-annotation/internal/message"
 	gnats "github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/encoders/protobuf"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const flushTimeout = 5 * time.Second
+
+const tracerName = "github.com/dictyBase/modware-annotation/internal/message/nats"
+
 type natsPublisher struct {
 	econn *gnats.EncodedConn
 }
@@ -35,18 +47,96 @@ func NewPublisher(
 }
 
 func (n *natsPublisher) Publish(
+	ctx context.Context,
 	subj string,
 	ann *annotation.TaggedAnnotation,
 ) error {
-	if err := n.econn.Publish(subj, ann); err != nil {
+	msg, err := n.newMsg(subj, ann)
+	if err != nil {
+		return err
+	}
+
+	return n.publishMsg(ctx, msg)
+}
+
+// PublishDelete sends the annotation as the message payload with the
+// deletion details appended to it, the details are also sent as message
+// headers.
+func (n *natsPublisher) PublishDelete(
+	ctx context.Context,
+	subj string,
+	ann *annotation.TaggedAnnotation,
+	del *message.Deletion,
+) error {
+	msg, err := n.newMsg(subj, ann)
+	if err != nil {
+		return err
+	}
+	msg.Data = message.AppendDeletion(msg.Data, del)
+	msg.Header.Set("Deleted-By", del.DeletedBy)
+	msg.Header.Set("Deleted-At", del.DeletedAt.Format(time.RFC3339))
+	msg.Header.Set("Delete-Reason", del.Reason)
+	msg.Header.Set("Purge", strconv.FormatBool(del.Purge))
+
+	return n.publishMsg(ctx, msg)
+}
+
+func (n *natsPublisher) newMsg(
+	subj string,
+	ann *annotation.TaggedAnnotation,
+) (*gnats.Msg, error) {
+	data, err := n.econn.Enc.Encode(subj, ann)
+	if err != nil {
+		return nil, fmt.Errorf("error in encoding message %s", err)
+	}
+	msg := gnats.NewMsg(subj)
+	msg.Data = data
+
+	return msg, nil
+}
+
+// publishMsg sends the message within a producer span whose trace context
+// is added to the message headers, so that subscribers can continue the
+// trace.
+func (n *natsPublisher) publishMsg(ctx context.Context, msg *gnats.Msg) error {
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		fmt.Sprintf("%s publish", msg.Subject),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("nats"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(msg.Subject),
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(msg.Header))
+	if err := n.econn.Conn.PublishMsg(msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
 		return fmt.Errorf("error in publishing through nats %s", err)
 	}
 
 	return nil
 }
 
+// Check fails unless the connection to the nats server is established.
+func (n *natsPublisher) Check(ctx context.Context) error {
+	if st := n.econn.Conn.Status(); st != gnats.CONNECTED {
+		return fmt.Errorf("nats connection is %s", st)
+	}
+
+	return nil
+}
+
+// Close flushes the pending messages to the nats server before closing the
+// connection.
 func (n *natsPublisher) Close() error {
-	n.econn.Close()
+	defer n.econn.Close()
+	if err := n.econn.FlushTimeout(flushTimeout); err != nil {
+		return fmt.Errorf("error in flushing messages to nats %s", err)
+	}
 
 	return nil
 }
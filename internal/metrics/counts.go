@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// OntologyCounts keeps gauges of the number of live annotations and of
+// groups per ontology. Counting scans the annotations, so the gauges are
+// refreshed at an interval instead of at every scrape.
+type OntologyCounts struct {
+	repo        repository.TaggedAnnotationRepository
+	annotations *prometheus.GaugeVec
+	groups      *prometheus.GaugeVec
+}
+
+// NewOntologyCounts creates and registers the per ontology gauges.
+func NewOntologyCounts(
+	repo repository.TaggedAnnotationRepository,
+	reg prometheus.Registerer,
+) *OntologyCounts {
+	occ := &OntologyCounts{
+		repo: repo,
+		annotations: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "annotations",
+				Help:      "Number of live annotations by ontology.",
+			},
+			[]string{"ontology"},
+		),
+		groups: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "annotation_groups",
+				Help:      "Number of annotation groups with a member in the ontology.",
+			},
+			[]string{"ontology"},
+		),
+	}
+	reg.MustRegister(occ.annotations, occ.groups)
+
+	return occ
+}
+
+// Refresh sets the gauges from the current counts.
+func (occ *OntologyCounts) Refresh(ctx context.Context) error {
+	counts, err := occ.repo.CountByOntology(ctx)
+	if err != nil {
+		return err
+	}
+	occ.annotations.Reset()
+	occ.groups.Reset()
+	for _, cnt := range counts {
+		occ.annotations.WithLabelValues(cnt.Ontology).Set(float64(cnt.Annotations))
+		occ.groups.WithLabelValues(cnt.Ontology).Set(float64(cnt.Groups))
+	}
+
+	return nil
+}
+
+// Watch refreshes the gauges at every interval until done is closed. A
+// refresh is abandoned when it takes longer than the interval.
+func (occ *OntologyCounts) Watch(
+	interval time.Duration,
+	done <-chan struct{},
+	logger *logrus.Entry,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := occ.Refresh(ctx); err != nil {
+			logger.Errorf("unable to count annotations %s", err)
+		}
+		cancel()
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics exposes prometheus metrics of the grpc server, the
+// repository, the message publisher and the annotation counts.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "modware_annotation"
+
+// ServerMetrics records the count and the latency of grpc requests per
+// method and status code.
+type ServerMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewServerMetrics creates and registers the grpc request metrics.
+func NewServerMetrics(reg prometheus.Registerer) *ServerMetrics {
+	smt := &ServerMetrics{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "grpc_requests_total",
+				Help:      "Number of grpc requests by method and status code.",
+			},
+			[]string{"method", "code"},
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "grpc_request_duration_seconds",
+				Help:      "Latency of grpc requests by method and status code.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method", "code"},
+		),
+	}
+	reg.MustRegister(smt.requests, smt.duration)
+
+	return smt
+}
+
+// Unary returns the interceptor for unary rpcs.
+func (smt *ServerMetrics) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		smt.observe(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// Stream returns the interceptor for streaming rpcs.
+func (smt *ServerMetrics) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		smt.observe(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func (smt *ServerMetrics) observe(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	smt.requests.WithLabelValues(method, code).Inc()
+	smt.duration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+// NewServer returns the http server exposing the metrics of the registry
+// at /metrics.
+func NewServer(addr string, gatherer prometheus.Gatherer) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// ListenAndServe serves the metrics until the server is shut down.
+func ListenAndServe(srv *http.Server) error {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
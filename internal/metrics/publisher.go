@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/message"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metricsPublisher struct {
+	message.Publisher
+	published *prometheus.CounterVec
+}
+
+// NewPublisher wraps a publisher to count the published messages per
+// subject and result.
+func NewPublisher(
+	pub message.Publisher,
+	reg prometheus.Registerer,
+) message.Publisher {
+	mpb := &metricsPublisher{
+		Publisher: pub,
+		published: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "messages_published_total",
+				Help:      "Number of published messages by subject and result.",
+			},
+			[]string{"subject", "result"},
+		),
+	}
+	reg.MustRegister(mpb.published)
+
+	return mpb
+}
+
+func (mp *metricsPublisher) Publish(
+	ctx context.Context,
+	subject string,
+	ann *annotation.TaggedAnnotation,
+) error {
+	err := mp.Publisher.Publish(ctx, subject, ann)
+	mp.count(subject, err)
+
+	return err
+}
+
+func (mp *metricsPublisher) PublishDelete(
+	ctx context.Context,
+	subject string,
+	ann *annotation.TaggedAnnotation,
+	del *message.Deletion,
+) error {
+	err := mp.Publisher.PublishDelete(ctx, subject, ann, del)
+	mp.count(subject, err)
+
+	return err
+}
+
+func (mp *metricsPublisher) count(subject string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	mp.published.WithLabelValues(subject, result).Inc()
+}
@@ -0,0 +1,447 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	manager "github.com/dictyBase/arangomanager"
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/go-obograph/storage"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsRepository wraps every method of the repository, it does not embed
+// the interface so that a method added to it fails to compile until it is
+// wrapped here.
+type metricsRepository struct {
+	repo     repository.TaggedAnnotationRepository
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewTaggedAnnotationRepo wraps a repository to record the latency and the
+// errors of every method.
+func NewTaggedAnnotationRepo(
+	repo repository.TaggedAnnotationRepository,
+	reg prometheus.Registerer,
+) repository.TaggedAnnotationRepository {
+	mr := &metricsRepository{
+		repo: repo,
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "repository_duration_seconds",
+				Help:      "Latency of repository methods.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "repository_errors_total",
+				Help:      "Number of repository method calls returning an error.",
+			},
+			[]string{"method"},
+		),
+	}
+	reg.MustRegister(mr.duration, mr.errors)
+
+	return mr
+}
+
+// observe runs a repository method and records its latency, and its error
+// if any, under the method name.
+func observe[T any](mr *metricsRepository, method string, call func() (T, error)) (res T, err error) {
+	start := time.Now()
+	defer func() {
+		mr.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			mr.errors.WithLabelValues(method).Inc()
+		}
+	}()
+
+	return call()
+}
+
+// observeErr is observe for the methods that only return an error.
+func observeErr(mr *metricsRepository, method string, call func() error) error {
+	_, err := observe(mr, method, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+
+	return err
+}
+
+func (mr *metricsRepository) GetAnnotationByID(ctx context.Context, id string) (*model.AnnoDoc, error) {
+	return observe(mr, "GetAnnotationByID", func() (*model.AnnoDoc, error) {
+		return mr.repo.GetAnnotationByID(ctx, id)
+	})
+}
+
+func (mr *metricsRepository) GetAnnotationByEntry(
+	ctx context.Context,
+	req *annotation.EntryAnnotationRequest,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "GetAnnotationByEntry", func() (*model.AnnoDoc, error) {
+		return mr.repo.GetAnnotationByEntry(ctx, req)
+	})
+}
+
+func (mr *metricsRepository) GetEntryProfile(
+	ctx context.Context,
+	entryID string,
+) (*model.EntryProfile, error) {
+	return observe(mr, "GetEntryProfile", func() (*model.EntryProfile, error) {
+		return mr.repo.GetEntryProfile(ctx, entryID)
+	})
+}
+
+func (mr *metricsRepository) AddAnnotation(
+	ctx context.Context,
+	na *annotation.NewTaggedAnnotation,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "AddAnnotation", func() (*model.AnnoDoc, error) {
+		return mr.repo.AddAnnotation(ctx, na)
+	})
+}
+
+func (mr *metricsRepository) EditAnnotation(
+	ctx context.Context,
+	ua *annotation.TaggedAnnotationUpdate,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "EditAnnotation", func() (*model.AnnoDoc, error) {
+		return mr.repo.EditAnnotation(ctx, ua)
+	})
+}
+
+func (mr *metricsRepository) EditAnnotationAtVersion(
+	ctx context.Context,
+	ua *annotation.TaggedAnnotationUpdate, version int64,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "EditAnnotationAtVersion", func() (*model.AnnoDoc, error) {
+		return mr.repo.EditAnnotationAtVersion(ctx, ua, version)
+	})
+}
+
+func (mr *metricsRepository) RemoveAnnotation(ctx context.Context, id string, purge bool) error {
+	return observeErr(mr, "RemoveAnnotation", func() error {
+		return mr.repo.RemoveAnnotation(ctx, id, purge)
+	})
+}
+
+func (mr *metricsRepository) RemoveAnnotationBy(
+	ctx context.Context,
+	id string, purge bool, info *model.DeleteInfo,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "RemoveAnnotationBy", func() (*model.AnnoDoc, error) {
+		return mr.repo.RemoveAnnotationBy(ctx, id, purge, info)
+	})
+}
+
+func (mr *metricsRepository) RestoreAnnotation(
+	ctx context.Context,
+	id, restoredBy string,
+) (*model.AnnoDoc, error) {
+	return observe(mr, "RestoreAnnotation", func() (*model.AnnoDoc, error) {
+		return mr.repo.RestoreAnnotation(ctx, id, restoredBy)
+	})
+}
+
+func (mr *metricsRepository) ListAnnotations(
+	ctx context.Context,
+	cursor int64, limit int64, filter string,
+) ([]*model.AnnoDoc, error) {
+	return observe(mr, "ListAnnotations", func() ([]*model.AnnoDoc, error) {
+		return mr.repo.ListAnnotations(ctx, cursor, limit, filter)
+	})
+}
+
+func (mr *metricsRepository) ListObsoleteAnnotations(
+	ctx context.Context,
+	cursor int64, limit int64, filter string,
+) ([]*model.AnnoDoc, error) {
+	return observe(mr, "ListObsoleteAnnotations", func() ([]*model.AnnoDoc, error) {
+		return mr.repo.ListObsoleteAnnotations(ctx, cursor, limit, filter)
+	})
+}
+
+func (mr *metricsRepository) ClearAnnotations(ctx context.Context) error {
+	return observeErr(mr, "ClearAnnotations", func() error {
+		return mr.repo.ClearAnnotations(ctx)
+	})
+}
+
+func (mr *metricsRepository) Clear(ctx context.Context) error {
+	return observeErr(mr, "Clear", func() error {
+		return mr.repo.Clear(ctx)
+	})
+}
+
+func (mr *metricsRepository) AddAnnotationGroup(
+	ctx context.Context,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "AddAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.AddAnnotationGroup(ctx, idslice...)
+	})
+}
+
+func (mr *metricsRepository) AddTypedAnnotationGroup(
+	ctx context.Context,
+	params *model.GroupParams, idslice ...string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "AddTypedAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.AddTypedAnnotationGroup(ctx, params, idslice...)
+	})
+}
+
+func (mr *metricsRepository) EditAnnotationGroup(
+	ctx context.Context,
+	groupID, name, description, updatedBy string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "EditAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.EditAnnotationGroup(ctx, groupID, name, description, updatedBy)
+	})
+}
+
+func (mr *metricsRepository) MoveAnnotationGroupMember(
+	ctx context.Context,
+	groupID, annoID string, position int64,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "MoveAnnotationGroupMember", func() (*model.AnnoGroup, error) {
+		return mr.repo.MoveAnnotationGroupMember(ctx, groupID, annoID, position)
+	})
+}
+
+func (mr *metricsRepository) ReorderAnnotationGroup(
+	ctx context.Context,
+	groupID string, idslice ...string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "ReorderAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.ReorderAnnotationGroup(ctx, groupID, idslice...)
+	})
+}
+
+func (mr *metricsRepository) BulkEditAnnotations(
+	ctx context.Context,
+	filter string, edit *model.BulkEdit, dryRun bool,
+) (*model.BulkResult, error) {
+	return observe(mr, "BulkEditAnnotations", func() (*model.BulkResult, error) {
+		return mr.repo.BulkEditAnnotations(ctx, filter, edit, dryRun)
+	})
+}
+
+func (mr *metricsRepository) BulkObsoleteAnnotations(
+	ctx context.Context,
+	filter string, info *model.DeleteInfo, dryRun bool,
+) (*model.BulkResult, error) {
+	return observe(mr, "BulkObsoleteAnnotations", func() (*model.BulkResult, error) {
+		return mr.repo.BulkObsoleteAnnotations(ctx, filter, info, dryRun)
+	})
+}
+
+func (mr *metricsRepository) ApplyBatch(
+	ctx context.Context,
+	ops []*model.BatchOperation,
+) ([]*model.BatchResult, error) {
+	return observe(mr, "ApplyBatch", func() ([]*model.BatchResult, error) {
+		return mr.repo.ApplyBatch(ctx, ops)
+	})
+}
+
+func (mr *metricsRepository) ListGroupsByAnnotation(
+	ctx context.Context,
+	annoID string,
+) ([]*model.AnnoGroup, error) {
+	return observe(mr, "ListGroupsByAnnotation", func() ([]*model.AnnoGroup, error) {
+		return mr.repo.ListGroupsByAnnotation(ctx, annoID)
+	})
+}
+
+func (mr *metricsRepository) ListGroupsByAnnotations(
+	ctx context.Context,
+	annoIDs []string,
+) ([]*model.AnnoGroup, error) {
+	return observe(mr, "ListGroupsByAnnotations", func() ([]*model.AnnoGroup, error) {
+		return mr.repo.ListGroupsByAnnotations(ctx, annoIDs)
+	})
+}
+
+func (mr *metricsRepository) ListGroupsByEntry(
+	ctx context.Context,
+	entryID string,
+) ([]*model.AnnoGroup, error) {
+	return observe(mr, "ListGroupsByEntry", func() ([]*model.AnnoGroup, error) {
+		return mr.repo.ListGroupsByEntry(ctx, entryID)
+	})
+}
+
+func (mr *metricsRepository) ListAnnotationGroupVersions(
+	ctx context.Context,
+	groupID string,
+) ([]*model.GroupVersion, error) {
+	return observe(mr, "ListAnnotationGroupVersions", func() ([]*model.GroupVersion, error) {
+		return mr.repo.ListAnnotationGroupVersions(ctx, groupID)
+	})
+}
+
+func (mr *metricsRepository) GetAnnotationGroupVersion(
+	ctx context.Context,
+	groupID string, version int64,
+) (*model.GroupVersion, error) {
+	return observe(mr, "GetAnnotationGroupVersion", func() (*model.GroupVersion, error) {
+		return mr.repo.GetAnnotationGroupVersion(ctx, groupID, version)
+	})
+}
+
+func (mr *metricsRepository) SetGroupType(
+	ctx context.Context,
+	gtp *model.GroupType,
+) (*model.GroupType, error) {
+	return observe(mr, "SetGroupType", func() (*model.GroupType, error) {
+		return mr.repo.SetGroupType(ctx, gtp)
+	})
+}
+
+func (mr *metricsRepository) GetGroupType(
+	ctx context.Context,
+	ontology, tag string,
+) (*model.GroupType, error) {
+	return observe(mr, "GetGroupType", func() (*model.GroupType, error) {
+		return mr.repo.GetGroupType(ctx, ontology, tag)
+	})
+}
+
+func (mr *metricsRepository) GetAnnotationGroup(
+	ctx context.Context,
+	groupID string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "GetAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.GetAnnotationGroup(ctx, groupID)
+	})
+}
+
+func (mr *metricsRepository) AppendToAnnotationGroup(
+	ctx context.Context,
+	groupID string, idslice ...string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "AppendToAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.AppendToAnnotationGroup(ctx, groupID, idslice...)
+	})
+}
+
+func (mr *metricsRepository) RemoveAnnotationGroup(ctx context.Context, groupID string) error {
+	return observeErr(mr, "RemoveAnnotationGroup", func() error {
+		return mr.repo.RemoveAnnotationGroup(ctx, groupID)
+	})
+}
+
+func (mr *metricsRepository) RemoveFromAnnotationGroup(
+	ctx context.Context,
+	groupID string, idslice ...string,
+) (*model.AnnoGroup, error) {
+	return observe(mr, "RemoveFromAnnotationGroup", func() (*model.AnnoGroup, error) {
+		return mr.repo.RemoveFromAnnotationGroup(ctx, groupID, idslice...)
+	})
+}
+
+func (mr *metricsRepository) ListAnnotationGroup(
+	ctx context.Context,
+	cursor, limit int64, filter string,
+) ([]*model.AnnoGroup, error) {
+	return observe(mr, "ListAnnotationGroup", func() ([]*model.AnnoGroup, error) {
+		return mr.repo.ListAnnotationGroup(ctx, cursor, limit, filter)
+	})
+}
+
+func (mr *metricsRepository) ListAnnotationGroupByType(
+	ctx context.Context,
+	cursor, limit int64, filter, ontology, tag string,
+) ([]*model.AnnoGroup, error) {
+	return observe(mr, "ListAnnotationGroupByType", func() ([]*model.AnnoGroup, error) {
+		return mr.repo.ListAnnotationGroupByType(ctx, cursor, limit, filter, ontology, tag)
+	})
+}
+
+func (mr *metricsRepository) DanglingReferences(
+	ctx context.Context,
+	repair bool,
+) (*model.DanglingReport, error) {
+	return observe(mr, "DanglingReferences", func() (*model.DanglingReport, error) {
+		return mr.repo.DanglingReferences(ctx, repair)
+	})
+}
+
+func (mr *metricsRepository) CountByOntology(ctx context.Context) ([]*model.OntologyCount, error) {
+	return observe(mr, "CountByOntology", func() ([]*model.OntologyCount, error) {
+		return mr.repo.CountByOntology(ctx)
+	})
+}
+
+func (mr *metricsRepository) GetAnnotationTag(
+	ctx context.Context,
+	name, ontology string,
+) (*model.AnnoTag, error) {
+	return observe(mr, "GetAnnotationTag", func() (*model.AnnoTag, error) {
+		return mr.repo.GetAnnotationTag(ctx, name, ontology)
+	})
+}
+
+func (mr *metricsRepository) GetAnnotationTags(
+	ctx context.Context,
+	keys []*model.TagKey,
+) ([]*model.AnnoTag, error) {
+	return observe(mr, "GetAnnotationTags", func() ([]*model.AnnoTag, error) {
+		return mr.repo.GetAnnotationTags(ctx, keys)
+	})
+}
+
+func (mr *metricsRepository) GetOntologies(
+	ctx context.Context,
+	namespaces []string,
+) ([]*model.Ontology, error) {
+	return observe(mr, "GetOntologies", func() ([]*model.Ontology, error) {
+		return mr.repo.GetOntologies(ctx, namespaces)
+	})
+}
+
+func (mr *metricsRepository) AddAuditEntry(
+	ctx context.Context,
+	entry *model.AuditEntry,
+) (*model.AuditEntry, error) {
+	return observe(mr, "AddAuditEntry", func() (*model.AuditEntry, error) {
+		return mr.repo.AddAuditEntry(ctx, entry)
+	})
+}
+
+func (mr *metricsRepository) ListAuditEntries(
+	ctx context.Context,
+	filter *model.AuditFilter,
+) ([]*model.AuditEntry, error) {
+	return observe(mr, "ListAuditEntries", func() ([]*model.AuditEntry, error) {
+		return mr.repo.ListAuditEntries(ctx, filter)
+	})
+}
+
+func (mr *metricsRepository) LoadOboJSON(
+	ctx context.Context,
+	r io.Reader,
+) (*storage.UploadInformation, error) {
+	return observe(mr, "LoadOboJSON", func() (*storage.UploadInformation, error) {
+		return mr.repo.LoadOboJSON(ctx, r)
+	})
+}
+
+// Dbh is not measured, it only hands out the database handle.
+func (mr *metricsRepository) Dbh() *manager.Database {
+	return mr.repo.Dbh()
+}
+
+// Close is not measured, it runs once at shutdown.
+func (mr *metricsRepository) Close() error {
+	return mr.repo.Close()
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// unmeasured are the repository methods passed through without metrics.
+var unmeasured = map[string]bool{"Dbh": true, "Close": true}
+
+type failingRepo struct {
+	repository.TaggedAnnotationRepository
+}
+
+func (fr *failingRepo) GetAnnotationByID(_ context.Context, _ string) (*model.AnnoDoc, error) {
+	return &model.AnnoDoc{}, errors.New("annotation not found")
+}
+
+// callMethod calls a method with zero arguments, the nil repository under
+// the wrapper panics once the call reaches it.
+func callMethod(mtd reflect.Value) {
+	defer func() {
+		_ = recover()
+	}()
+	args := make([]reflect.Value, mtd.Type().NumIn())
+	for i := range args {
+		args[i] = reflect.Zero(mtd.Type().In(i))
+	}
+	if mtd.Type().IsVariadic() {
+		mtd.CallSlice(args)
+
+		return
+	}
+	mtd.Call(args)
+}
+
+func TestEveryMethodObserved(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	reg := prometheus.NewPedanticRegistry()
+	mrp := NewTaggedAnnotationRepo(nil, reg)
+	iface := reflect.TypeOf((*repository.TaggedAnnotationRepository)(nil)).Elem()
+	wrapped := reflect.ValueOf(mrp)
+	for i := 0; i < iface.NumMethod(); i++ {
+		callMethod(wrapped.MethodByName(iface.Method(i).Name))
+	}
+	families, err := reg.Gather()
+	assert.NoError(err, "expect no error from gathering the metrics")
+	observed := make(map[string]uint64)
+	for _, fam := range families {
+		if fam.GetName() != namespace+"_repository_duration_seconds" {
+			continue
+		}
+		for _, mtc := range fam.GetMetric() {
+			observed[mtc.GetLabel()[0].GetValue()] = mtc.GetHistogram().GetSampleCount()
+		}
+	}
+	for i := 0; i < iface.NumMethod(); i++ {
+		name := iface.Method(i).Name
+		if unmeasured[name] {
+			assert.NotContains(observed, name, "should not record %s", name)
+
+			continue
+		}
+		assert.Equal(uint64(1), observed[name], "should record the latency of %s under its name", name)
+	}
+	assert.Len(observed, iface.NumMethod()-len(unmeasured), "should record no other method")
+}
+
+func TestObserveErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	reg := prometheus.NewPedanticRegistry()
+	mrp := NewTaggedAnnotationRepo(&failingRepo{}, reg)
+	_, err := mrp.GetAnnotationByID(context.Background(), "4589")
+	assert.Error(err, "expect the error of the repository")
+	errs := mrp.(*metricsRepository).errors
+	assert.Equal(1.0, testutil.ToFloat64(errs.WithLabelValues("GetAnnotationByID")), "should count the error")
+	assert.Equal(0.0, testutil.ToFloat64(errs.WithLabelValues("AddAnnotation")), "should not count other methods")
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	driver "github.com/arangodb/go-driver"
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 )
 
 type UploadStatus int
@@ -23,6 +24,21 @@ type AnnoTag struct {
 	Ontology   string `json:"ontology"`
 }
 
+// TagKey identifies a tag by its name and ontology.
+type TagKey struct {
+	Name     string `json:"name"`
+	Ontology string `json:"ontology"`
+}
+
+// Ontology describes a loaded ontology, it is identified by its namespace.
+type Ontology struct {
+	ID        string `json:"id"`
+	IRI       string `json:"iri"`
+	Label     string `json:"label"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+}
+
 type AnnoDoc struct {
 	driver.DocumentMeta
 	Value         string    `json:"value"`
@@ -36,44 +52,245 @@ type AnnoDoc struct {
 	Ontology      string    `json:"ontology,omitempty"`
 	Tag           string    `json:"tag,omitempty"`
 	CvtId         string    `json:"cvtid,omitempty"`
-	NotFound      bool
+	Groups        []string  `json:"groups,omitempty"`
+	// RestoredBy and RestoredAt record the last restore of an obsolete
+	// annotation
+	RestoredBy string     `json:"restored_by,omitempty"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+	// DeletedBy, DeletedAt and DeleteReason record the soft delete of
+	// an obsolete annotation
+	DeletedBy    string     `json:"deleted_by,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+	NotFound     bool
+}
+
+// DeleteInfo records who removed an annotation and why.
+type DeleteInfo struct {
+	DeletedBy string `json:"deleted_by"`
+	Reason    string `json:"reason"`
+}
+
+// TagAnnotations are the annotations of an entry sharing an
+// identical ontology and tag, ordered by rank.
+type TagAnnotations struct {
+	Ontology string     `json:"ontology"`
+	Tag      string     `json:"tag"`
+	AnnoDocs []*AnnoDoc `json:"annotations"`
+}
+
+// EntryProfile contains all live annotations of a biological entry.
+type EntryProfile struct {
+	EntryId string            `json:"entry_id"`
+	Tags    []*TagAnnotations `json:"tags"`
 }
 
 type AnnoGroup struct {
-	AnnoDocs  []*AnnoDoc `json:"annotations"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	GroupId   string     `json:"group_id"`
+	// AnnoDocs are the members in the order of their position in the group
+	AnnoDocs      []*AnnoDoc `json:"annotations"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	GroupId       string     `json:"group_id"`
+	GroupType     string     `json:"group_type,omitempty"`
+	GroupOntology string     `json:"group_ontology,omitempty"`
+	Name          string     `json:"name,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	UpdatedBy     string     `json:"updated_by,omitempty"`
+	Version       int64      `json:"version"`
 }
 
 type DbGroup struct {
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Group         []string  `json:"group"`
+	GroupId       string    `json:"_key,omitempty"`
+	GroupType     string    `json:"group_type,omitempty"`
+	GroupOntology string    `json:"group_ontology,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	UpdatedBy     string    `json:"updated_by,omitempty"`
+	Version       int64     `json:"version"`
+}
+
+// GroupVersion is the membership of an annotation group after one of its
+// changes.
+type GroupVersion struct {
+	driver.DocumentMeta
+	GroupId   string    `json:"group_id"`
+	Version   int64     `json:"version"`
+	Members   []string  `json:"group"`
+	Action    string    `json:"action"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Group     []string  `json:"group"`
-	GroupId   string    `json:"_key,omitempty"`
 }
 
-func UniqueModel(a []*AnnoDoc) []*AnnoDoc {
-	mdoc := make([]*AnnoDoc, 0)
+// GroupType gives meaning to annotation groups through an ontology term
+// and defines the rules for their members.
+type GroupType struct {
+	driver.DocumentMeta
+	Ontology string `json:"ontology"`
+	Tag      string `json:"tag"`
+	// AllowedTags restricts the tags of the members, any tag is allowed
+	// when empty
+	AllowedTags []string `json:"allowed_tags"`
+	// MinMembers is the least number of members, no lower limit when zero
+	MinMembers int64 `json:"min_members"`
+	// MaxMembers is the largest number of members, no upper limit when zero
+	MaxMembers int64 `json:"max_members"`
+}
+
+// GroupParams are the optional attributes of an annotation group.
+type GroupParams struct {
+	// Ontology and Tag identify the group type
+	Ontology    string `json:"ontology"`
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedBy   string `json:"created_by"`
+}
+
+// Actions of the operations in a batch.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+	BatchGroup  = "group"
+	BatchAppend = "append"
+)
+
+// BatchOperation is a single change applied as part of a batch. An
+// identifier of the form $ref refers to the annotation or group created or
+// updated by an earlier operation of the batch labeled with ref.
+type BatchOperation struct {
+	Action string `json:"action"`
+	Ref    string `json:"ref"`
+	// Id is the annotation to update or delete, or the group to append to
+	Id string `json:"id"`
+	// Ids are the members of a new group or the members to append
+	Ids []string `json:"ids"`
+	// Version is the expected current version of the annotation to update,
+	// it is not checked when zero
+	Version int64                                        `json:"version"`
+	Create  *annotation.NewTaggedAnnotationAttributes    `json:"create"`
+	Update  *annotation.TaggedAnnotationUpdateAttributes `json:"update"`
+	Group   *GroupParams                                 `json:"group"`
+	// Delete records who deleted the annotation and why
+	Delete *DeleteInfo `json:"delete"`
+}
+
+// BatchResult is the outcome of an applied batch operation.
+type BatchResult struct {
+	Action string `json:"action"`
+	Ref    string `json:"ref,omitempty"`
+	Id     string `json:"id"`
+}
+
+// BulkEdit is a change applied to many annotations at once.
+type BulkEdit struct {
+	// CreatedBy replaces the curator of the new versions when not empty
+	CreatedBy string
+	// Pattern is a regular expression whose matches in the value and the
+	// editable value are replaced with Replacement
+	Pattern     string
+	Replacement string
+}
+
+// BulkResult is the outcome of a bulk change.
+type BulkResult struct {
+	DryRun bool  `json:"dry_run"`
+	Count  int64 `json:"count"`
+	// Ids are the annotations changed, or to be changed with a dry run
+	Ids []string `json:"ids"`
+	// NewIds are the new versions created by an edit
+	NewIds []string `json:"new_ids,omitempty"`
+}
+
+// DanglingMembers are the identifiers in a group whose annotations no
+// longer exist.
+type DanglingMembers struct {
+	GroupId string   `json:"group_id"`
+	Members []string `json:"members"`
+}
+
+// DanglingReport lists references to annotations that no longer exist.
+type DanglingReport struct {
+	Groups       []*DanglingMembers `json:"groups"`
+	TagEdges     []string           `json:"tag_edges"`
+	VersionEdges []string           `json:"version_edges"`
+}
+
+// UniqueIds removes the duplicate identifiers while keeping the order of
+// their first appearance.
+func UniqueIds(ids []string) []string {
+	uids := make([]string, 0)
 	hmap := make(map[string]int)
-	for _, m := range a {
-		if _, ok := hmap[m.Key]; ok {
+	for _, id := range ids {
+		if _, ok := hmap[id]; ok {
 			continue
 		}
-		mdoc = append(mdoc, m)
-		hmap[m.Key] = 1
+		uids = append(uids, id)
+		hmap[id] = 1
 	}
 
-	return mdoc
+	return uids
 }
 
-func DocToIds(ml []*AnnoDoc) []string {
-	str := make([]string, 0)
-	for _, m := range ml {
-		str = append(str, m.Key)
-	}
+// OntologyCount is the number of live annotations and of groups with a
+// member in an ontology.
+type OntologyCount struct {
+	Ontology    string `json:"ontology"`
+	Annotations int64  `json:"annotations"`
+	Groups      int64  `json:"groups"`
+}
+
+// AuditEntry is an immutable record of a mutating repository call.
+type AuditEntry struct {
+	driver.DocumentMeta
+	// Operation is the name of the repository method
+	Operation string    `json:"operation"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+	// Digest is the hex encoded sha256 of the request payload
+	Digest string `json:"digest"`
+	// Keys are the identifiers of the affected annotations and groups
+	Keys []string `json:"keys"`
+	// EntryIds are the biological entries of the affected annotations
+	EntryIds []string `json:"entry_ids"`
+}
+
+// AuditFilter restricts the audit entries to retrieve. Empty or zero fields
+// are not used for filtering.
+type AuditFilter struct {
+	Actor   string
+	EntryId string
+	Since   time.Time
+	Until   time.Time
+	// Cursor and CursorKey are the creation time, in milliseconds, and the
+	// key of the last entry of the previous page
+	Cursor    int64
+	CursorKey string
+	Limit     int64
+}
+
+// AuditPage is a page of audit entries, NextCursor is zero on the last
+// page.
+type AuditPage struct {
+	Entries    []*AuditEntry `json:"entries"`
+	NextCursor int64         `json:"next_cursor"`
+	NextKey    string        `json:"next_key"`
+}
+
+// AuditCursor is the cursor of the audit entries listed after an entry.
+func AuditCursor(aent *AuditEntry) (int64, string) {
+	return aent.CreatedAt.UnixNano() / int64(time.Millisecond), aent.Key
+}
 
-	return str
+// AnnotationPage is a page of annotations, NextCursor is zero on the last
+// page.
+type AnnotationPage struct {
+	Annotations []*AnnoDoc `json:"annotations"`
+	NextCursor  int64      `json:"next_cursor"`
 }
 
 func ConvToModel(i interface{}) (*AnnoDoc, error) {
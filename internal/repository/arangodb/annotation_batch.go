@@ -0,0 +1,275 @@
+package arangodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+)
+
+// batchErrorNum is the error number raised by a failed batch operation,
+// it matches ERROR_BAD_PARAMETER of arangodb.
+const batchErrorNum = 10
+
+// ApplyBatch runs the operations in order within a single transaction.
+// Either every operation is applied or none of them.
+func (ar *arangorepository) ApplyBatch(
+	ctx context.Context,
+	ops []*model.BatchOperation,
+) ([]*model.BatchResult, error) {
+	var res []*model.BatchResult
+	if len(ops) == 0 {
+		return res, &repository.BatchError{Reason: "no operation to apply"}
+	}
+	bops, err := ar.batchParams(ctx, ops)
+	if err != nil {
+		return res, err
+	}
+	size, err := ar.batchSize(ctx, bops)
+	if err != nil {
+		return res, err
+	}
+	ctx, span := ar.startSpan(ctx, "transaction", "annBatchFn")
+	dbh := ar.database.Handler()
+	out, err := dbh.Transaction(
+		ctx,
+		annBatchFn,
+		&driver.TransactionOptions{
+			WriteCollections: []string{
+				ar.anno.annot.Name(),
+				ar.anno.term.Name(),
+				ar.anno.ver.Name(),
+				ar.anno.annog.Name(),
+				ar.anno.annogv.Name(),
+			},
+			Params:             []interface{}{ar.batchConfig(), bops},
+			MaxTransactionSize: transactionSize(size),
+		})
+	endSpan(span, err)
+	if err != nil {
+		return res, batchTransactionError(err)
+	}
+	ct, err := json.Marshal(out)
+	if err != nil {
+		return res, fmt.Errorf("error in encoding batch result %s", err)
+	}
+	if err := json.Unmarshal(ct, &res); err != nil {
+		return res, fmt.Errorf("error in decoding batch result %s", err)
+	}
+
+	return res, nil
+}
+
+// batchSize estimates the size in bytes written by the updates of a batch,
+// the other operations fit within maxTransactionSize.
+func (ar *arangorepository) batchSize(ctx context.Context, bops []map[string]interface{}) (int, error) {
+	var edits []map[string]interface{}
+	for _, bop := range bops {
+		if bop["action"] != model.BatchUpdate {
+			continue
+		}
+		upd, _ := bop["update"].(map[string]interface{})
+		edits = append(edits, map[string]interface{}{
+			"key":            bop["id"],
+			"value":          upd["value"],
+			"editable_value": upd["editable_value"],
+		})
+	}
+	if len(edits) == 0 {
+		return 0, nil
+	}
+	members, err := ar.groupMembers(ctx, editKeys(edits))
+	if err != nil {
+		return 0, err
+	}
+	size := 0
+	for _, e := range edits {
+		size += editSize(e, members)
+	}
+
+	return size, nil
+}
+
+// batchParams validates the operations and converts them to the
+// parameters of the batch transaction.
+func (ar *arangorepository) batchParams(
+	ctx context.Context,
+	ops []*model.BatchOperation,
+) ([]map[string]interface{}, error) {
+	bops := make([]map[string]interface{}, 0)
+	refs := make(map[string]bool)
+	for idx, bop := range ops {
+		if err := validateBatchRefs(idx, bop, refs); err != nil {
+			return bops, err
+		}
+		prm := map[string]interface{}{"action": bop.Action, "ref": bop.Ref}
+		switch bop.Action {
+		case model.BatchCreate:
+			if bop.Create == nil {
+				return bops, &repository.BatchError{Index: idx, Reason: "missing annotation attributes"}
+			}
+			cvtid, err := ar.termID(ctx, bop.Create.Ontology, bop.Create.Tag)
+			if err != nil {
+				return bops, &repository.BatchError{Index: idx, Reason: err.Error()}
+			}
+			tag, err := ar.termName(ctx, cvtid)
+			if err != nil {
+				return bops, &repository.BatchError{Index: idx, Reason: err.Error()}
+			}
+			prm["exist"] = map[string]interface{}{
+				"entry_id": bop.Create.EntryId,
+				"rank":     bop.Create.Rank,
+				"ontology": bop.Create.Ontology,
+				"tag":      tag,
+			}
+			prm["create"] = map[string]interface{}{
+				"editable_value": bop.Create.EditableValue,
+				"created_by":     bop.Create.CreatedBy,
+				"entry_id":       bop.Create.EntryId,
+				"rank":           bop.Create.Rank,
+				"value":          bop.Create.Value,
+				"to":             cvtid,
+			}
+		case model.BatchUpdate:
+			if bop.Update == nil || len(bop.Id) == 0 {
+				return bops, &repository.BatchError{Index: idx, Reason: "missing annotation id or attributes"}
+			}
+			prm["id"] = bop.Id
+			prm["version"] = bop.Version
+			prm["update"] = map[string]interface{}{
+				"value":          bop.Update.Value,
+				"editable_value": bop.Update.EditableValue,
+				"created_by":     bop.Update.CreatedBy,
+			}
+		case model.BatchDelete:
+			if len(bop.Id) == 0 {
+				return bops, &repository.BatchError{Index: idx, Reason: "missing annotation id"}
+			}
+			prm["id"] = bop.Id
+			del := bop.Delete
+			if del == nil {
+				del = &model.DeleteInfo{}
+			}
+			prm["delete"] = map[string]interface{}{
+				"deleted_by": del.DeletedBy,
+				"reason":     del.Reason,
+			}
+		case model.BatchGroup:
+			if len(bop.Ids) <= 1 {
+				return bops, &repository.BatchError{Index: idx, Reason: "need more than one member for a group"}
+			}
+			gpr := bop.Group
+			if gpr == nil {
+				gpr = &model.GroupParams{}
+			}
+			prm["ids"] = bop.Ids
+			prm["group"] = map[string]interface{}{
+				"group_type":     nullString(gpr.Tag),
+				"group_ontology": nullString(gpr.Ontology),
+				"name":           nullString(gpr.Name),
+				"description":    nullString(gpr.Description),
+				"created_by":     nullString(gpr.CreatedBy),
+			}
+		case model.BatchAppend:
+			if len(bop.Id) == 0 || len(bop.Ids) == 0 {
+				return bops, &repository.BatchError{Index: idx, Reason: "missing group id or members"}
+			}
+			prm["id"] = bop.Id
+			prm["ids"] = bop.Ids
+			prm["group"] = map[string]interface{}{}
+		default:
+			return bops, &repository.BatchError{
+				Index:  idx,
+				Reason: fmt.Sprintf("unknown action %s", bop.Action),
+			}
+		}
+		bops = append(bops, prm)
+		if len(bop.Ref) > 0 {
+			refs[bop.Ref] = true
+		}
+	}
+
+	return bops, nil
+}
+
+// validateBatchRefs checks that every reference of an operation is
+// labeled by an earlier operation.
+func validateBatchRefs(idx int, bop *model.BatchOperation, refs map[string]bool) error {
+	for _, id := range append([]string{bop.Id}, bop.Ids...) {
+		if !strings.HasPrefix(id, "$") {
+			continue
+		}
+		if !refs[strings.TrimPrefix(id, "$")] {
+			return &repository.BatchError{
+				Index:  idx,
+				Reason: fmt.Sprintf("unknown reference %s", id),
+			}
+		}
+	}
+	if len(bop.Ref) > 0 && refs[bop.Ref] {
+		return &repository.BatchError{
+			Index:  idx,
+			Reason: fmt.Sprintf("duplicate reference %s", bop.Ref),
+		}
+	}
+
+	return nil
+}
+
+// batchConfig provides the collections and queries used by the batch
+// transaction.
+func (ar *arangorepository) batchConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"error_num":           batchErrorNum,
+		"anno_collection":     ar.anno.annot.Name(),
+		"anno_cv_collection":  ar.anno.term.Name(),
+		"anno_ver_collection": ar.anno.ver.Name(),
+		"exist_query":         annExistQ,
+		"exist_vars": map[string]interface{}{
+			"@anno_collection":  ar.anno.annot.Name(),
+			"@cv_collection":    ar.onto.Cv.Name(),
+			"anno_cvterm_graph": ar.anno.annotg.Name(),
+		},
+		"create_query": annInst,
+		"create_vars": map[string]interface{}{
+			"@anno_collection":    ar.anno.annot.Name(),
+			"@anno_cv_collection": ar.anno.term.Name(),
+			"version":             1,
+		},
+		"replace_query": annGroupMemberReplaceQ,
+		"replace_vars": map[string]interface{}{
+			"@anno_group_collection":         ar.anno.annog.Name(),
+			"@anno_group_version_collection": ar.anno.annogv.Name(),
+		},
+		"group_query":  annGroupInst,
+		"append_query": annGroupAppendQ,
+		"group_vars": map[string]interface{}{
+			"@anno_collection":               ar.anno.annot.Name(),
+			"@cv_collection":                 ar.onto.Cv.Name(),
+			"@anno_group_collection":         ar.anno.annog.Name(),
+			"@anno_group_type_collection":    ar.anno.annogt.Name(),
+			"@anno_group_version_collection": ar.anno.annogv.Name(),
+			"anno_cvterm_graph":              ar.anno.annotg.Name(),
+		},
+	}
+}
+
+// batchTransactionError converts the failure raised by a batch operation
+// to a BatchError.
+func batchTransactionError(err error) error {
+	aerr, ok := driver.AsArangoError(err)
+	if !ok || aerr.ErrorNum != batchErrorNum {
+		return fmt.Errorf("error in running batch transaction %s", err)
+	}
+	var idx int
+	if _, serr := fmt.Sscanf(aerr.ErrorMessage, "batch operation %d:", &idx); serr != nil {
+		return fmt.Errorf("error in running batch transaction %s", err)
+	}
+	_, reason, _ := strings.Cut(aerr.ErrorMessage, ": ")
+
+	return &repository.BatchError{Index: idx, Reason: reason}
+}
@@ -0,0 +1,124 @@
+package arangodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+)
+
+func newTestBatchCreate(ref, tag, entryID string) *model.BatchOperation {
+	return &model.BatchOperation{
+		Action: model.BatchCreate,
+		Ref:    ref,
+		Create: newTestTaggedAnnotationWithParams(tag, entryID).Data.Attributes,
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 2)
+	res, err := anrepo.ApplyBatch(context.Background(), []*model.BatchOperation{
+		newTestBatchCreate("a", "curation", "DDB_G0286429"),
+		newTestBatchCreate("b", "product", "DDB_G0286429"),
+		newTestBatchCreate("c", "note", "DDB_G0286429"),
+		{
+			Action: model.BatchUpdate,
+			Ref:    "b2",
+			Id:     "$b",
+			Update: &annotation.TaggedAnnotationUpdateAttributes{
+				Value:         "updated product",
+				EditableValue: "updated product",
+				CreatedBy:     "basu@gmail.com",
+			},
+		},
+		{
+			Action: model.BatchGroup,
+			Ref:    "g",
+			Ids:    []string{"$a", "$b2", "$c"},
+			Group:  &model.GroupParams{Name: "phenotype"},
+		},
+		{
+			Action: model.BatchDelete,
+			Id:     ids[0],
+			Delete: &model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "merged"},
+		},
+		{Action: model.BatchAppend, Id: "$g", Ids: []string{ids[1]}},
+	})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(res, 7, "should have a result for every operation")
+	for i, act := range []string{"create", "create", "create", "update", "group", "delete", "append"} {
+		assert.Equal(act, res[i].Action, "should match the action")
+		assert.NotEmpty(res[i].Id, "should have an identifier")
+	}
+	assert.Equal(ids[0], res[5].Id, "should match the deleted annotation")
+	assert.Equal(res[4].Id, res[6].Id, "should append to the group of the batch")
+	ua, err := anrepo.GetAnnotationByID(context.Background(), res[3].Id)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal("updated product", ua.Value, "should have the updated value")
+	assert.Equal(int64(2), ua.Version, "should have the second version")
+	assert.Equal("product", ua.Tag, "should keep the tag")
+	oa, err := anrepo.GetAnnotationByID(context.Background(), res[1].Id)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.True(oa.IsObsolete, "should obsolete the updated version")
+	da, err := anrepo.GetAnnotationByID(context.Background(), ids[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.True(da.IsObsolete, "should obsolete the deleted annotation")
+	assert.Equal("pfey@gmail.com", da.DeletedBy, "should record the curator of the deletion")
+	assert.Equal("merged", da.DeleteReason, "should record the reason of the deletion")
+	assert.NotNil(da.DeletedAt, "should record the time of deletion")
+	g, err := anrepo.GetAnnotationGroup(context.Background(), res[4].Id)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal("phenotype", g.Name, "should match the group name")
+	assert.Equal(
+		[]string{res[0].Id, res[3].Id, res[2].Id, ids[1]},
+		testModelMaptoID(g.AnnoDocs, model2IdCallback),
+		"should have the members in order",
+	)
+}
+
+func TestApplyBatchRollback(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 2)
+	err := anrepo.RemoveAnnotation(context.Background(), ids[1], false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.ApplyBatch(context.Background(), []*model.BatchOperation{
+		newTestBatchCreate("a", "curation", "DDB_G0294491"),
+		{
+			Action: model.BatchDelete,
+			Id:     ids[0],
+			Delete: &model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "merged"},
+		},
+		{Action: model.BatchGroup, Ids: []string{"$a", ids[1]}},
+	})
+	assert.True(repository.IsBatchError(err), "should fail with obsolete group member")
+	berr, ok := err.(*repository.BatchError)
+	assert.True(ok, "should be a batch error")
+	assert.Equal(2, berr.Index, "should fail at the third operation")
+	da, err := anrepo.GetAnnotationByID(context.Background(), ids[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.False(da.IsObsolete, "should roll back the delete")
+	_, err = anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
+		Tag:      "curation",
+		Ontology: "dicty_annotation",
+		EntryId:  "DDB_G0294491",
+	})
+	assert.True(repository.IsAnnotationNotFound(err), "should roll back the create")
+	_, err = anrepo.ApplyBatch(context.Background(), []*model.BatchOperation{
+		{Action: model.BatchDelete, Id: "$missing"},
+	})
+	assert.True(repository.IsBatchError(err), "should not allow unknown reference")
+	_, err = anrepo.ApplyBatch(context.Background(), []*model.BatchOperation{
+		newTestBatchCreate("a", "curation", "DDB_G0294491"),
+		newTestBatchCreate("b", "curation", "DDB_G0294491"),
+	})
+	assert.True(repository.IsBatchError(err), "should not create the same annotation twice")
+	_, err = anrepo.ApplyBatch(context.Background(), []*model.BatchOperation{{Action: "rename"}})
+	assert.True(repository.IsBatchError(err), "should not allow unknown action")
+}
@@ -0,0 +1,247 @@
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+)
+
+const (
+	// bulkChunkSize is the number of annotations read or obsoleted at once
+	bulkChunkSize = 500
+	// versionOverhead is the approximate size in bytes written for a new
+	// version besides its values and groups, it includes the edges
+	versionOverhead = 1000
+)
+
+// BulkEditAnnotations creates a new version of every live annotation that
+// matches the filter and is changed by the edit. The new versions are
+// created in chunks that fit in a transaction, every chunk is applied
+// atomically. When a chunk fails, the result still has the new versions of
+// the chunks applied before it. With dryRun, only the annotations that
+// would be changed are reported.
+func (ar *arangorepository) BulkEditAnnotations(
+	ctx context.Context,
+	filter string,
+	edit *model.BulkEdit,
+	dryRun bool,
+) (*model.BulkResult, error) {
+	res := &model.BulkResult{DryRun: dryRun}
+	var rgx *regexp.Regexp
+	if len(edit.Pattern) > 0 {
+		r, err := regexp.Compile(edit.Pattern)
+		if err != nil {
+			return res, fmt.Errorf("error in compiling pattern %s", err)
+		}
+		rgx = r
+	}
+	keys, err := ar.matchAnnotations(ctx, filter)
+	if err != nil {
+		return res, err
+	}
+	var edits []map[string]interface{}
+	for _, chunk := range chunkKeys(keys, bulkChunkSize) {
+		docs, err := ar.liveAnnotations(ctx, chunk)
+		if err != nil {
+			return res, err
+		}
+		for _, doc := range docs {
+			if upd := bulkEditDoc(doc, edit, rgx); upd != nil {
+				res.Ids = append(res.Ids, doc.Key)
+				edits = append(edits, upd)
+			}
+		}
+	}
+	res.Count = int64(len(res.Ids))
+	if dryRun {
+		return res, nil
+	}
+	members, err := ar.groupMembers(ctx, editKeys(edits))
+	if err != nil {
+		return res, err
+	}
+	for _, chunk := range chunkEdits(edits, members, maxTransactionSize/2) {
+		out, err := ar.editVersions(ctx, chunk)
+		if err != nil {
+			return res, err
+		}
+		for _, o := range out {
+			umd, err := model.ConvToModel(o)
+			if err != nil {
+				return res, fmt.Errorf("error in converting model struct %s", err)
+			}
+			res.NewIds = append(res.NewIds, umd.Key)
+		}
+	}
+
+	return res, nil
+}
+
+// BulkObsoleteAnnotations marks every live annotation that matches the
+// filter as obsolete in chunks, recording who removed it and why. When a
+// chunk fails, the result still has the annotations of the chunks applied
+// before it. With dryRun, only the matching annotations are reported.
+func (ar *arangorepository) BulkObsoleteAnnotations(
+	ctx context.Context,
+	filter string,
+	info *model.DeleteInfo,
+	dryRun bool,
+) (*model.BulkResult, error) {
+	res := &model.BulkResult{DryRun: dryRun}
+	keys, err := ar.matchAnnotations(ctx, filter)
+	if err != nil {
+		return res, err
+	}
+	if dryRun {
+		res.Ids = keys
+		res.Count = int64(len(keys))
+
+		return res, nil
+	}
+	for _, chunk := range chunkKeys(keys, bulkChunkSize) {
+		rs, err := ar.searchRows(
+			ctx,
+			"annBulkObsoleteQ", annBulkObsoleteQ,
+			map[string]interface{}{
+				"@anno_collection": ar.anno.annot.Name(),
+				"keys":             chunk,
+				"deleted_by":       info.DeletedBy,
+				"reason":           info.Reason,
+			})
+		if err != nil {
+			res.Count = int64(len(res.Ids))
+
+			return res, fmt.Errorf("error in obsoleting annotations %s", err)
+		}
+		for rs.Scan() {
+			var key string
+			if err := rs.Read(&key); err != nil {
+				res.Count = int64(len(res.Ids))
+
+				return res, fmt.Errorf("error in reading data %s", err)
+			}
+			res.Ids = append(res.Ids, key)
+		}
+	}
+	res.Count = int64(len(res.Ids))
+
+	return res, nil
+}
+
+// matchAnnotations returns the keys of all live annotations matching the
+// filter.
+func (ar *arangorepository) matchAnnotations(ctx context.Context, filter string) ([]string, error) {
+	var keys []string
+	rs, err := ar.searchRows(
+		ctx,
+		"annBulkMatchQ", fmt.Sprintf(annBulkMatchQ, filter),
+		map[string]interface{}{
+			"@cvt_collection":   ar.onto.Term.Name(),
+			"@cv_collection":    ar.onto.Cv.Name(),
+			"anno_cvterm_graph": ar.anno.annotg.Name(),
+		})
+	if err != nil {
+		return keys, fmt.Errorf("error in searching rows %s", err)
+	}
+	for rs.Scan() {
+		var key string
+		if err := rs.Read(&key); err != nil {
+			return keys, fmt.Errorf("error in reading data %s", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (ar *arangorepository) liveAnnotations(ctx context.Context, keys []string) ([]*model.AnnoDoc, error) {
+	var docs []*model.AnnoDoc
+	rs, err := ar.searchRows(
+		ctx,
+		"annBulkGetQ", annBulkGetQ,
+		map[string]interface{}{
+			"@anno_collection": ar.anno.annot.Name(),
+			"keys":             keys,
+		})
+	if err != nil {
+		return docs, fmt.Errorf("error in searching rows %s", err)
+	}
+	for rs.Scan() {
+		doc := &model.AnnoDoc{}
+		if err := rs.Read(doc); err != nil {
+			return docs, fmt.Errorf("error in reading data to structure %s", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// bulkEditDoc applies the edit to an annotation, it returns nil when the
+// annotation is left unchanged.
+func bulkEditDoc(
+	doc *model.AnnoDoc,
+	edit *model.BulkEdit,
+	rgx *regexp.Regexp,
+) map[string]interface{} {
+	value, editable, createdBy := doc.Value, doc.EditableValue, doc.CreatedBy
+	if rgx != nil {
+		value = rgx.ReplaceAllString(value, edit.Replacement)
+		editable = rgx.ReplaceAllString(editable, edit.Replacement)
+	}
+	if len(edit.CreatedBy) > 0 {
+		createdBy = edit.CreatedBy
+	}
+	if value == doc.Value && editable == doc.EditableValue && createdBy == doc.CreatedBy {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"key":            doc.Key,
+		"version":        doc.Version,
+		"value":          value,
+		"editable_value": editable,
+		"created_by":     createdBy,
+	}
+}
+
+func chunkKeys(keys []string, size int) [][]string {
+	var chunks [][]string
+	for size < len(keys) {
+		keys, chunks = keys[size:], append(chunks, keys[:size])
+	}
+	if len(keys) > 0 {
+		chunks = append(chunks, keys)
+	}
+
+	return chunks
+}
+
+// chunkEdits splits the edits so that the estimated size of every chunk,
+// including the groups it rewrites, stays within limit. A chunk has at
+// least one edit.
+func chunkEdits(
+	edits []map[string]interface{},
+	members map[string]int,
+	limit int,
+) [][]map[string]interface{} {
+	var chunks [][]map[string]interface{}
+	var chunk []map[string]interface{}
+	size := 0
+	for _, e := range edits {
+		esize := editSize(e, members)
+		if len(chunk) > 0 && size+esize > limit {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, e)
+		size += esize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
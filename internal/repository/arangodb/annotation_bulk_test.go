@@ -0,0 +1,156 @@
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+const bulkFilter = `FILTER ann.entry_id == 'DDB_G0286429'`
+
+func addTestAnnotationsForBulk(
+	t *testing.T,
+	anrepo repository.TaggedAnnotationRepository,
+	num int,
+) []string {
+	t.Helper()
+	var ids []string
+	for _, tag := range tags[:num] {
+		m, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams(tag, "DDB_G0286429"))
+		if err != nil {
+			t.Fatalf("expect no error, received %s", err)
+		}
+		ids = append(ids, m.Key)
+	}
+
+	return ids
+}
+
+func TestBulkEditAnnotations(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForBulk(t, anrepo, 12)
+	other := addTestAnnotationsForGroup(t, anrepo, 3)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], other[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	edit := &model.BulkEdit{
+		Pattern:     `develop(\w+)`,
+		Replacement: "grow${1}",
+		CreatedBy:   "pfey@gmail.com",
+	}
+	dry, err := anrepo.BulkEditAnnotations(context.Background(), bulkFilter, edit, true)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.True(dry.DryRun, "should be a dry run")
+	assert.Equal(int64(len(ids)), dry.Count, "should match all annotations of the entry")
+	assert.ElementsMatch(ids, dry.Ids, "should match the affected identifiers")
+	assert.Empty(dry.NewIds, "should not create any version")
+	ma, err := anrepo.GetAnnotationByID(context.Background(), ids[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.False(ma.IsObsolete, "should leave annotations unchanged with a dry run")
+	res, err := anrepo.BulkEditAnnotations(context.Background(), bulkFilter, edit, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(len(ids)), res.Count, "should change all annotations of the entry")
+	assert.Len(res.NewIds, len(ids), "should create a version for every annotation")
+	for _, id := range res.NewIds {
+		m, err := anrepo.GetAnnotationByID(context.Background(), id)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.Equal("growmentally regulated gene", m.Value, "should rewrite the value")
+		assert.Equal("pfey@gmail.com", m.CreatedBy, "should replace the curator")
+		assert.Equal(int64(2), m.Version, "should have the second version")
+	}
+	for _, id := range ids {
+		m, err := anrepo.GetAnnotationByID(context.Background(), id)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.True(m.IsObsolete, "should obsolete the previous version")
+	}
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Contains(
+		res.NewIds, eg.AnnoDocs[0].Key,
+		"should point the group at the new version",
+	)
+	none, err := anrepo.BulkEditAnnotations(context.Background(), bulkFilter, edit, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(0), none.Count, "should not change annotations already edited")
+	_, err = anrepo.BulkEditAnnotations(context.Background(), bulkFilter, &model.BulkEdit{Pattern: "(unclosed"}, true)
+	assert.Error(err, "should not allow invalid pattern")
+}
+
+func TestBulkEditAnnotationsInLargeGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForBulk(t, anrepo, 12)
+	other := addTestAnnotationsForGroup(t, anrepo, 300)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), append(other, ids...)...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	res, err := anrepo.BulkEditAnnotations(
+		context.Background(),
+		bulkFilter,
+		&model.BulkEdit{CreatedBy: "pfey@gmail.com"},
+		false,
+	)
+	assert.NoErrorf(err, "expect no error editing members of a large group, received %s", err)
+	assert.Len(res.NewIds, len(ids), "should create a version for every annotation")
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	members := testModelMaptoID(eg.AnnoDocs, model2IdCallback)
+	assert.Len(members, len(other)+len(ids), "should keep every member")
+	assert.Subset(members, res.NewIds, "should point the group at the new versions")
+}
+
+func TestBulkObsoleteAnnotations(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForBulk(t, anrepo, 8)
+	other := addTestAnnotationsForGroup(t, anrepo, 3)
+	info := &model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "retracted paper"}
+	dry, err := anrepo.BulkObsoleteAnnotations(context.Background(), bulkFilter, info, true)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(len(ids)), dry.Count, "should match all annotations of the entry")
+	assert.ElementsMatch(ids, dry.Ids, "should match the affected identifiers")
+	res, err := anrepo.BulkObsoleteAnnotations(context.Background(), bulkFilter, info, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(ids, res.Ids, "should obsolete all annotations of the entry")
+	for _, id := range ids {
+		m, err := anrepo.GetAnnotationByID(context.Background(), id)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.True(m.IsObsolete, "should be obsolete")
+		assert.Equal(info.DeletedBy, m.DeletedBy, "should record the curator")
+		assert.Equal(info.Reason, m.DeleteReason, "should record the reason")
+		assert.NotNil(m.DeletedAt, "should record the time of deletion")
+	}
+	for _, id := range other {
+		m, err := anrepo.GetAnnotationByID(context.Background(), id)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.False(m.IsObsolete, "should leave other annotations live")
+	}
+	none, err := anrepo.BulkObsoleteAnnotations(context.Background(), bulkFilter, info, true)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(0), none.Count, "should not match obsolete annotations")
+}
+
+func TestChunkEdits(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	var edits []map[string]interface{}
+	for i := 0; i < 20; i++ {
+		edits = append(edits, map[string]interface{}{
+			"key":            fmt.Sprintf("k%d", i),
+			"value":          "v",
+			"editable_value": "v",
+		})
+	}
+	chunks := chunkEdits(edits, map[string]int{}, 3*versionOverhead)
+	assert.Len(chunks, 10, "should fit two edits in every chunk")
+	chunks = chunkEdits(edits, map[string]int{"k4": 100}, 3*versionOverhead)
+	assert.Len(chunks, 11, "should count the members of the groups")
+	assert.Equal([]map[string]interface{}{edits[4]}, chunks[2], "should keep the edit of a large group alone")
+	assert.Len(chunkKeys(make([]string, 1001), bulkChunkSize), 3, "should split keys in three chunks")
+}
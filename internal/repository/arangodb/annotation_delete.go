@@ -5,47 +5,132 @@ import (
 	"errors"
 	"fmt"
 
-	driver "github.com/arangodb/go-driver"
-	"github.com/dictyBase/modware-annotation/internal/collection"
 	"github.com/dictyBase/modware-annotation/internal/model"
 	"github.com/dictyBase/modware-annotation/internal/repository"
 )
 
-func (ar *arangorepository) RemoveAnnotation(id string, purge bool) error {
-	manno := &model.AnnoDoc{}
-	_, err := ar.anno.annot.ReadDocument(context.Background(), id, manno)
-	if err != nil {
-		if driver.IsNotFoundGeneral(err) {
-			return &repository.AnnoNotFoundError{Id: id}
-		}
+func (ar *arangorepository) RemoveAnnotation(ctx context.Context, id string, purge bool) error {
+	_, err := ar.RemoveAnnotationBy(ctx, id, purge, &model.DeleteInfo{})
 
-		return fmt.Errorf("error in reading document %s", err)
+	return err
+}
+
+// RemoveAnnotationBy removes an annotation and returns it. A soft delete
+// records the curator, the time and the reason of the removal in the
+// obsolete annotation.
+func (ar *arangorepository) RemoveAnnotationBy(
+	ctx context.Context,
+	id string,
+	purge bool,
+	info *model.DeleteInfo,
+) (*model.AnnoDoc, error) {
+	manno, err := ar.GetAnnotationByID(ctx, id)
+	if err != nil {
+		return manno, err
 	}
 	if manno.IsObsolete {
-		return fmt.Errorf(
+		return manno, fmt.Errorf(
 			"annotation with id %s has already been obsolete",
 			manno.Key,
 		)
 	}
 	if purge {
-		if _, err := ar.anno.annot.RemoveDocument(context.Background(), manno.Key); err != nil {
-			return fmt.Errorf(
-				"unable to purge annotation with id %s %s",
-				manno.Key,
-				err,
-			)
-		}
+		return manno, ar.purgeAnnotation(ctx, manno)
+	}
+	res, err := ar.doRun(
+		ctx,
+		"annSoftDeleteQ", annSoftDeleteQ,
+		map[string]interface{}{
+			"@anno_collection": ar.anno.annot.Name(),
+			"key":              manno.Key,
+			"deleted_by":       info.DeletedBy,
+			"reason":           info.Reason,
+		})
+	if err != nil {
+		return manno, fmt.Errorf(
+			"unable to remove annotation with id %s %s",
+			manno.Key,
+			err,
+		)
+	}
+	if res.IsEmpty() {
+		return manno, fmt.Errorf(
+			"annotation with id %s has already been obsolete",
+			manno.Key,
+		)
+	}
+	dann := &model.AnnoDoc{}
+	if err := res.Read(dann); err != nil {
+		return manno, fmt.Errorf("error in reading data to structure %s", err)
+	}
+	manno.IsObsolete = true
+	manno.DeletedBy = dann.DeletedBy
+	manno.DeletedAt = dann.DeletedAt
+	manno.DeleteReason = dann.DeleteReason
 
-		return nil
+	return manno, nil
+}
+
+// RestoreAnnotation brings back an obsolete annotation that is the latest
+// of its versions, provided no other live annotation has taken its entry,
+// rank, tag and ontology.
+func (ar *arangorepository) RestoreAnnotation(
+	ctx context.Context,
+	id, restoredBy string,
+) (*model.AnnoDoc, error) {
+	rst := &restoreResult{}
+	res, err := ar.doRun(
+		ctx,
+		"annRestoreQ", annRestoreQ,
+		map[string]interface{}{
+			"@anno_collection":     ar.anno.annot.Name(),
+			"@anno_ver_collection": ar.anno.ver.Name(),
+			"@cv_collection":       ar.onto.Cv.Name(),
+			"anno_cvterm_graph":    ar.anno.annotg.Name(),
+			"key":                  id,
+			"restored_by":          restoredBy,
+		})
+	if err != nil {
+		return &model.AnnoDoc{}, fmt.Errorf("error in restoring annotation %s", err)
+	}
+	if err := res.Read(rst); err != nil {
+		return &model.AnnoDoc{}, fmt.Errorf("error in reading data into struct %s", err)
+	}
+	switch {
+	case !rst.Exists:
+		return &model.AnnoDoc{NotFound: true}, &repository.AnnoNotFoundError{Id: id}
+	case !rst.IsObsolete:
+		return &model.AnnoDoc{}, fmt.Errorf("annotation with id %s is not obsolete", id)
+	case len(rst.Successor) > 0:
+		return &model.AnnoDoc{}, &repository.AnnoConflictError{
+			Id:     id,
+			Reason: fmt.Sprintf("it has a newer version %s", rst.Successor),
+		}
+	case len(rst.Live) > 0:
+		return &model.AnnoDoc{}, &repository.AnnoSlotTakenError{Id: id, LiveId: rst.Live}
 	}
-	_, err = ar.anno.annot.UpdateDocument(
-		context.Background(),
-		manno.Key,
-		map[string]interface{}{"is_obsolete": true},
-	)
+
+	return rst.Annotation, nil
+}
+
+// purgeAnnotation removes the annotation along with its group memberships,
+// tag and version edges.
+func (ar *arangorepository) purgeAnnotation(ctx context.Context, manno *model.AnnoDoc) error {
+	err := ar.do(
+		ctx,
+		"annPurgeQ", annPurgeQ,
+		map[string]interface{}{
+			"@anno_collection":               ar.anno.annot.Name(),
+			"@anno_group_collection":         ar.anno.annog.Name(),
+			"@anno_group_version_collection": ar.anno.annogv.Name(),
+			"@anno_cv_collection":            ar.anno.term.Name(),
+			"@anno_ver_collection":           ar.anno.ver.Name(),
+			"key":                            manno.Key,
+			"id":                             manno.ID.String(),
+		})
 	if err != nil {
 		return fmt.Errorf(
-			"unable to remove annotation with id %s %s",
+			"unable to purge annotation with id %s %s",
 			manno.Key,
 			err,
 		)
@@ -54,66 +139,48 @@ func (ar *arangorepository) RemoveAnnotation(id string, purge bool) error {
 	return nil
 }
 
+// DanglingReferences reports group members, tag edges and version edges
+// that refer to annotations that no longer exist. With repair, the
+// dangling members are removed from their groups and the edges are
+// deleted.
+func (ar *arangorepository) DanglingReferences(
+	ctx context.Context,
+	repair bool,
+) (*model.DanglingReport, error) {
+	rpt := &model.DanglingReport{}
+	name, query := "annDanglingQ", annDanglingQ
+	bindVars := map[string]interface{}{
+		"@anno_collection":       ar.anno.annot.Name(),
+		"@anno_group_collection": ar.anno.annog.Name(),
+		"@anno_cv_collection":    ar.anno.term.Name(),
+		"@anno_ver_collection":   ar.anno.ver.Name(),
+	}
+	if repair {
+		name, query = "annDanglingRepairQ", annDanglingRepairQ
+		bindVars["@anno_group_version_collection"] = ar.anno.annogv.Name()
+	}
+	res, err := ar.doRun(ctx, name, query, bindVars)
+	if err != nil {
+		return rpt, fmt.Errorf("error in checking dangling references %s", err)
+	}
+	if err := res.Read(rpt); err != nil {
+		return rpt, fmt.Errorf("error in reading data into struct %s", err)
+	}
+
+	return rpt, nil
+}
+
 // RemoveFromAnnotationGroup remove annotations from an existing group.
 func (ar *arangorepository) RemoveFromAnnotationGroup(
+	ctx context.Context,
 	groupID string,
 	idslice ...string,
 ) (*model.AnnoGroup, error) {
-	manno := &model.AnnoGroup{}
 	if len(idslice) <= 1 {
-		return manno, errors.New(
+		return &model.AnnoGroup{}, errors.New(
 			"need at least more than one entry to form a group",
 		)
 	}
-	// check if the group exists
-	isok, err := ar.anno.annog.DocumentExists(
-		context.Background(), groupID,
-	)
-	if err != nil {
-		return manno, fmt.Errorf(
-			"error in checking for existence of group identifier %s %s",
-			groupID, err,
-		)
-	}
-	if !isok {
-		return manno, &repository.GroupNotFoundError{Id: groupID}
-	}
-	// retrieve all annotations ids for the group
-	dbg := &model.DbGroup{}
-	_, err = ar.anno.annog.ReadDocument(
-		context.Background(),
-		groupID, dbg,
-	)
-	if err != nil {
-		return manno, fmt.Errorf("error in retrieving the group %s", err)
-	}
-	nids := collection.RemoveStringItems(dbg.Group, idslice...)
-	mla, err := ar.getAllAnnotations(nids...)
-	if err != nil {
-		return manno, err
-	}
-	// update the new group
-	res, err := ar.database.DoRun(
-		annGroupUpd,
-		map[string]interface{}{
-			"@anno_group_collection": ar.anno.annog.Name(),
-			"key":                    groupID,
-			"group":                  nids,
-		})
-	if err != nil {
-		return manno, fmt.Errorf(
-			"error in removing group members with id %s %s",
-			groupID, err,
-		)
-	}
-	ndbg := &model.DbGroup{}
-	if err := res.Read(ndbg); err != nil {
-		return manno, fmt.Errorf("error in reading data into struct %s", err)
-	}
-	manno.CreatedAt = ndbg.CreatedAt
-	manno.UpdatedAt = ndbg.UpdatedAt
-	manno.GroupId = ndbg.GroupId
-	manno.AnnoDocs = mla
 
-	return manno, nil
+	return ar.writeGroup(ctx, "annGroupRemoveQ", annGroupRemoveQ, groupID, idslice, nil)
 }
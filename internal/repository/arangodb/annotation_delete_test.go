@@ -1,9 +1,11 @@
 package arangodb
 
 import (
+	"context"
 	"testing"
 
 	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
 )
 
 func TestRemoveFromAnnotationGroup(t *testing.T) {
@@ -13,14 +15,14 @@ func TestRemoveFromAnnotationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(9)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	ids := testModelMaptoID(mla, model2IdCallback)
-	g, err := anrepo.AddAnnotationGroup(ids...)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	ega, err := anrepo.RemoveFromAnnotationGroup(g.GroupId, ids[:5]...)
+	ega, err := anrepo.RemoveFromAnnotationGroup(context.Background(), g.GroupId, ids[:5]...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.ElementsMatch(
 		testModelMaptoID(g.AnnoDocs, model2IdCallback),
@@ -41,16 +43,16 @@ func TestRemoveAnnotationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(7)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	ids := testModelMaptoID(mla, model2IdCallback)
-	g, err := anrepo.AddAnnotationGroup(ids...)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	err = anrepo.RemoveAnnotationGroup(g.GroupId)
+	err = anrepo.RemoveAnnotationGroup(context.Background(), g.GroupId)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	err = anrepo.RemoveAnnotationGroup(g.GroupId)
+	err = anrepo.RemoveAnnotationGroup(context.Background(), g.GroupId)
 	assert.Errorf(err, "should return error")
 	assert.Contains(
 		err.Error(),
@@ -64,16 +66,129 @@ func TestRemoveAnnotation(t *testing.T) {
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	nta := newTestTaggedAnnotation()
-	m, err := anrepo.AddAnnotation(nta)
+	m, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	nta2 := newTestTaggedAnnotationWithParams("curation", "DDB_G0287317")
-	mt2, err := anrepo.AddAnnotation(nta2)
+	mt2, err := anrepo.AddAnnotation(context.Background(), nta2)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	err = anrepo.RemoveAnnotation(m.Key, true)
+	err = anrepo.RemoveAnnotation(context.Background(), m.Key, true)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	err = anrepo.RemoveAnnotation(mt2.Key, false)
+	err = anrepo.RemoveAnnotation(context.Background(), mt2.Key, false)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	err = anrepo.RemoveAnnotation(mt2.Key, false)
+	err = anrepo.RemoveAnnotation(context.Background(), mt2.Key, false)
 	assert.Errorf(err, "should return error")
 	assert.Contains(err.Error(), "obsolete", "should contain obsolete message")
 }
+
+func TestPurgeAnnotationCascade(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 4)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = anrepo.RemoveAnnotation(context.Background(), ids[0], true)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		ids[1:],
+		"should remove purged annotation from the group",
+	)
+	rpt, err := anrepo.DanglingReferences(context.Background(), false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(rpt.Groups, "should not have dangling group members")
+	assert.Empty(rpt.TagEdges, "should not have dangling tag edges")
+	assert.Empty(rpt.VersionEdges, "should not have dangling version edges")
+}
+
+func TestDanglingReferences(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 4)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	// remove the document bypassing the repository to leave dangling references
+	annc, err := anrepo.Dbh().Collection("annotation")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = annc.RemoveDocument(context.Background(), ids[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	rpt, err := anrepo.DanglingReferences(context.Background(), false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(rpt.Groups, 1, "should have one group with dangling member")
+	assert.Equal(rpt.Groups[0].GroupId, g.GroupId, "should match the group")
+	assert.Equal(rpt.Groups[0].Members, ids[:1], "should match the dangling member")
+	assert.Len(rpt.TagEdges, 1, "should have one dangling tag edge")
+	rpt2, err := anrepo.DanglingReferences(context.Background(), true)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(rpt, rpt2, "should repair the reported references")
+	rpt3, err := anrepo.DanglingReferences(context.Background(), false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(rpt3.Groups, "should not have dangling group members after repair")
+	assert.Empty(rpt3.TagEdges, "should not have dangling tag edges after repair")
+}
+
+func TestRestoreAnnotation(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("curation", "DDB_G0286429"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.RestoreAnnotation(context.Background(), mda.Key, "pfey@gmail.com")
+	assert.Error(err, "should not restore a live annotation")
+	err = anrepo.RemoveAnnotation(context.Background(), mda.Key, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	rda, err := anrepo.RestoreAnnotation(context.Background(), mda.Key, "pfey@gmail.com")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.False(rda.IsObsolete, "should not be obsolete")
+	assert.Equal("pfey@gmail.com", rda.RestoredBy, "should record the curator")
+	assert.NotNil(rda.RestoredAt, "should record the time of restore")
+	assert.Equal("curation", rda.Tag, "should match the tag")
+	assert.Equal("dicty_annotation", rda.Ontology, "should match the ontology")
+	err = anrepo.RemoveAnnotation(context.Background(), mda.Key, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	nda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("curation", "DDB_G0286429"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.RestoreAnnotation(context.Background(), mda.Key, "pfey@gmail.com")
+	assert.True(repository.IsAnnotationSlotTaken(err), "should not restore into a taken slot")
+	um, err := anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(nda.Key, "edited"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = anrepo.RemoveAnnotation(context.Background(), um.Key, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.RestoreAnnotation(context.Background(), nda.Key, "pfey@gmail.com")
+	assert.True(repository.IsAnnotationConflict(err), "should not restore a superseded version")
+	_, err = anrepo.RestoreAnnotation(context.Background(), "9999999", "pfey@gmail.com")
+	assert.True(repository.IsAnnotationNotFound(err), "should not restore nonexistent annotation")
+}
+
+func TestRemoveAnnotationBy(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("curation", "DDB_G0286429"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	info := &model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "duplicate curation"}
+	dda, err := anrepo.RemoveAnnotationBy(context.Background(), mda.Key, false, info)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.True(dda.IsObsolete, "should be obsolete")
+	assert.Equal(info.DeletedBy, dda.DeletedBy, "should record the curator")
+	assert.Equal(info.Reason, dda.DeleteReason, "should record the reason")
+	assert.NotNil(dda.DeletedAt, "should record the time of deletion")
+	assert.Equal("curation", dda.Tag, "should match the tag")
+	oml, err := anrepo.ListObsoleteAnnotations(context.Background(), 0, 10, "")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(oml, 1, "should list the obsolete annotation")
+	assert.Equal(mda.Key, oml[0].Key, "should match the obsolete annotation")
+	assert.Equal(info.DeletedBy, oml[0].DeletedBy, "should list the curator")
+	assert.Equal(info.Reason, oml[0].DeleteReason, "should list the reason")
+	assert.NotNil(oml[0].DeletedAt, "should list the time of deletion")
+	_, err = anrepo.ListAnnotations(context.Background(), 0, 10, "")
+	assert.True(repository.IsAnnotationListNotFound(err), "should not list obsolete annotation as live")
+	rda, err := anrepo.RestoreAnnotation(context.Background(), mda.Key, "pfey@gmail.com")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(rda.DeletedBy, "should clear the curator after restore")
+	assert.Nil(rda.DeletedAt, "should clear the time of deletion after restore")
+	assert.Empty(rda.DeleteReason, "should clear the reason after restore")
+}
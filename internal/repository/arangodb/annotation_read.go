@@ -11,18 +11,11 @@ import (
 )
 
 func (ar *arangorepository) GetAnnotationByID(
+	ctx context.Context,
 	annoid string,
 ) (*model.AnnoDoc, error) {
 	model := &model.AnnoDoc{}
-	res, err := ar.database.Get(
-		fmt.Sprintf(
-			annGetQ,
-			ar.anno.annot.Name(),
-			ar.anno.annotg.Name(),
-			ar.onto.Cv.Name(),
-			annoid,
-		),
-	)
+	res, err := ar.getRow(ctx, "annGetQ", annGetQ, ar.annoGetBindVars(annoid))
 	if err != nil {
 		return model, fmt.Errorf("error in fetching id %s", err)
 	}
@@ -39,22 +32,23 @@ func (ar *arangorepository) GetAnnotationByID(
 }
 
 func (ar *arangorepository) GetAnnotationByEntry(
+	ctx context.Context,
 	req *annotation.EntryAnnotationRequest,
 ) (*model.AnnoDoc, error) {
 	mann := &model.AnnoDoc{}
-	res, err := ar.database.Get(
-		fmt.Sprintf(
-			annGetByEntryQ,
-			ar.anno.annot.Name(),
-			ar.anno.annotg.Name(),
-			ar.onto.Cv.Name(),
-			req.EntryId,
-			req.Rank,
-			req.IsObsolete,
-			req.Tag,
-			req.Ontology,
-		),
-	)
+	res, err := ar.getRow(
+		ctx,
+		"annGetByEntryQ", annGetByEntryQ,
+		map[string]interface{}{
+			"@anno_collection":  ar.anno.annot.Name(),
+			"@cv_collection":    ar.onto.Cv.Name(),
+			"anno_cvterm_graph": ar.anno.annotg.Name(),
+			"entry_id":          req.EntryId,
+			"rank":              req.Rank,
+			"is_obsolete":       req.IsObsolete,
+			"tag":               req.Tag,
+			"ontology":          req.Ontology,
+		})
 	if err != nil {
 		return mann, fmt.Errorf("error in fetching id %s", err)
 	}
@@ -70,10 +64,69 @@ func (ar *arangorepository) GetAnnotationByEntry(
 	return mann, nil
 }
 
+// GetEntryProfile retrieves all live annotations of an entry grouped by
+// ontology and tag, ordered by rank within every group.
+func (ar *arangorepository) GetEntryProfile(
+	ctx context.Context,
+	entryID string,
+) (*model.EntryProfile, error) {
+	prof := &model.EntryProfile{EntryId: entryID}
+	res, err := ar.searchRows(
+		ctx,
+		"annEntryProfileQ", annEntryProfileQ,
+		map[string]interface{}{
+			"@anno_collection":       ar.anno.annot.Name(),
+			"@cv_collection":         ar.onto.Cv.Name(),
+			"@anno_group_collection": ar.anno.annog.Name(),
+			"anno_cvterm_graph":      ar.anno.annotg.Name(),
+			"entry_id":               entryID,
+		})
+	if err != nil {
+		return prof, fmt.Errorf("error in searching rows %s", err)
+	}
+	if res.IsEmpty() {
+		return prof, &repository.AnnoNotFoundError{Id: entryID}
+	}
+	for res.Scan() {
+		tgm := &model.TagAnnotations{}
+		if err := res.Read(tgm); err != nil {
+			return prof, fmt.Errorf(
+				"error in reading data to structure %s",
+				err,
+			)
+		}
+		prof.Tags = append(prof.Tags, tgm)
+	}
+
+	return prof, nil
+}
+
 func (ar *arangorepository) ListAnnotations(
+	ctx context.Context,
 	cursor int64,
 	limit int64,
 	filter string,
+) ([]*model.AnnoDoc, error) {
+	return ar.listAnnotations(ctx, cursor, limit, filter, false)
+}
+
+// ListObsoleteAnnotations lists the obsolete annotations along with who
+// removed them and why.
+func (ar *arangorepository) ListObsoleteAnnotations(
+	ctx context.Context,
+	cursor int64,
+	limit int64,
+	filter string,
+) ([]*model.AnnoDoc, error) {
+	return ar.listAnnotations(ctx, cursor, limit, filter, true)
+}
+
+func (ar *arangorepository) listAnnotations(
+	ctx context.Context,
+	cursor int64,
+	limit int64,
+	filter string,
+	obsolete bool,
 ) ([]*model.AnnoDoc, error) {
 	annoModel := make([]*model.AnnoDoc, 0)
 	bindVars := map[string]interface{}{
@@ -81,12 +134,13 @@ func (ar *arangorepository) ListAnnotations(
 		"@cv_collection":    ar.onto.Cv.Name(),
 		"anno_cvterm_graph": ar.anno.annotg.Name(),
 		"limit":             limit + 1,
+		"is_obsolete":       obsolete,
 	}
 	if cursor != 0 {
 		bindVars["cursor"] = cursor
 	}
 	stmt := getListAnnoStatement(filter, cursor)
-	res, err := ar.database.SearchRows(stmt, bindVars)
+	res, err := ar.searchRows(ctx, "annListQ", stmt, bindVars)
 	if err != nil {
 		return annoModel, fmt.Errorf("error in searching rows %s", err)
 	}
@@ -109,27 +163,29 @@ func (ar *arangorepository) ListAnnotations(
 
 // Retrieves an annotation group.
 func (ar *arangorepository) GetAnnotationGroup(
+	ctx context.Context,
 	groupID string,
 ) (*model.AnnoGroup, error) {
 	grp := &model.AnnoGroup{}
-	ann, err := ar.groupID2Annotations(groupID)
-	if err != nil {
-		return grp, err
-	}
-	// retrieve group information
-	dbg := &model.DbGroup{}
-	_, err = ar.anno.annog.ReadDocument(
-		context.Background(),
-		groupID,
-		dbg,
-	)
+	res, err := ar.getRow(
+		ctx,
+		"annGroupGetQ", annGroupGetQ,
+		map[string]interface{}{
+			"@anno_collection":       ar.anno.annot.Name(),
+			"@cv_collection":         ar.onto.Cv.Name(),
+			"@anno_group_collection": ar.anno.annog.Name(),
+			"anno_cvterm_graph":      ar.anno.annotg.Name(),
+			"key":                    groupID,
+		})
 	if err != nil {
 		return grp, fmt.Errorf("error in retrieving the group %s", err)
 	}
-	grp.CreatedAt = dbg.CreatedAt
-	grp.UpdatedAt = dbg.UpdatedAt
-	grp.GroupId = dbg.GroupId
-	grp.AnnoDocs = ann
+	if res.IsEmpty() {
+		return grp, &repository.GroupNotFoundError{Id: groupID}
+	}
+	if err := res.Read(grp); err != nil {
+		return grp, fmt.Errorf("error in reading data to structure %s", err)
+	}
 
 	return grp, nil
 }
@@ -137,44 +193,83 @@ func (ar *arangorepository) GetAnnotationGroup(
 // ListAnnotationGroup provides a paginated list of annotation groups along
 // with optional filtering.
 func (ar *arangorepository) ListAnnotationGroup(
+	ctx context.Context,
 	cursor, limit int64,
 	filter string,
+) ([]*model.AnnoGroup, error) {
+	return ar.ListAnnotationGroupByType(ctx, cursor, limit, filter, "", "")
+}
+
+// ListAnnotationGroupByType provides a paginated list of annotation groups
+// of a group type along with optional filtering. Groups of every type are
+// included when the ontology or tag of the group type is empty.
+func (ar *arangorepository) ListAnnotationGroupByType(
+	ctx context.Context,
+	cursor, limit int64,
+	filter, ontology, tag string,
+) ([]*model.AnnoGroup, error) {
+	params := map[string]interface{}{
+		"group_ontology": nullString(ontology),
+		"group_type":     nullString(tag),
+		"limit":          limit,
+	}
+	if cursor != 0 {
+		params["cursor"] = cursor
+	}
+
+	return ar.searchGroups(
+		ctx,
+		"annGroupListQ", getListGroupStatement(filter, cursor), params,
+	)
+}
+
+// ListGroupsByAnnotation retrieves all groups containing an annotation,
+// newest first.
+func (ar *arangorepository) ListGroupsByAnnotation(
+	ctx context.Context,
+	annoID string,
+) ([]*model.AnnoGroup, error) {
+	return ar.searchGroups(ctx, "annGroupByAnnoQ", annGroupByAnnoQ, map[string]interface{}{"key": annoID})
+}
+
+// ListGroupsByAnnotations retrieves all groups containing any of the
+// annotations in one query, newest first.
+func (ar *arangorepository) ListGroupsByAnnotations(
+	ctx context.Context,
+	annoIDs []string,
+) ([]*model.AnnoGroup, error) {
+	return ar.searchGroups(ctx, "annGroupByAnnosQ", annGroupByAnnosQ, map[string]interface{}{"keys": annoIDs})
+}
+
+// ListGroupsByEntry retrieves all groups containing any annotation of an
+// entry, newest first.
+func (ar *arangorepository) ListGroupsByEntry(
+	ctx context.Context,
+	entryID string,
+) ([]*model.AnnoGroup, error) {
+	return ar.searchGroups(
+		ctx,
+		"annGroupByEntryQ", annGroupByEntryQ,
+		map[string]interface{}{"entry_id": entryID},
+	)
+}
+
+func (ar *arangorepository) searchGroups(
+	ctx context.Context,
+	name, query string,
+	params map[string]interface{},
 ) ([]*model.AnnoGroup, error) {
 	var agrp []*model.AnnoGroup
-	var stmt string
-	if len(filter) > 0 { // filter
-		// no cursor
-		stmt = fmt.Sprintf(annGroupListFilterQ,
-			ar.anno.annot.Name(), ar.anno.annotg.Name(), ar.onto.Cv.Name(),
-			filter, ar.anno.annog.Name(), ar.anno.annot.Name(),
-			ar.anno.annotg.Name(), ar.onto.Cv.Name(),
-			limit,
-		)
-		if cursor != 0 { // with cursor
-			stmt = fmt.Sprintf(annGroupListFilterWithCursorQ,
-				ar.anno.annot.Name(), ar.anno.annotg.Name(),
-				ar.onto.Cv.Name(), filter,
-				ar.anno.annog.Name(), ar.anno.annot.Name(),
-				ar.anno.annotg.Name(), ar.onto.Cv.Name(),
-				cursor, limit,
-			)
-		}
-	} else { // no filter
-		// no cursor
-		stmt = fmt.Sprintf(annGroupListQ,
-			ar.anno.annog.Name(), ar.anno.annot.Name(),
-			ar.anno.annotg.Name(), ar.onto.Cv.Name(),
-			limit,
-		)
-		if cursor != 0 { // with cursor
-			stmt = fmt.Sprintf(annGroupListWithCursorQ,
-				ar.anno.annog.Name(), ar.anno.annot.Name(),
-				ar.anno.annotg.Name(), ar.onto.Cv.Name(),
-				cursor, limit,
-			)
-		}
+	bindVars := map[string]interface{}{
+		"@anno_collection":       ar.anno.annot.Name(),
+		"@cv_collection":         ar.onto.Cv.Name(),
+		"@anno_group_collection": ar.anno.annog.Name(),
+		"anno_cvterm_graph":      ar.anno.annotg.Name(),
+	}
+	for k, v := range params {
+		bindVars[k] = v
 	}
-	res, err := ar.database.Search(stmt)
+	res, err := ar.searchRows(ctx, name, query, bindVars)
 	if err != nil {
 		return agrp, fmt.Errorf("error in searching rows %s", err)
 	}
@@ -195,13 +290,135 @@ func (ar *arangorepository) ListAnnotationGroup(
 	return agrp, nil
 }
 
+// ListAnnotationGroupVersions retrieves the membership history of a group,
+// oldest version first.
+func (ar *arangorepository) ListAnnotationGroupVersions(
+	ctx context.Context,
+	groupID string,
+) ([]*model.GroupVersion, error) {
+	var gvl []*model.GroupVersion
+	res, err := ar.searchRows(
+		ctx,
+		"annGroupVersionListQ", annGroupVersionListQ,
+		map[string]interface{}{
+			"@anno_group_version_collection": ar.anno.annogv.Name(),
+			"key":                            groupID,
+		})
+	if err != nil {
+		return gvl, fmt.Errorf("error in searching rows %s", err)
+	}
+	if res.IsEmpty() {
+		return gvl, &repository.GroupNotFoundError{Id: groupID}
+	}
+	for res.Scan() {
+		gvr := &model.GroupVersion{}
+		if err := res.Read(gvr); err != nil {
+			return gvl, fmt.Errorf(
+				"error in reading data to structure %s",
+				err,
+			)
+		}
+		gvl = append(gvl, gvr)
+	}
+
+	return gvl, nil
+}
+
+// GetAnnotationGroupVersion retrieves the membership of a group at a
+// version.
+func (ar *arangorepository) GetAnnotationGroupVersion(
+	ctx context.Context,
+	groupID string,
+	version int64,
+) (*model.GroupVersion, error) {
+	gvr := &model.GroupVersion{}
+	res, err := ar.getRow(
+		ctx,
+		"annGroupVersionGetQ", annGroupVersionGetQ,
+		map[string]interface{}{
+			"@anno_group_version_collection": ar.anno.annogv.Name(),
+			"key":                            groupID,
+			"version":                        version,
+		})
+	if err != nil {
+		return gvr, fmt.Errorf("error in retrieving the group version %s", err)
+	}
+	if res.IsEmpty() {
+		return gvr, &repository.GroupNotFoundError{Id: groupID}
+	}
+	if err := res.Read(gvr); err != nil {
+		return gvr, fmt.Errorf("error in reading data to structure %s", err)
+	}
+
+	return gvr, nil
+}
+
+// GetGroupType retrieves a group type along with its rules.
+func (ar *arangorepository) GetGroupType(
+	ctx context.Context,
+	ontology, tag string,
+) (*model.GroupType, error) {
+	mgt := &model.GroupType{}
+	res, err := ar.getRow(
+		ctx,
+		"annGroupTypeGetQ", annGroupTypeGetQ,
+		map[string]interface{}{
+			"@anno_group_type_collection": ar.anno.annogt.Name(),
+			"ontology":                    ontology,
+			"tag":                         tag,
+		})
+	if err != nil {
+		return mgt, fmt.Errorf("error in running group type query %s", err)
+	}
+	if res.IsEmpty() {
+		return mgt, &repository.GroupTypeNotFoundError{Ontology: ontology, Tag: tag}
+	}
+	if err := res.Read(mgt); err != nil {
+		return mgt, fmt.Errorf("error in reading data to structure %s", err)
+	}
+
+	return mgt, nil
+}
+
+// CountByOntology counts the live annotations of every ontology and the
+// groups having at least one member in it.
+func (ar *arangorepository) CountByOntology(ctx context.Context) ([]*model.OntologyCount, error) {
+	counts := make([]*model.OntologyCount, 0)
+	rs, err := ar.searchRows(
+		ctx,
+		"annCountByOntologyQ", annCountByOntologyQ,
+		map[string]interface{}{
+			"@anno_collection":       ar.anno.annot.Name(),
+			"@anno_group_collection": ar.anno.annog.Name(),
+			"@cv_collection":         ar.onto.Cv.Name(),
+			"anno_cvterm_graph":      ar.anno.annotg.Name(),
+		})
+	if err != nil {
+		return counts, fmt.Errorf("error in counting annotations %s", err)
+	}
+	if rs.IsEmpty() {
+		return counts, nil
+	}
+	for rs.Scan() {
+		cnt := &model.OntologyCount{}
+		if err := rs.Read(cnt); err != nil {
+			return counts, fmt.Errorf("error in reading data to structure %s", err)
+		}
+		counts = append(counts, cnt)
+	}
+
+	return counts, nil
+}
+
 // GetAnnotationTag retrieves tag information.
 func (ar *arangorepository) GetAnnotationTag(
+	ctx context.Context,
 	tag, ontology string,
 ) (*model.AnnoTag, error) {
 	annoModel := new(model.AnnoTag)
-	res, err := ar.database.GetRow(
-		tagGetQ,
+	res, err := ar.getRow(
+		ctx,
+		"tagGetQ", tagGetQ,
 		map[string]interface{}{
 			"@cvterm_collection": ar.onto.Term.Name(),
 			"@cv_collection":     ar.onto.Cv.Name(),
@@ -225,11 +442,73 @@ func (ar *arangorepository) GetAnnotationTag(
 	return annoModel, nil
 }
 
+// GetAnnotationTags retrieves the information of many tags in one query.
+func (ar *arangorepository) GetAnnotationTags(
+	ctx context.Context,
+	keys []*model.TagKey,
+) ([]*model.AnnoTag, error) {
+	tags := make([]*model.AnnoTag, 0)
+	res, err := ar.searchRows(
+		ctx,
+		"tagBatchGetQ", tagBatchGetQ,
+		map[string]interface{}{
+			"@cvterm_collection": ar.onto.Term.Name(),
+			"@cv_collection":     ar.onto.Cv.Name(),
+			"keys":               keys,
+		})
+	if err != nil {
+		return tags, fmt.Errorf("error in running tag query %s", err)
+	}
+	if res.IsEmpty() {
+		return tags, nil
+	}
+	for res.Scan() {
+		tag := &model.AnnoTag{}
+		if err := res.Read(tag); err != nil {
+			return tags, fmt.Errorf("error in reading tag %s", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// GetOntologies retrieves the ontologies of the given namespaces.
+func (ar *arangorepository) GetOntologies(
+	ctx context.Context,
+	namespaces []string,
+) ([]*model.Ontology, error) {
+	ontos := make([]*model.Ontology, 0)
+	res, err := ar.searchRows(
+		ctx,
+		"ontologyGetQ", ontologyGetQ,
+		map[string]interface{}{
+			"@cv_collection": ar.onto.Cv.Name(),
+			"namespaces":     namespaces,
+		})
+	if err != nil {
+		return ontos, fmt.Errorf("error in running ontology query %s", err)
+	}
+	if res.IsEmpty() {
+		return ontos, nil
+	}
+	for res.Scan() {
+		onto := &model.Ontology{}
+		if err := res.Read(onto); err != nil {
+			return ontos, fmt.Errorf("error in reading ontology %s", err)
+		}
+		ontos = append(ontos, onto)
+	}
+
+	return ontos, nil
+}
+
 func (ar *arangorepository) existAnno(
+	ctx context.Context,
 	attr *annotation.NewTaggedAnnotationAttributes,
 	tag string,
 ) error {
-	count, err := ar.database.CountWithParams(annExistQ, map[string]interface{}{
+	count, err := ar.countWithParams(ctx, "annExistQ", annExistQ, map[string]interface{}{
 		"@anno_collection":  ar.anno.annot.Name(),
 		"@cv_collection":    ar.onto.Cv.Name(),
 		"anno_cvterm_graph": ar.anno.annotg.Name(),
@@ -248,63 +527,6 @@ func (ar *arangorepository) existAnno(
 	return nil
 }
 
-func (ar *arangorepository) groupID2Annotations(
-	groupID string,
-) ([]*model.AnnoDoc, error) {
-	var annoModel []*model.AnnoDoc
-	// check if the group exists
-	isOk, err := ar.anno.annog.DocumentExists(context.Background(), groupID)
-	if err != nil {
-		return annoModel,
-			fmt.Errorf(
-				"error in checking for existence of group identifier %s %s",
-				groupID,
-				err,
-			)
-	}
-	if !isOk {
-		return annoModel, &repository.GroupNotFoundError{Id: groupID}
-	}
-	// retrieve group object
-	dbg := &model.DbGroup{}
-	_, err = ar.anno.annog.ReadDocument(
-		context.Background(),
-		groupID, dbg,
-	)
-	if err != nil {
-		return annoModel, fmt.Errorf("error in retrieving the group %s", err)
-	}
-	// retrieve the model objects for the existing annotations
-	return ar.getAllAnnotations(dbg.Group...)
-}
-
-func (ar *arangorepository) getAllAnnotations(
-	ids ...string,
-) ([]*model.AnnoDoc, error) {
-	annoModel := make([]*model.AnnoDoc, 0)
-	for _, k := range ids {
-		res, err := ar.database.Get(
-			fmt.Sprintf(
-				annGetQ, ar.anno.annot.Name(),
-				ar.anno.annotg.Name(), ar.onto.Cv.Name(), k,
-			),
-		)
-		if err != nil {
-			return annoModel, fmt.Errorf("error in fetching id %s", err)
-		}
-		amodel := &model.AnnoDoc{}
-		if err := res.Read(amodel); err != nil {
-			return annoModel, fmt.Errorf(
-				"error in reading data to structure %s",
-				err,
-			)
-		}
-		annoModel = append(annoModel, amodel)
-	}
-
-	return annoModel, nil
-}
-
 func getListAnnoStatement(filter string, cursor int64) string {
 	var stmt string
 	switch {
@@ -320,3 +542,32 @@ func getListAnnoStatement(filter string, cursor int64) string {
 
 	return stmt
 }
+
+// getListGroupStatement picks the group listing query. The filter is an AQL
+// fragment generated from a parsed filter string, its values are restricted
+// by the parser to word characters and are never taken verbatim from the
+// request.
+func getListGroupStatement(filter string, cursor int64) string {
+	var stmt string
+	switch {
+	case len(filter) > 0 && cursor == 0:
+		stmt = fmt.Sprintf(annGroupListFilterQ, filter)
+	case len(filter) > 0 && cursor != 0:
+		stmt = fmt.Sprintf(annGroupListFilterWithCursorQ, filter)
+	case len(filter) == 0 && cursor == 0:
+		stmt = annGroupListQ
+	case len(filter) == 0 && cursor != 0:
+		stmt = annGroupListWithCursorQ
+	}
+
+	return stmt
+}
+
+func (ar *arangorepository) annoGetBindVars(key string) map[string]interface{} {
+	return map[string]interface{}{
+		"@anno_collection":  ar.anno.annot.Name(),
+		"@cv_collection":    ar.onto.Cv.Name(),
+		"anno_cvterm_graph": ar.anno.annotg.Name(),
+		"key":               key,
+	}
+}
@@ -1,6 +1,7 @@
 package arangodb
 
 import (
+	"context"
 	"regexp"
 	"testing"
 
@@ -27,10 +28,10 @@ func TestListAnnotations(t *testing.T) {
 	defer tearDown(anrepo)
 	tal := newTestTaggedAnnotationsList(15)
 	for _, anno := range tal {
-		_, err := anrepo.AddAnnotation(anno)
+		_, err := anrepo.AddAnnotation(context.Background(), anno)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 	}
-	mla, err := anrepo.ListAnnotations(0, 4, "")
+	mla, err := anrepo.ListAnnotations(context.Background(), 0, 4, "")
 	if err != nil {
 		assert.NoErrorf(err, "expect no error, received %s", err)
 	}
@@ -44,6 +45,7 @@ func TestListAnnotations(t *testing.T) {
 		assert.Equal(int(manno.Rank), 0, "should match the zero rank")
 	}
 	ml2, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(mla[len(mla)-1].CreatedAt),
 		4,
 		"",
@@ -55,6 +57,7 @@ func TestListAnnotations(t *testing.T) {
 	assert.Exactly(mla[len(mla)-1], ml2[0], "should have identical model objects")
 
 	ml3, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(ml2[len(ml2)-1].CreatedAt),
 		4,
 		"",
@@ -64,6 +67,7 @@ func TestListAnnotations(t *testing.T) {
 	assert.Exactly(ml2[len(ml2)-1], ml3[0], "should have identical model objects")
 
 	ml4, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(ml3[len(ml3)-1].CreatedAt),
 		4,
 		"",
@@ -83,10 +87,10 @@ func TestListAnnoFilter(t *testing.T) {
 	defer tearDown(anrepo)
 	tal := newTestTaggedAnnotationsListForFiltering(20)
 	for _, anno := range tal {
-		_, err := anrepo.AddAnnotation(anno)
+		_, err := anrepo.AddAnnotation(context.Background(), anno)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 	}
-	mla, err := anrepo.ListAnnotations(0, 4, filterOne)
+	mla, err := anrepo.ListAnnotations(context.Background(), 0, 4, filterOne)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Len(mla, 5, "should have 5 annotations")
 	for _, m := range mla {
@@ -95,6 +99,7 @@ func TestListAnnoFilter(t *testing.T) {
 		assert.Equal(m.EnrtyId, ddbg[0], "should match the entry id")
 	}
 	ml2, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(mla[len(mla)-1].CreatedAt),
 		4, filterOne,
 	)
@@ -102,13 +107,14 @@ func TestListAnnoFilter(t *testing.T) {
 	assert.Len(ml2, 5, "should have five annotations")
 	assert.Exactly(mla[len(mla)-1], ml2[0], "should have identical model objects")
 	ml3, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(ml2[len(ml2)-1].CreatedAt),
 		4, filterOne,
 	)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Len(ml3, 2, "should have two annotations")
 	assert.Exactly(ml2[len(ml2)-1], ml3[0], "should have identical model objects")
-	ml4, err := anrepo.ListAnnotations(0, 6, filterTwo)
+	ml4, err := anrepo.ListAnnotations(context.Background(), 0, 6, filterTwo)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Len(ml4, 7, "should have 7 annotations")
 	for _, m := range ml4 {
@@ -117,6 +123,7 @@ func TestListAnnoFilter(t *testing.T) {
 		assert.Equal(m.EnrtyId, ddbg[1], "should match the entry id")
 	}
 	ml5, err := anrepo.ListAnnotations(
+		context.Background(),
 		toTimestamp(ml4[len(ml4)-1].CreatedAt),
 		4, filterTwo,
 	)
@@ -126,7 +133,7 @@ func TestListAnnoFilter(t *testing.T) {
 	for _, sml := range [][]*model.AnnoDoc{mla, ml2, ml3, ml4, ml5} {
 		testModelListSort(t, sml)
 	}
-	_, err = anrepo.ListAnnotations(0, 4, filterThree)
+	_, err = anrepo.ListAnnotations(context.Background(), 0, 4, filterThree)
 	assert.Error(err, "expect error")
 	assert.True(repository.IsAnnotationListNotFound(err), "expect no annotation list found")
 }
@@ -136,12 +143,12 @@ func TestGetAnnotationByID(t *testing.T) {
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	nta := newTestTaggedAnnotation()
-	mann, err := anrepo.AddAnnotation(nta)
+	mann, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	nta2 := newTestTaggedAnnotationWithParams("curation", "DDB_G0287317")
-	ml2, err := anrepo.AddAnnotation(nta2)
+	ml2, err := anrepo.AddAnnotation(context.Background(), nta2)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	eim, err := anrepo.GetAnnotationByID(mann.Key)
+	eim, err := anrepo.GetAnnotationByID(context.Background(), mann.Key)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Equal(mann.EnrtyId, eim.EnrtyId, "should match entry identifier")
 	assert.Equal(mann.Ontology, eim.Ontology, "should match ontology")
@@ -151,11 +158,11 @@ func TestGetAnnotationByID(t *testing.T) {
 	assert.True(mann.CreatedAt.Equal(eim.CreatedAt), "should match created time of annotation")
 	assert.Equal(mann.Rank, eim.Rank, "should match rank")
 
-	em2, err := anrepo.GetAnnotationByID(ml2.Key)
+	em2, err := anrepo.GetAnnotationByID(context.Background(), ml2.Key)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Equal(ml2.EnrtyId, em2.EnrtyId, "should match entry identifier")
 
-	nie, err := anrepo.GetAnnotationByID("9999999")
+	nie, err := anrepo.GetAnnotationByID(context.Background(), "9999999")
 	assert.Errorf(err, "expected %s error, received nothing", err)
 	assert.True(
 		repository.IsAnnotationNotFound(err),
@@ -169,12 +176,12 @@ func TestGetAnnotationByEntry(t *testing.T) {
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	nta := newTestTaggedAnnotation()
-	_, err := anrepo.AddAnnotation(nta)
+	_, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	nta2 := newTestTaggedAnnotationWithParams("curation", "DDB_G0287317")
-	_, err = anrepo.AddAnnotation(nta2)
+	_, err = anrepo.AddAnnotation(context.Background(), nta2)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	mae, err := anrepo.GetAnnotationByEntry(&annotation.EntryAnnotationRequest{
+	mae, err := anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
 		Tag:      nta.Data.Attributes.Tag,
 		EntryId:  nta.Data.Attributes.EntryId,
 		Ontology: nta.Data.Attributes.Ontology,
@@ -183,7 +190,7 @@ func TestGetAnnotationByEntry(t *testing.T) {
 	assert.Equal(mae.Rank, int64(0), "should match rank 0")
 	assert.Equal(mae.EnrtyId, nta.Data.Attributes.EntryId, "should match the entry id")
 
-	ml2, err := anrepo.GetAnnotationByEntry(&annotation.EntryAnnotationRequest{
+	ml2, err := anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
 		Tag:      nta2.Data.Attributes.Tag,
 		EntryId:  nta2.Data.Attributes.EntryId,
 		Ontology: nta2.Data.Attributes.Ontology,
@@ -192,7 +199,7 @@ func TestGetAnnotationByEntry(t *testing.T) {
 	assert.Equal(ml2.EnrtyId, nta2.Data.Attributes.EntryId, "should match the entry id")
 	assert.Equal(ml2.Tag, nta2.Data.Attributes.Tag, "should match the tag")
 
-	emt, err := anrepo.GetAnnotationByEntry(&annotation.EntryAnnotationRequest{
+	emt, err := anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
 		Tag:      nta2.Data.Attributes.Tag,
 		Ontology: nta2.Data.Attributes.Ontology,
 		EntryId:  "DDB_G0277853",
@@ -210,7 +217,7 @@ func TestAddAnnotation(t *testing.T) {
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	nta := newTestAnnoWithTagAndOnto("dicty_annotation", "curator")
-	mann, err := anrepo.AddAnnotation(nta)
+	mann, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.False(mann.IsObsolete, "new tagged annotation should not be obsolete")
 	assert.Equal(mann.Value, nta.Data.Attributes.Value, "should match the value")
@@ -219,28 +226,28 @@ func TestAddAnnotation(t *testing.T) {
 	assert.Equal(mann.Rank, nta.Data.Attributes.Rank, "should match the rank")
 	assert.Equal(mann.Ontology, nta.Data.Attributes.Ontology, "should match ontology name")
 	assert.Equal(mann.Tag, nta.Data.Attributes.Tag, "should match the ontology tag")
-	_, err = anrepo.AddAnnotation(nta)
+	_, err = anrepo.AddAnnotation(context.Background(), nta)
 	assert.Error(err, "expect error for existing annotation")
 	assert.Regexp(
 		regexp.MustCompile("already exists"),
 		err.Error(), "error should have existence of annotation",
 	)
 	nta.Data.Attributes.Tag = "respiration"
-	_, err = anrepo.AddAnnotation(nta)
+	_, err = anrepo.AddAnnotation(context.Background(), nta)
 	assert.Error(err, "expect error in case of non-existent ontology and tag")
 	assert.Regexp(
 		regexp.MustCompile("respiration"),
 		err.Error(), "error should contain the non-existent tag name",
 	)
 	nta = newTestAnnoWithTagAndOnto("caboose", "description")
-	_, err = anrepo.AddAnnotation(nta)
+	_, err = anrepo.AddAnnotation(context.Background(), nta)
 	assert.Error(err, "expect error in case of non-existent ontology and tag")
 	assert.Regexp(
 		regexp.MustCompile("caboose"),
 		err.Error(), "error should contain the non-existent ontology",
 	)
 	nta = newTestAnnoWithTagAndOnto("dicty_annotation", "summary")
-	mann2, err := anrepo.AddAnnotation(nta)
+	mann2, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.False(mann2.IsObsolete, "new tagged annotation should not be obsolete")
 	assert.Equal(mann2.Value, nta.Data.Attributes.Value, "should match the value")
@@ -250,7 +257,7 @@ func TestAddAnnotation(t *testing.T) {
 	assert.Equal(mann2.Ontology, nta.Data.Attributes.Ontology, "should match ontology name")
 	assert.Equal(mann2.Tag, "description", "should match the ontology tag")
 	nta = newTestAnnoWithTagAndOnto("dicty_annotation", "decreased 3',5'-cyclic-GMP phosphodiesterase activity")
-	m3, err := anrepo.AddAnnotation(nta)
+	m3, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Equal(m3.Ontology, nta.Data.Attributes.Ontology, "should match ontology name")
 	assert.Equal(m3.Tag, nta.Data.Attributes.Tag, "should match the tag")
@@ -263,14 +270,14 @@ func TestGetAnnotationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(4)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	ids := testModelMaptoID(mla, model2IdCallback)
-	g, err := anrepo.AddAnnotationGroup(ids...)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
-	eg, err := anrepo.GetAnnotationGroup(g.GroupId)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.ElementsMatch(
 		testModelMaptoID(g.AnnoDocs, model2IdCallback),
@@ -286,14 +293,14 @@ func TestListAnnGrFilter(t *testing.T) {
 	tal := newTestTaggedAnnotationsListForFiltering(20)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	j := 5
 	for i := 0; j <= len(mla); i += 5 {
 		ids := testModelMaptoID(mla[i:j], model2IdCallback)
-		_, err := anrepo.AddAnnotationGroup(ids...)
+		_, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		j += 5
 	}
@@ -301,24 +308,25 @@ func TestListAnnGrFilter(t *testing.T) {
 				  AND cvt.label == 'private note'
 				  AND cv.metadata.namespace == 'dicty_annotation'
 	`
-	egl, err := anrepo.ListAnnotationGroup(0, 10, filterOne)
+	egl, err := anrepo.ListAnnotationGroup(context.Background(), 0, 10, filterOne)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	testGroupMember(t, egl, 2, 0, "sidd@gmail.com")
 	filterTwo := `FILTER ann.entry_id == 'DDB_G0294491'
 				  AND cvt.label == 'name description'
 				  AND cv.metadata.namespace == 'dicty_annotation'
 	`
-	egl2, err := anrepo.ListAnnotationGroup(0, 10, filterTwo)
+	egl2, err := anrepo.ListAnnotationGroup(context.Background(), 0, 10, filterTwo)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	testGroupMember(t, egl2, 2, 1, "basu@gmail.com")
 	filterThree := `FILTER cv.metadata.namespace == 'dicty_annotation'`
-	egl3, err := anrepo.ListAnnotationGroup(0, 2, filterThree)
+	egl3, err := anrepo.ListAnnotationGroup(context.Background(), 0, 2, filterThree)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Len(egl3, 2, "should have two groups")
 	for _, g := range egl3 {
 		assert.Len(g.AnnoDocs, 5, "should have 5 annotations in each group")
 	}
 	egl4, err := anrepo.ListAnnotationGroup(
+		context.Background(),
 		toTimestamp(egl3[len(egl3)-1].CreatedAt),
 		4,
 		filterThree,
@@ -328,7 +336,7 @@ func TestListAnnGrFilter(t *testing.T) {
 	for _, g := range egl4 {
 		assert.Len(g.AnnoDocs, 5, "should have 5 annotations in each group")
 	}
-	_, err = anrepo.ListAnnotationGroup(0, 4, "FILTER ann.entry_id == 'jumbo'")
+	_, err = anrepo.ListAnnotationGroup(context.Background(), 0, 4, "FILTER ann.entry_id == 'jumbo'")
 	assert.Error(err, "expect error")
 	assert.True(repository.IsAnnotationGroupListNotFound(err), "expect no annotation group to be found")
 }
@@ -340,24 +348,25 @@ func TestListAnnotationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(60)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	j := 5
 	for i := 0; j <= len(mla); i += 5 {
 		ids := testModelMaptoID(mla[i:j], model2IdCallback)
-		_, err := anrepo.AddAnnotationGroup(ids...)
+		_, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		j += 5
 	}
-	egl, err := anrepo.ListAnnotationGroup(0, 4, "")
+	egl, err := anrepo.ListAnnotationGroup(context.Background(), 0, 4, "")
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Len(egl, 4, "should have 4 groups")
 	for _, g := range egl {
 		assert.Len(g.AnnoDocs, 5, "should have 5 annotations in each group")
 	}
 	egl2, err := anrepo.ListAnnotationGroup(
+		context.Background(),
 		toTimestamp(egl[len(egl)-1].CreatedAt),
 		6,
 		"",
@@ -373,6 +382,7 @@ func TestListAnnotationGroup(t *testing.T) {
 		"should have identical model objects",
 	)
 	egl3, err := anrepo.ListAnnotationGroup(
+		context.Background(),
 		toTimestamp(egl2[len(egl2)-1].CreatedAt),
 		6,
 		"",
@@ -389,18 +399,211 @@ func TestListAnnotationGroup(t *testing.T) {
 	)
 }
 
+func TestListAnnotationGroupByType(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	_, err := anrepo.SetGroupType(context.Background(), &model.GroupType{Ontology: "dicty_annotation", Tag: "curation"})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	gtp, err := anrepo.GetGroupType(context.Background(), "dicty_annotation", "curation")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(gtp.AllowedTags, "should allow any tag")
+	_, err = anrepo.GetGroupType(context.Background(), "dicty_annotation", "product")
+	assert.True(repository.IsGroupTypeNotFound(err), "should not find undefined group type")
+	ids := addTestAnnotationsForGroup(t, anrepo, 20)
+	params := &model.GroupParams{Ontology: "dicty_annotation", Tag: "curation", Name: "curated"}
+	for i := 0; i < 10; i += 2 {
+		_, err := anrepo.AddTypedAnnotationGroup(context.Background(), params, ids[i:i+2]...)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+	}
+	for i := 10; i < len(ids); i += 5 {
+		_, err := anrepo.AddAnnotationGroup(context.Background(), ids[i:i+5]...)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+	}
+	egl, err := anrepo.ListAnnotationGroupByType(context.Background(), 0, 10, "", "dicty_annotation", "curation")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(egl, 5, "should have 5 typed groups")
+	for _, g := range egl {
+		assert.Equal("curation", g.GroupType, "should match the group type")
+		assert.Equal("curated", g.Name, "should match the group name")
+		assert.Len(g.AnnoDocs, 2, "should have 2 annotations in each group")
+	}
+	all, err := anrepo.ListAnnotationGroup(context.Background(), 0, 10, "")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(all, 7, "should have groups of every type")
+	_, err = anrepo.ListAnnotationGroupByType(context.Background(), 0, 10, "", "dicty_annotation", "product")
+	assert.True(repository.IsAnnotationGroupListNotFound(err), "should not find groups of other type")
+}
+
+func TestListGroupsByAnnotation(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 6)
+	g1, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[1], ids[2])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	g2, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[3])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids[4], ids[5])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	gl, err := anrepo.ListGroupsByAnnotation(context.Background(), ids[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(gl, 2, "should have two groups with the annotation")
+	assert.ElementsMatch(
+		[]string{g1.GroupId, g2.GroupId},
+		[]string{gl[0].GroupId, gl[1].GroupId},
+		"should match the group identifiers",
+	)
+	gl, err = anrepo.ListGroupsByAnnotation(context.Background(), ids[3])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(gl, 1, "should have one group with the annotation")
+	assert.Len(gl[0].AnnoDocs, 2, "should have all members of the group")
+	_, err = anrepo.ListGroupsByAnnotation(context.Background(), "9999999")
+	assert.True(repository.IsAnnotationGroupListNotFound(err), "should not find any group")
+	ma, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("curation", "DDB_G0294491"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	mb, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("product", "DDB_G0294491"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	g3, err := anrepo.AddAnnotationGroup(context.Background(), ma.Key, ids[1])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	g4, err := anrepo.AddAnnotationGroup(context.Background(), mb.Key, ma.Key)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	el, err := anrepo.ListGroupsByEntry(context.Background(), "DDB_G0294491")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(el, 2, "should have each group of the entry once")
+	assert.ElementsMatch(
+		[]string{g3.GroupId, g4.GroupId},
+		[]string{el[0].GroupId, el[1].GroupId},
+		"should match the group identifiers of the entry",
+	)
+	_, err = anrepo.ListGroupsByEntry(context.Background(), "DDB_G0000000")
+	assert.True(repository.IsAnnotationGroupListNotFound(err), "should not find any group of the entry")
+}
+
 func TestGetAnnotationTag(t *testing.T) {
 	t.Parallel()
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	for _, tag := range tags[:6] {
-		m, err := anrepo.GetAnnotationTag(tag, "dicty_annotation")
+		m, err := anrepo.GetAnnotationTag(context.Background(), tag, "dicty_annotation")
 		assert.NoErrorf(err, "expect no error from fetching %s tag", tag)
 		assert.Equal(m.Name, tag, "should match tag name")
 		assert.Equal(m.Ontology, "dicty_annotation", "should match ontology")
 		assert.Falsef(m.IsObsolete, "tag %s should not be obsolete", tag)
 	}
-	_, err := anrepo.GetAnnotationTag("yadayada", "dicty_annotation")
+	_, err := anrepo.GetAnnotationTag(context.Background(), "yadayada", "dicty_annotation")
 	assert.Error(err, "expect error from non-existent tag")
 	assert.True(repository.IsAnnoTagNotFound(err), "should be an error for non-existent tag")
 }
+
+func TestGetAnnotationTags(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	keys := []*model.TagKey{{Name: "yadayada", Ontology: "dicty_annotation"}}
+	for _, tag := range tags[:4] {
+		keys = append(keys, &model.TagKey{Name: tag, Ontology: "dicty_annotation"})
+	}
+	tl, err := anrepo.GetAnnotationTags(context.Background(), keys)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(tl, 4, "should leave out the unknown tag")
+	for i, m := range tl {
+		assert.Equal(m.Name, tags[i], "should match tag name in the order of the keys")
+		assert.Equal(m.Ontology, "dicty_annotation", "should match ontology")
+		assert.NotEmpty(m.ID, "should have the tag identifier")
+	}
+	ol, err := anrepo.GetOntologies(context.Background(), []string{"dicty_annotation", "yadayada"})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(ol, 1, "should leave out the unknown ontology")
+	assert.Equal(ol[0].Namespace, "dicty_annotation", "should match the namespace")
+	ol, err = anrepo.GetOntologies(context.Background(), []string{"yadayada"})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(ol, "should not find any ontology")
+}
+
+func TestListGroupsByAnnotations(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 6)
+	g1, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[1])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	g2, err := anrepo.AddAnnotationGroup(context.Background(), ids[2], ids[3])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids[4], ids[5])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	gl, err := anrepo.ListGroupsByAnnotations(context.Background(), []string{ids[0], ids[1], ids[3]})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(gl, 2, "should have each group of the annotations once")
+	assert.ElementsMatch(
+		[]string{g1.GroupId, g2.GroupId},
+		[]string{gl[0].GroupId, gl[1].GroupId},
+		"should match the group identifiers",
+	)
+	for _, g := range gl {
+		assert.Len(g.AnnoDocs, 2, "should have all members of the group")
+	}
+	_, err = anrepo.ListGroupsByAnnotations(context.Background(), []string{"9999999"})
+	assert.True(repository.IsAnnotationGroupListNotFound(err), "should not find any group")
+}
+
+func TestGetEntryProfile(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	tal := newTestTaggedAnnotationsListForFiltering(20)
+	mla := make([]*model.AnnoDoc, 0)
+	for _, ann := range tal {
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		mla = append(mla, m)
+	}
+	nta := newTestTaggedAnnotationWithParams("curation", ddbg[0])
+	_, err := anrepo.AddAnnotation(context.Background(), nta)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	ids := testModelMaptoID(mla[:3], model2IdCallback)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = anrepo.RemoveAnnotation(context.Background(), mla[9].Key, false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	prof, err := anrepo.GetEntryProfile(context.Background(), ddbg[0])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(prof.EntryId, ddbg[0], "should match the entry id")
+	assert.Len(prof.Tags, 2, "should have two tags")
+	assert.Equal(prof.Tags[0].Tag, "curation", "should have curation as first tag")
+	assert.Len(prof.Tags[0].AnnoDocs, 1, "should have one curation annotation")
+	assert.Equal(prof.Tags[1].Tag, tags[0], "should match the second tag")
+	assert.Len(prof.Tags[1].AnnoDocs, 9, "should have nine live annotations")
+	for idx, m := range prof.Tags[1].AnnoDocs {
+		assert.Equal(m.Rank, int64(idx), "should be ordered by rank")
+		assert.Equal(m.Ontology, "dicty_annotation", "should match the ontology")
+		if idx < 3 {
+			assert.Equal(m.Groups, []string{g.GroupId}, "should belong to the group")
+		} else {
+			assert.Empty(m.Groups, "should not belong to any group")
+		}
+	}
+	_, err = anrepo.GetEntryProfile(context.Background(), "DDB_G0277853")
+	assert.Error(err, "expect error for entry without annotations")
+	assert.True(repository.IsAnnotationNotFound(err), "entry should not exist")
+}
+
+func TestCountByOntology(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	counts, err := anrepo.CountByOntology(context.Background())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(counts, "should have no counts without annotations")
+	ids := addTestAnnotationsForGroup(t, anrepo, 6)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids[:3]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = anrepo.RemoveAnnotation(context.Background(), ids[5], false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	counts, err = anrepo.CountByOntology(context.Background())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(counts, 1, "should count one ontology")
+	assert.Equal("dicty_annotation", counts[0].Ontology, "should match the ontology")
+	assert.Equal(int64(5), counts[0].Annotations, "should count only live annotations")
+	assert.Equal(int64(1), counts[0].Groups, "should count the group")
+}
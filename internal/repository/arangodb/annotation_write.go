@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"time"
 
 	driver "github.com/arangodb/go-driver"
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
@@ -11,27 +13,45 @@ import (
 	"github.com/dictyBase/modware-annotation/internal/repository"
 )
 
-const maxTransactionSize = 10000
+const (
+	maxTransactionSize = 10000
+	// memberOverhead is the approximate size in bytes an edit writes for
+	// every member of a group holding the annotation, the member is
+	// rewritten in the group and copied to the snapshot of the group
+	memberOverhead = 64
+	// staleErrorNum is the error number raised by the edit transaction for
+	// an annotation that is no longer at the expected version, it matches
+	// ERROR_HTTP_PRECONDITION_FAILED of arangodb
+	staleErrorNum = 412
+	// conflictWait and maxConflictWait bound the backoff between the
+	// retries of a write conflicting with a concurrent one
+	conflictWait    = 10 * time.Millisecond
+	maxConflictWait = 500 * time.Millisecond
+	// conflictTimeout limits the retries of a conflicting write when the
+	// context has no deadline
+	conflictTimeout = 30 * time.Second
+)
 
-func (ar *arangorepository) AddAnnotation(na *annotation.NewTaggedAnnotation) (*model.AnnoDoc, error) {
+func (ar *arangorepository) AddAnnotation(ctx context.Context, na *annotation.NewTaggedAnnotation) (*model.AnnoDoc, error) {
 	mann := &model.AnnoDoc{}
 	attr := na.Data.Attributes
 	// check if the tag and ontology exist
-	cvtid, err := ar.termID(attr.Ontology, attr.Tag)
+	cvtid, err := ar.termID(ctx, attr.Ontology, attr.Tag)
 	if err != nil {
 		return mann, err
 	}
 	// get the tag from database
-	tag, err := ar.termName(cvtid)
+	tag, err := ar.termName(ctx, cvtid)
 	if err != nil {
 		return mann, err
 	}
 	// check if the annotation exist
-	if err := ar.existAnno(attr, tag); err != nil {
+	if err := ar.existAnno(ctx, attr, tag); err != nil {
 		return mann, err
 	}
 
 	return ar.createAnno(
+		ctx,
 		&createParams{
 			attr: attr,
 			id:   cvtid,
@@ -40,18 +60,23 @@ func (ar *arangorepository) AddAnnotation(na *annotation.NewTaggedAnnotation) (*
 	)
 }
 
-func (ar *arangorepository) EditAnnotation(uat *annotation.TaggedAnnotationUpdate) (*model.AnnoDoc, error) {
+func (ar *arangorepository) EditAnnotation(ctx context.Context, uat *annotation.TaggedAnnotationUpdate) (*model.AnnoDoc, error) {
+	return ar.EditAnnotationAtVersion(ctx, uat, 0)
+}
+
+// EditAnnotationAtVersion creates a new version of an annotation only if
+// the annotation is still at the expected version. The version is not
+// checked when it is zero, however an annotation that has already been
+// edited is never edited again, so that every version has at most one
+// successor.
+func (ar *arangorepository) EditAnnotationAtVersion(
+	ctx context.Context,
+	uat *annotation.TaggedAnnotationUpdate,
+	version int64,
+) (*model.AnnoDoc, error) {
 	mann := &model.AnnoDoc{}
 	attr := uat.Data.Attributes
-	rgt, err := ar.database.Get(
-		fmt.Sprintf(
-			annGetQ,
-			ar.anno.annot.Name(),
-			ar.anno.annotg.Name(),
-			ar.onto.Cv.Name(),
-			uat.Data.Id,
-		),
-	)
+	rgt, err := ar.getRow(ctx, "annGetQ", annGetQ, ar.annoGetBindVars(uat.Data.Id))
 	if err != nil {
 		return mann, fmt.Errorf("error in fetching id %s", err)
 	}
@@ -63,37 +88,23 @@ func (ar *arangorepository) EditAnnotation(uat *annotation.TaggedAnnotationUpdat
 	if err := rgt.Read(mann); err != nil {
 		return mann, fmt.Errorf("error in reading to struct %s", err)
 	}
-	// create annotation document
-	bindParams := []interface{}{
-		ar.anno.annot.Name(),
-		ar.anno.term.Name(),
-		ar.anno.ver.Name(),
-		attr.Value,
-		attr.EditableValue,
-		attr.CreatedBy,
-		mann.EnrtyId,
-		mann.Rank,
-		mann.Version + 1,
-		mann.CvtId,
-		mann.ID.String(),
-	}
-	dbh := ar.database.Handler()
-	idt, err := dbh.Transaction(
-		context.Background(),
-		annVerInstFn,
-		&driver.TransactionOptions{
-			WriteCollections: []string{
-				ar.anno.annot.Name(),
-				ar.anno.term.Name(),
-				ar.anno.ver.Name(),
-			},
-			Params:             bindParams,
-			MaxTransactionSize: maxTransactionSize,
-		})
+	if mann.IsObsolete || (version > 0 && mann.Version != version) {
+		return mann, &repository.AnnoConflictError{
+			Id:     mann.Key,
+			Reason: fmt.Sprintf("annotation is at version %d and has been edited", mann.Version),
+		}
+	}
+	out, err := ar.editVersions(ctx, []map[string]interface{}{{
+		"key":            mann.Key,
+		"version":        version,
+		"value":          attr.Value,
+		"editable_value": attr.EditableValue,
+		"created_by":     attr.CreatedBy,
+	}})
 	if err != nil {
-		return mann, fmt.Errorf("error in running transaction %s", err)
+		return mann, err
 	}
-	umd, err := model.ConvToModel(idt)
+	umd, err := model.ConvToModel(out[0])
 	if err != nil {
 		return umd, fmt.Errorf("error in converting model struct %s", err)
 	}
@@ -103,47 +114,259 @@ func (ar *arangorepository) EditAnnotation(uat *annotation.TaggedAnnotationUpdat
 	return umd, nil
 }
 
-// Creates a new annotation group.
-func (ar *arangorepository) AddAnnotationGroup(idslice ...string) (*model.AnnoGroup, error) {
-	grp := &model.AnnoGroup{}
-	if len(idslice) <= 1 {
-		return grp, errors.New("need at least more than one entry to form a group")
+// editVersions creates a new version for every edit in a single
+// transaction. The edited annotations are marked obsolete and the groups
+// containing them are updated to the new versions. A transaction that
+// conflicts with a concurrent write is retried.
+func (ar *arangorepository) editVersions(
+	ctx context.Context,
+	edits []map[string]interface{},
+) ([]interface{}, error) {
+	var out []interface{}
+	members, err := ar.groupMembers(ctx, editKeys(edits))
+	if err != nil {
+		return out, err
 	}
-	// check if the annotations exists
-	if err := DocumentsExists(ar.anno.annot, idslice...); err != nil {
-		return grp, err
+	size := 0
+	for _, e := range edits {
+		size += editSize(e, members)
 	}
-	// retrieve all annotations objects
-	mla, err := ar.getAllAnnotations(idslice...)
+	var idt interface{}
+	err = retryConflict(ctx, func() (err error) {
+		tctx, span := ar.startSpan(ctx, "transaction", "annVerInstFn")
+		defer func() { endSpan(span, err) }()
+		idt, err = ar.database.Handler().Transaction(
+			tctx,
+			annVerInstFn,
+			&driver.TransactionOptions{
+				WriteCollections: []string{
+					ar.anno.annot.Name(),
+					ar.anno.term.Name(),
+					ar.anno.ver.Name(),
+					ar.anno.annog.Name(),
+					ar.anno.annogv.Name(),
+				},
+				Params: []interface{}{
+					map[string]interface{}{
+						"stale_error_num":               staleErrorNum,
+						"anno_collection":               ar.anno.annot.Name(),
+						"anno_cv_collection":            ar.anno.term.Name(),
+						"anno_ver_collection":           ar.anno.ver.Name(),
+						"anno_group_collection":         ar.anno.annog.Name(),
+						"anno_group_version_collection": ar.anno.annogv.Name(),
+						"replace_query":                 annGroupMemberReplaceQ,
+					},
+					edits,
+				},
+				MaxTransactionSize: transactionSize(size),
+			})
+
+		return err
+	})
 	if err != nil {
-		return grp, err
+		if aerr, ok := driver.AsArangoError(err); ok && aerr.ErrorNum == staleErrorNum {
+			key := fmt.Sprintf("%v", edits[0]["key"])
+			_, _ = fmt.Sscanf(aerr.ErrorMessage, "annotation %s is at version", &key)
+
+			return out, &repository.AnnoConflictError{Id: key, Reason: aerr.ErrorMessage}
+		}
+
+		return out, fmt.Errorf("error in running transaction %s", err)
 	}
-	dbg := &model.DbGroup{}
-	rdn, err := ar.database.DoRun(
-		annGroupInst,
+	out, ok := idt.([]interface{})
+	if !ok || len(out) != len(edits) {
+		return out, errors.New("error in reading the new versions")
+	}
+
+	return out, nil
+}
+
+// groupMembers returns the number of members of all the groups holding
+// each of the annotations, an edit of the annotation rewrites them.
+func (ar *arangorepository) groupMembers(ctx context.Context, keys []string) (map[string]int, error) {
+	members := make(map[string]int)
+	rs, err := ar.searchRows(
+		ctx,
+		"annGroupMemberCountQ", annGroupMemberCountQ,
 		map[string]interface{}{
 			"@anno_group_collection": ar.anno.annog.Name(),
-			"group":                  idslice,
-		},
-	)
+			"keys":                   keys,
+		})
 	if err != nil {
-		return grp, fmt.Errorf("error in creating group %s", err)
+		return members, fmt.Errorf("error in counting group members %s", err)
+	}
+	for rs.Scan() {
+		var cnt struct {
+			Key     string `json:"key"`
+			Members int    `json:"members"`
+		}
+		if err := rs.Read(&cnt); err != nil {
+			return members, fmt.Errorf("error in reading data %s", err)
+		}
+		members[cnt.Key] = cnt.Members
+	}
+
+	return members, nil
+}
+
+func editKeys(edits []map[string]interface{}) []string {
+	keys := make([]string, 0, len(edits))
+	for _, e := range edits {
+		keys = append(keys, fmt.Sprint(e["key"]))
+	}
+
+	return keys
+}
+
+// editSize estimates the size in bytes written by an edit, along with the
+// rewrite of the groups holding the annotation.
+func editSize(edit map[string]interface{}, members map[string]int) int {
+	return versionOverhead +
+		len(fmt.Sprint(edit["value"])) +
+		len(fmt.Sprint(edit["editable_value"])) +
+		memberOverhead*members[fmt.Sprint(edit["key"])]
+}
+
+// transactionSize caps a transaction at twice its estimated size, but
+// never below maxTransactionSize.
+func transactionSize(size int) int {
+	if 2*size > maxTransactionSize {
+		return 2 * size
+	}
+
+	return maxTransactionSize
+}
+
+// Creates a new annotation group.
+func (ar *arangorepository) AddAnnotationGroup(ctx context.Context, idslice ...string) (*model.AnnoGroup, error) {
+	return ar.AddTypedAnnotationGroup(ctx, &model.GroupParams{}, idslice...)
+}
+
+// AddTypedAnnotationGroup creates a new annotation group with a type, name
+// and description. The members are validated against the rules of the
+// group type.
+func (ar *arangorepository) AddTypedAnnotationGroup(
+	ctx context.Context,
+	params *model.GroupParams,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	if len(idslice) <= 1 {
+		return &model.AnnoGroup{}, errors.New("need at least more than one entry to form a group")
+	}
+	bindVars := map[string]interface{}{
+		"group_type":     nullString(params.Tag),
+		"group_ontology": nullString(params.Ontology),
+		"name":           nullString(params.Name),
+		"description":    nullString(params.Description),
+		"created_by":     nullString(params.CreatedBy),
+	}
+
+	return ar.writeGroup(ctx, "annGroupInst", annGroupInst, "", idslice, bindVars)
+}
+
+// EditAnnotationGroup changes the name and description of a group.
+func (ar *arangorepository) EditAnnotationGroup(
+	ctx context.Context,
+	groupID, name, description, updatedBy string,
+) (*model.AnnoGroup, error) {
+	grp := &model.AnnoGroup{}
+	res, err := ar.doRun(
+		ctx,
+		"annGroupEditQ", annGroupEditQ,
+		map[string]interface{}{
+			"@anno_collection":       ar.anno.annot.Name(),
+			"@cv_collection":         ar.onto.Cv.Name(),
+			"@anno_group_collection": ar.anno.annog.Name(),
+			"anno_cvterm_graph":      ar.anno.annotg.Name(),
+			"key":                    groupID,
+			"name":                   nullString(name),
+			"description":            nullString(description),
+			"updated_by":             nullString(updatedBy),
+		})
+	if err != nil {
+		return grp, fmt.Errorf("error in updating group with id %s %s", groupID, err)
+	}
+	if res.IsEmpty() {
+		return grp, &repository.GroupNotFoundError{Id: groupID}
 	}
-	if err := rdn.Read(dbg); err != nil {
+	if err := res.Read(grp); err != nil {
 		return grp, fmt.Errorf("error in reading to struct %s", err)
 	}
-	grp.CreatedAt = dbg.CreatedAt
-	grp.UpdatedAt = dbg.UpdatedAt
-	grp.GroupId = dbg.GroupId
-	grp.AnnoDocs = mla
 
 	return grp, nil
 }
 
-// Delete an annotation group.
-func (ar *arangorepository) RemoveAnnotationGroup(groupID string) error {
+// MoveAnnotationGroupMember moves a member of a group to a zero based
+// position, a position beyond the last member moves it to the end.
+func (ar *arangorepository) MoveAnnotationGroupMember(
+	ctx context.Context,
+	groupID, annoID string,
+	position int64,
+) (*model.AnnoGroup, error) {
+	if position < 0 {
+		return &model.AnnoGroup{}, fmt.Errorf("invalid member position %d", position)
+	}
+
+	return ar.writeGroup(
+		ctx,
+		"annGroupMoveQ",
+		annGroupMoveQ,
+		groupID,
+		[]string{annoID},
+		map[string]interface{}{"position": position},
+	)
+}
+
+// ReorderAnnotationGroup places the given members at the start of a group
+// in the given order, the remaining members follow in their current order.
+func (ar *arangorepository) ReorderAnnotationGroup(
+	ctx context.Context,
+	groupID string,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	if len(idslice) == 0 {
+		return &model.AnnoGroup{}, errors.New("need at least one entry to reorder a group")
+	}
+
+	return ar.writeGroup(ctx, "annGroupReorderQ", annGroupReorderQ, groupID, idslice, nil)
+}
+
+// SetGroupType creates or updates a group type. The ontology term that
+// names the group type has to exist.
+func (ar *arangorepository) SetGroupType(ctx context.Context, gtp *model.GroupType) (*model.GroupType, error) {
+	mgt := &model.GroupType{}
+	if _, err := ar.termID(ctx, gtp.Ontology, gtp.Tag); err != nil {
+		return mgt, err
+	}
+	allowed := gtp.AllowedTags
+	if allowed == nil {
+		allowed = []string{}
+	}
+	res, err := ar.doRun(
+		ctx,
+		"annGroupTypeUpsertQ", annGroupTypeUpsertQ,
+		map[string]interface{}{
+			"@anno_group_type_collection": ar.anno.annogt.Name(),
+			"ontology":                    gtp.Ontology,
+			"tag":                         gtp.Tag,
+			"allowed_tags":                allowed,
+			"min_members":                 gtp.MinMembers,
+			"max_members":                 gtp.MaxMembers,
+		})
+	if err != nil {
+		return mgt, fmt.Errorf("error in saving group type %s", err)
+	}
+	if err := res.Read(mgt); err != nil {
+		return mgt, fmt.Errorf("error in reading to struct %s", err)
+	}
+
+	return mgt, nil
+}
+
+// Remove an annotation group.
+func (ar *arangorepository) RemoveAnnotationGroup(ctx context.Context, groupID string) error {
 	_, err := ar.anno.annog.RemoveDocument(
-		context.Background(),
+		ctx,
 		groupID,
 	)
 	if err != nil {
@@ -154,52 +377,151 @@ func (ar *arangorepository) RemoveAnnotationGroup(groupID string) error {
 }
 
 // Add a new annotations to an existing group.
-func (ar *arangorepository) AppendToAnnotationGroup(groupID string, idslice ...string) (*model.AnnoGroup, error) {
-	grp := &model.AnnoGroup{}
+func (ar *arangorepository) AppendToAnnotationGroup(ctx context.Context, groupID string, idslice ...string) (*model.AnnoGroup, error) {
 	if len(idslice) <= 1 {
-		return grp, errors.New("need at least more than one entry to form a group")
+		return &model.AnnoGroup{}, errors.New("need at least more than one entry to form a group")
 	}
-	// retrieve annotation objects for existing group
-	gml, err := ar.groupID2Annotations(groupID)
-	if err != nil {
-		return grp, err
+
+	return ar.writeGroup(ctx, "annGroupAppendQ", annGroupAppendQ, groupID, idslice, nil)
+}
+
+// writeGroup runs a group modification query that validates, updates and
+// retrieves the group along with all of its annotations in a single round
+// trip. The membership change is computed from the stored document inside
+// the query, a concurrent modification of the same group aborts it with a
+// write-write conflict and the query is then retried against the new
+// state, so no update is lost.
+func (ar *arangorepository) writeGroup(
+	ctx context.Context,
+	name, query, groupID string,
+	idslice []string,
+	params map[string]interface{},
+) (*model.AnnoGroup, error) {
+	grp := &groupResult{}
+	bindVars := map[string]interface{}{
+		"@anno_collection":               ar.anno.annot.Name(),
+		"@cv_collection":                 ar.onto.Cv.Name(),
+		"@anno_group_collection":         ar.anno.annog.Name(),
+		"@anno_group_type_collection":    ar.anno.annogt.Name(),
+		"@anno_group_version_collection": ar.anno.annogv.Name(),
+		"anno_cvterm_graph":              ar.anno.annotg.Name(),
+		"group":                          model.UniqueIds(idslice),
+	}
+	if len(groupID) > 0 {
+		bindVars["key"] = groupID
 	}
-	// retrieve annotation objects for given identifiers
-	ml, err := ar.getAllAnnotations(idslice...)
+	for k, v := range params {
+		bindVars[k] = v
+	}
+	err := retryConflict(ctx, func() error {
+		return ar.runGroupQuery(ctx, name, query, bindVars, grp)
+	})
 	if err != nil {
-		return grp, err
+		return &grp.AnnoGroup, fmt.Errorf("error in writing group %s", err)
 	}
-	// remove duplicates
-	aml := model.UniqueModel(append(gml, ml...))
-	// update the new group
-	rdn, err := ar.database.DoRun(
-		annGroupUpd,
-		map[string]interface{}{
-			"@anno_group_collection": ar.anno.annog.Name(),
-			"key":                    groupID,
-			"group":                  model.DocToIds(aml),
-		},
-	)
+
+	return &grp.AnnoGroup, groupResultError(groupID, grp)
+}
+
+// retryConflict runs a write until it no longer conflicts with a concurrent
+// one, backing off exponentially with jitter. It gives up at the deadline of
+// the context or after conflictTimeout without one, returning the last
+// conflict.
+func retryConflict(ctx context.Context, write func() error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(conflictTimeout)
+	}
+	wait := conflictWait
+	for {
+		err := write()
+		if !driver.IsArangoErrorWithErrorNum(err, driver.ErrArangoConflict) {
+			return err
+		}
+		pause := wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		if time.Now().Add(pause).After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(pause):
+		}
+		if wait *= 2; wait > maxConflictWait {
+			wait = maxConflictWait
+		}
+	}
+}
+
+// groupResultError converts the validation outcome of a group modification
+// query to an error.
+func groupResultError(groupID string, grp *groupResult) error {
+	switch {
+	case !grp.Exists:
+		return &repository.GroupNotFoundError{Id: groupID}
+	case len(grp.Missing) > 0:
+		return &repository.AnnoNotFoundError{Id: grp.Missing[0]}
+	case len(grp.Obsolete) > 0:
+		return &repository.ObsoleteMemberError{Id: grp.Obsolete[0]}
+	case grp.TypeMissing:
+		return &repository.GroupTypeNotFoundError{
+			Ontology: grp.GroupOntology,
+			Tag:      grp.GroupType,
+		}
+	case len(grp.Disallowed) > 0:
+		return &repository.GroupRuleError{
+			Reason: fmt.Sprintf("tag of annotation %s is not allowed", grp.Disallowed[0]),
+		}
+	case grp.MinMembers > 0 && grp.Size < grp.MinMembers:
+		return &repository.GroupRuleError{
+			Reason: fmt.Sprintf("needs at least %d members, has %d", grp.MinMembers, grp.Size),
+		}
+	case grp.MaxMembers > 0 && grp.Size > grp.MaxMembers:
+		return &repository.GroupRuleError{
+			Reason: fmt.Sprintf("allows at most %d members, has %d", grp.MaxMembers, grp.Size),
+		}
+	}
+
+	return nil
+}
+
+// nullString maps an empty string to a null database value.
+func nullString(str string) interface{} {
+	if len(str) == 0 {
+		return nil
+	}
+
+	return str
+}
+
+// runGroupQuery runs the query with the database driver, keeping the
+// driver error intact for conflict detection.
+func (ar *arangorepository) runGroupQuery(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+	grp *groupResult,
+) (err error) {
+	ctx, span := ar.startSpan(ctx, "aql", name)
+	defer func() { endSpan(span, err) }()
+	cursor, err := ar.database.Handler().Query(ar.queryContext(ctx), query, bindVars)
 	if err != nil {
-		return grp, fmt.Errorf("error in updating group with id %s %s", groupID, err)
+		return err
 	}
-	dbg := &model.DbGroup{}
-	if err := rdn.Read(dbg); err != nil {
-		return grp, fmt.Errorf("error in reading to struct %s", err)
+	defer cursor.Close()
+	if _, err := cursor.ReadDocument(ctx, grp); err != nil {
+		return err
 	}
-	grp.CreatedAt = dbg.CreatedAt
-	grp.UpdatedAt = dbg.UpdatedAt
-	grp.GroupId = dbg.GroupId
-	grp.AnnoDocs = aml
 
-	return grp, nil
+	return nil
 }
 
-func (ar *arangorepository) createAnno(params *createParams) (*model.AnnoDoc, error) {
+func (ar *arangorepository) createAnno(ctx context.Context, params *createParams) (*model.AnnoDoc, error) {
 	mann := &model.AnnoDoc{}
 	attr := params.attr
-	rins, err := ar.database.DoRun(
-		annInst, map[string]interface{}{
+	rins, err := ar.doRun(
+		ctx,
+		"annInst", annInst, map[string]interface{}{
 			"@anno_collection":    ar.anno.annot.Name(),
 			"@anno_cv_collection": ar.anno.term.Name(),
 			"editable_value":      attr.EditableValue,
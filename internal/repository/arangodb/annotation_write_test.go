@@ -1,10 +1,14 @@
 package arangodb
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
 	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestEditAnnotation(t *testing.T) {
@@ -12,7 +16,7 @@ func TestEditAnnotation(t *testing.T) {
 	assert, anrepo := setUp(t)
 	defer tearDown(anrepo)
 	nta := newTestTaggedAnnotation()
-	mda, err := anrepo.AddAnnotation(nta)
+	mda, err := anrepo.AddAnnotation(context.Background(), nta)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	uan := &annotation.TaggedAnnotationUpdate{
 		Data: &annotation.TaggedAnnotationUpdate_Data{
@@ -25,7 +29,7 @@ func TestEditAnnotation(t *testing.T) {
 			},
 		},
 	}
-	um, err := anrepo.EditAnnotation(uan)
+	um, err := anrepo.EditAnnotation(context.Background(), uan)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Equal(mda.Version+1, um.Version, "version should be incremented by 1")
 	assert.NotEqual(uan.Data.Id, um.Key, "identifier should not match")
@@ -40,12 +44,12 @@ func TestAddAnnotationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(8)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	ids := testModelMaptoID(mla, model2IdCallback)
-	g, err := anrepo.AddAnnotationGroup(ids...)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.Lenf(g.AnnoDocs, len(ids), "should have %d annotations", len(ids))
 }
@@ -57,15 +61,15 @@ func TestAppendToAnntationGroup(t *testing.T) {
 	tal := newTestTaggedAnnotationsList(7)
 	mla := make([]*model.AnnoDoc, 0)
 	for _, ann := range tal {
-		m, err := anrepo.AddAnnotation(ann)
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
 		assert.NoErrorf(err, "expect no error, received %s", err)
 		mla = append(mla, m)
 	}
 	ids := testModelMaptoID(mla[:4], model2IdCallback)
-	g, err := anrepo.AddAnnotationGroup(ids...)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	nids := testModelMaptoID(mla[4:], model2IdCallback)
-	eg, err := anrepo.AppendToAnnotationGroup(g.GroupId, nids...)
+	eg, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, nids...)
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.ElementsMatch(
 		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
@@ -73,3 +77,449 @@ func TestAppendToAnntationGroup(t *testing.T) {
 		"expected identical annotation identifiers after appending to the group",
 	)
 }
+
+func addTestAnnotationsForGroup(
+	t *testing.T,
+	anrepo repository.TaggedAnnotationRepository,
+	num int,
+) []string {
+	t.Helper()
+	mla := make([]*model.AnnoDoc, 0)
+	for _, ann := range newTestTaggedAnnotationsList(num) {
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
+		if err != nil {
+			t.Fatalf("expect no error, received %s", err)
+		}
+		mla = append(mla, m)
+	}
+
+	return testModelMaptoID(mla, model2IdCallback)
+}
+
+func TestConcurrentAppendToAnnotationGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 42)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[:2]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	egrp := new(errgroup.Group)
+	for idx := 2; idx < len(ids); idx += 2 {
+		pair := ids[idx : idx+2]
+		egrp.Go(func() error {
+			_, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, pair...)
+
+			return err
+		})
+		// same members appended concurrently should not be duplicated
+		egrp.Go(func() error {
+			_, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[:2]...)
+
+			return err
+		})
+	}
+	err = egrp.Wait()
+	assert.NoErrorf(err, "expect no error from concurrent appends, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		ids,
+		"should have every appended annotation exactly once",
+	)
+}
+
+func TestConcurrentRemoveFromAnnotationGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 42)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	extra := addTestAnnotationsForGroup(t, anrepo, 20)
+	egrp := new(errgroup.Group)
+	for idx := 2; idx < len(ids); idx += 2 {
+		pair := ids[idx : idx+2]
+		egrp.Go(func() error {
+			_, err := anrepo.RemoveFromAnnotationGroup(context.Background(), g.GroupId, pair...)
+
+			return err
+		})
+	}
+	for idx := 0; idx < len(extra); idx += 2 {
+		pair := extra[idx : idx+2]
+		egrp.Go(func() error {
+			_, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, pair...)
+
+			return err
+		})
+	}
+	err = egrp.Wait()
+	assert.NoErrorf(err, "expect no error from concurrent updates, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		append(ids[:2], extra...),
+		"should keep every concurrent removal and append",
+	)
+}
+
+func TestAnnotationGroupMemberValidation(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 6)
+	err := anrepo.RemoveAnnotation(context.Background(), ids[5], false)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids[0], "9999999")
+	assert.True(repository.IsAnnotationNotFound(err), "should not allow nonexistent member")
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[5])
+	assert.True(repository.IsObsoleteMember(err), "should not allow obsolete member")
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[:2]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[2], "9999999")
+	assert.True(repository.IsAnnotationNotFound(err), "should not append nonexistent member")
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[2], ids[5])
+	assert.True(repository.IsObsoleteMember(err), "should not append obsolete member")
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		ids[:2],
+		"should leave the group unchanged after rejected appends",
+	)
+}
+
+func TestAddTypedAnnotationGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 5)
+	params := &model.GroupParams{
+		Ontology:    "dicty_annotation",
+		Tag:         "curation",
+		Name:        "curated set",
+		Description: "annotations reviewed together",
+		CreatedBy:   "basu@gmail.com",
+	}
+	_, err := anrepo.AddTypedAnnotationGroup(context.Background(), params, ids[:2]...)
+	assert.True(repository.IsGroupTypeNotFound(err), "should not allow undefined group type")
+	gtp, err := anrepo.SetGroupType(context.Background(), &model.GroupType{
+		Ontology:    "dicty_annotation",
+		Tag:         "curation",
+		AllowedTags: tags[:len(tags)-1],
+		MinMembers:  2,
+		MaxMembers:  4,
+	})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(4), gtp.MaxMembers, "should match the maximum members")
+	_, err = anrepo.SetGroupType(context.Background(), &model.GroupType{Ontology: "dicty_annotation", Tag: "nonexistent"})
+	assert.Error(err, "should not allow group type with undefined tag")
+	_, err = anrepo.AddTypedAnnotationGroup(context.Background(), params, ids...)
+	assert.True(repository.IsGroupRuleViolation(err), "should not allow more than maximum members")
+	note, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("note", "DDB_G0286429"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AddTypedAnnotationGroup(context.Background(), params, ids[0], note.Key)
+	assert.True(repository.IsGroupRuleViolation(err), "should not allow member with disallowed tag")
+	g, err := anrepo.AddTypedAnnotationGroup(context.Background(), params, ids[:3]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(params.Name, g.Name, "should match the group name")
+	assert.Equal(params.Tag, g.GroupType, "should match the group type")
+	assert.Equal(params.Ontology, g.GroupOntology, "should match the group ontology")
+	assert.Equal(params.CreatedBy, g.CreatedBy, "should match the group creator")
+	assert.Lenf(g.AnnoDocs, 3, "should have %d annotations", 3)
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[3:]...)
+	assert.True(repository.IsGroupRuleViolation(err), "should not append beyond maximum members")
+	_, err = anrepo.RemoveFromAnnotationGroup(context.Background(), g.GroupId, ids[:2]...)
+	assert.True(repository.IsGroupRuleViolation(err), "should not remove below minimum members")
+	eg, err := anrepo.EditAnnotationGroup(context.Background(), g.GroupId, "renamed set", "new description", "pfey@gmail.com")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal("renamed set", eg.Name, "should match the updated name")
+	assert.Equal("new description", eg.Description, "should match the updated description")
+	assert.Equal("pfey@gmail.com", eg.UpdatedBy, "should match the updater")
+	assert.Lenf(eg.AnnoDocs, 3, "should have %d annotations", 3)
+	_, err = anrepo.EditAnnotationGroup(context.Background(), "9999999", "name", "", "pfey@gmail.com")
+	assert.True(repository.IsGroupNotFound(err), "should not edit nonexistent group")
+}
+
+func TestClearAnnotationsKeepsGroupTypes(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	_, err := anrepo.SetGroupType(context.Background(), &model.GroupType{Ontology: "dicty_annotation", Tag: "curation"})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	ids := addTestAnnotationsForGroup(t, anrepo, 2)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = anrepo.ClearAnnotations(context.Background())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.GetAnnotationByID(context.Background(), ids[0])
+	assert.True(repository.IsAnnotationNotFound(err), "should remove the annotations")
+	_, err = anrepo.GetGroupType(context.Background(), "dicty_annotation", "curation")
+	assert.NoErrorf(err, "should keep the group type, received %s", err)
+}
+
+func TestAnnotationGroupMemberOrder(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 6)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[1], ids[2], ids[1])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		ids[:3], testModelMaptoID(g.AnnoDocs, model2IdCallback),
+		"should keep members in the given order without duplicates",
+	)
+	eg, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[4], ids[3])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[0], ids[1], ids[2], ids[4], ids[3]},
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		"should append members at the end",
+	)
+	mg, err := anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[3], 0)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[3], ids[0], ids[1], ids[2], ids[4]},
+		testModelMaptoID(mg.AnnoDocs, model2IdCallback),
+		"should move member to the first position",
+	)
+	mg, err = anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[3], 2)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[0], ids[1], ids[3], ids[2], ids[4]},
+		testModelMaptoID(mg.AnnoDocs, model2IdCallback),
+		"should move member to the third position",
+	)
+	mg, err = anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[0], 100)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[1], ids[3], ids[2], ids[4], ids[0]},
+		testModelMaptoID(mg.AnnoDocs, model2IdCallback),
+		"should move member to the end",
+	)
+	_, err = anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[5], 0)
+	assert.True(repository.IsAnnotationNotFound(err), "should not move a non member")
+	_, err = anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[0], -1)
+	assert.Error(err, "should not move to a negative position")
+	rg, err := anrepo.ReorderAnnotationGroup(context.Background(), g.GroupId, ids[4], ids[2])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[4], ids[2], ids[1], ids[3], ids[0]},
+		testModelMaptoID(rg.AnnoDocs, model2IdCallback),
+		"should place reordered members first",
+	)
+	_, err = anrepo.ReorderAnnotationGroup(context.Background(), g.GroupId, ids[5])
+	assert.True(repository.IsAnnotationNotFound(err), "should not reorder a non member")
+	gg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		testModelMaptoID(rg.AnnoDocs, model2IdCallback),
+		testModelMaptoID(gg.AnnoDocs, model2IdCallback),
+		"should retrieve members in their stored order",
+	)
+}
+
+func TestAnnotationGroupVersions(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 5)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[:2]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(1), g.Version, "should start with the first version")
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[2:4]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.MoveAnnotationGroupMember(context.Background(), g.GroupId, ids[3], 0)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	rg, err := anrepo.RemoveFromAnnotationGroup(context.Background(), g.GroupId, ids[0], ids[1])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(4), rg.Version, "should have the fourth version")
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[4], "9999999")
+	assert.Error(err, "should not append nonexistent member")
+	gvl, err := anrepo.ListAnnotationGroupVersions(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(gvl, 4, "should not record rejected changes")
+	for i, act := range []string{"create", "append", "move", "remove"} {
+		assert.Equal(int64(i+1), gvl[i].Version, "should match the version")
+		assert.Equal(act, gvl[i].Action, "should match the action")
+	}
+	assert.Equal(ids[:2], gvl[0].Members, "should match the members of the first version")
+	assert.Equal(
+		[]string{ids[3], ids[0], ids[1], ids[2]},
+		gvl[2].Members,
+		"should match the members after moving",
+	)
+	gv, err := anrepo.GetAnnotationGroupVersion(context.Background(), g.GroupId, 2)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(ids[:4], gv.Members, "should match the members of the second version")
+	_, err = anrepo.GetAnnotationGroupVersion(context.Background(), g.GroupId, 10)
+	assert.True(repository.IsGroupNotFound(err), "should not find nonexistent version")
+}
+
+func TestEditAnnotationInGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 4)
+	g1, err := anrepo.AddAnnotationGroup(context.Background(), ids[0], ids[1], ids[2])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	g2, err := anrepo.AddAnnotationGroup(context.Background(), ids[3], ids[1])
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	um, err := anrepo.EditAnnotation(context.Background(), &annotation.TaggedAnnotationUpdate{
+		Data: &annotation.TaggedAnnotationUpdate_Data{
+			Type: "annotations",
+			Id:   ids[1],
+			Attributes: &annotation.TaggedAnnotationUpdateAttributes{
+				Value:         "edited gene description",
+				EditableValue: "edited gene description",
+				CreatedBy:     "basu@gmail.com",
+			},
+		},
+	})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g1.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(
+		[]string{ids[0], um.Key, ids[2]},
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		"should point to the new version at the same position",
+	)
+	assert.Equal(um.Value, eg.AnnoDocs[1].Value, "should have the edited value")
+	for _, m := range eg.AnnoDocs {
+		assert.False(m.IsObsolete, "should not have obsolete members")
+	}
+	assert.Equal(int64(2), eg.Version, "should have a new group version")
+	gl, err := anrepo.ListGroupsByAnnotation(context.Background(), um.Key)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		[]string{g1.GroupId, g2.GroupId},
+		[]string{gl[0].GroupId, gl[1].GroupId},
+		"should find both groups through the new version",
+	)
+	_, err = anrepo.ListGroupsByAnnotation(context.Background(), ids[1])
+	assert.True(repository.IsAnnotationGroupListNotFound(err), "should not find groups through the old version")
+	gvl, err := anrepo.ListAnnotationGroupVersions(context.Background(), g2.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(gvl, 2, "should record the edit in the group history")
+	assert.Equal("edit", gvl[1].Action, "should match the action")
+	assert.Equal([]string{ids[3], ids[1]}, gvl[0].Members, "should keep the members before the edit")
+	assert.Equal([]string{ids[3], um.Key}, gvl[1].Members, "should have the members after the edit")
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g1.GroupId, ids[3], um.Key)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+}
+
+func TestEditAnnotationInLargeGroup(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 400)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	um, err := anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(ids[200], "edited"))
+	assert.NoErrorf(err, "expect no error editing a member of a large group, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(eg.AnnoDocs, len(ids), "should keep every member")
+	assert.Equal(um.Key, eg.AnnoDocs[200].Key, "should point to the new version")
+	assert.Equal(int64(2), eg.Version, "should have a new group version")
+}
+
+func TestEditAnnotationWithConcurrentGroupWrites(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ids := addTestAnnotationsForGroup(t, anrepo, 22)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[:2]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	egrp := new(errgroup.Group)
+	for idx := 2; idx < len(ids); idx += 2 {
+		pair := ids[idx : idx+2]
+		egrp.Go(func() error {
+			_, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, pair...)
+
+			return err
+		})
+	}
+	um, err := anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(ids[0], "edited"))
+	assert.NoErrorf(err, "expect no conflict from concurrent group writes, received %s", err)
+	err = egrp.Wait()
+	assert.NoErrorf(err, "expect no error from concurrent appends, received %s", err)
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	members := testModelMaptoID(eg.AnnoDocs, model2IdCallback)
+	assert.Contains(members, um.Key, "should have the new version")
+	assert.NotContains(members, ids[0], "should not have the edited version")
+	assert.Len(members, len(ids), "should have every appended annotation")
+}
+
+func newTestAnnotationUpdate(id, value string) *annotation.TaggedAnnotationUpdate {
+	return &annotation.TaggedAnnotationUpdate{
+		Data: &annotation.TaggedAnnotationUpdate_Data{
+			Type: "annotations",
+			Id:   id,
+			Attributes: &annotation.TaggedAnnotationUpdateAttributes{
+				Value:         value,
+				EditableValue: value,
+				CreatedBy:     "basu@gmail.com",
+			},
+		},
+	}
+}
+
+func TestEditAnnotationAtVersion(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotation())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.EditAnnotationAtVersion(context.Background(), newTestAnnotationUpdate(mda.Key, "stale"), mda.Version+1)
+	assert.True(repository.IsAnnotationConflict(err), "should not edit with a wrong version")
+	um, err := anrepo.EditAnnotationAtVersion(context.Background(), newTestAnnotationUpdate(mda.Key, "first"), mda.Version)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(mda.Version+1, um.Version, "version should be incremented by 1")
+	_, err = anrepo.EditAnnotationAtVersion(context.Background(), newTestAnnotationUpdate(mda.Key, "second"), mda.Version)
+	assert.True(repository.IsAnnotationConflict(err), "should not edit a stale version")
+	_, err = anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(mda.Key, "second"))
+	assert.True(repository.IsAnnotationConflict(err), "should not branch the history of an edited version")
+	_, err = anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(um.Key, "second"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+}
+
+func TestConcurrentEditAnnotation(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotation())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	num := 8
+	errc := make(chan error, num)
+	for i := 0; i < num; i++ {
+		value := fmt.Sprintf("concurrent edit %d", i)
+		go func() {
+			_, err := anrepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(mda.Key, value))
+			errc <- err
+		}()
+	}
+	var success int
+	for i := 0; i < num; i++ {
+		err := <-errc
+		if err == nil {
+			success++
+
+			continue
+		}
+		assert.Truef(
+			repository.IsAnnotationConflict(err),
+			"expect conflict error, received %s", err,
+		)
+	}
+	assert.Equal(1, success, "should allow only one successor version")
+	count, err := anrepo.Dbh().CountWithParams(
+		"FOR e IN annotation_version FILTER e._from == @from RETURN e",
+		map[string]interface{}{"from": mda.ID.String()},
+	)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Equal(int64(1), count, "should have a single version edge from the parent")
+}
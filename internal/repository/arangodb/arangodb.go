@@ -3,6 +3,7 @@ package arangodb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	driver "github.com/arangodb/go-driver"
 	manager "github.com/dictyBase/arangomanager"
@@ -16,15 +17,23 @@ type annoc struct {
 	term   driver.Collection
 	ver    driver.Collection
 	annog  driver.Collection
+	annogt driver.Collection
+	annogv driver.Collection
+	audit  driver.Collection
 	verg   driver.Graph
 	annotg driver.Graph
 }
 
+// defaultQueryRuntime is the maximum runtime of a query on the server when
+// its context has no deadline.
+const defaultQueryRuntime = time.Minute
+
 type arangorepository struct {
-	sess     *manager.Session
-	database *manager.Database
-	anno     *annoc
-	onto     *ontoarango.OntoCollection
+	sess         *manager.Session
+	database     *manager.Database
+	anno         *annoc
+	onto         *ontoarango.OntoCollection
+	queryRuntime time.Duration
 }
 
 func NewTaggedAnnotationRepo(
@@ -45,10 +54,11 @@ func NewTaggedAnnotationRepo(
 	annoc, err := setAnnotationCollection(dbh, ontoc, collP)
 
 	return &arangorepository{
-		sess:     sess,
-		database: dbh,
-		onto:     ontoc,
-		anno:     annoc,
+		sess:         sess,
+		database:     dbh,
+		onto:         ontoc,
+		anno:         annoc,
+		queryRuntime: defaultQueryRuntime,
 	}, err
 }
 
@@ -85,6 +95,30 @@ func setAnnotationCollection(dbh *manager.Database, onto *ontoarango.OntoCollect
 	}
 	annoc.verg = verg
 	annoc.annotg = annotg
+	_, _, err = dbh.EnsurePersistentIndex(
+		annoc.annog.Name(),
+		[]string{"group[*]"},
+		&driver.EnsurePersistentIndexOptions{
+			InBackground: true,
+		},
+	)
+	if err != nil {
+		return annoc, fmt.Errorf("error in creating index %s", err)
+	}
+	_, _, err = dbh.EnsurePersistentIndex(
+		annoc.annogv.Name(),
+		[]string{"group_id", "version"},
+		&driver.EnsurePersistentIndexOptions{
+			InBackground: true,
+			Unique:       true,
+		},
+	)
+	if err != nil {
+		return annoc, fmt.Errorf("error in creating index %s", err)
+	}
+	if err := ensureAuditIndexes(dbh, annoc.audit); err != nil {
+		return annoc, err
+	}
 	_, _, err = dbh.EnsurePersistentIndex(
 		annoc.annot.Name(),
 		collP.AnnoIndexes,
@@ -112,6 +146,27 @@ func setDocumentCollection(dbh *manager.Database, collP *CollectionParams) (*ann
 	if err != nil {
 		return anns, fmt.Errorf("error in finding or creating collection %s", err)
 	}
+	annogrpt, err := dbh.FindOrCreateCollection(
+		collP.AnnoGroupType,
+		&driver.CreateCollectionOptions{},
+	)
+	if err != nil {
+		return anns, fmt.Errorf("error in finding or creating collection %s", err)
+	}
+	annogrpv, err := dbh.FindOrCreateCollection(
+		collP.AnnoGroupVersion,
+		&driver.CreateCollectionOptions{},
+	)
+	if err != nil {
+		return anns, fmt.Errorf("error in finding or creating collection %s", err)
+	}
+	annoaudit, err := dbh.FindOrCreateCollection(
+		collP.AnnoAudit,
+		&driver.CreateCollectionOptions{},
+	)
+	if err != nil {
+		return anns, fmt.Errorf("error in finding or creating collection %s", err)
+	}
 	annocvt, err := dbh.FindOrCreateCollection(
 		collP.AnnoTerm,
 		&driver.CreateCollectionOptions{Type: driver.CollectionTypeEdge},
@@ -125,28 +180,52 @@ func setDocumentCollection(dbh *manager.Database, collP *CollectionParams) (*ann
 	)
 
 	return &annoc{
-		annot: anno,
-		annog: annogrp,
-		term:  annocvt,
-		ver:   annov,
+		annot:  anno,
+		annog:  annogrp,
+		annogt: annogrpt,
+		annogv: annogrpv,
+		audit:  annoaudit,
+		term:   annocvt,
+		ver:    annov,
 	}, err
 }
 
+func ensureAuditIndexes(dbh *manager.Database, audit driver.Collection) error {
+	for _, fields := range [][]string{
+		{"actor", "created_at"},
+		{"entry_ids[*]"},
+		{"created_at"},
+	} {
+		_, _, err := dbh.EnsurePersistentIndex(
+			audit.Name(),
+			fields,
+			&driver.EnsurePersistentIndexOptions{
+				InBackground: true,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("error in creating index %s", err)
+		}
+	}
+
+	return nil
+}
+
 // Clear clears all annotations and related ontologies from the repository
 // datasource.
-func (ar *arangorepository) Clear() error {
-	if err := ar.ClearAnnotations(); err != nil {
+func (ar *arangorepository) Clear(ctx context.Context) error {
+	if err := ar.ClearAnnotations(ctx); err != nil {
 		return err
 	}
 	for _, c := range []driver.Collection{
-		ar.onto.Term, ar.onto.Cv, ar.onto.Rel,
+		ar.anno.annogt, ar.onto.Term, ar.onto.Cv, ar.onto.Rel,
 	} {
-		if err := c.Truncate(context.Background()); err != nil {
+		if err := c.Truncate(ctx); err != nil {
 			return fmt.Errorf("error in truncating %s", err)
 		}
 	}
 
-	err := ar.onto.Obog.Remove(context.Background())
+	err := ar.onto.Obog.Remove(ctx)
 	if err != nil {
 		return fmt.Errorf("error in removing graph %s", err)
 	}
@@ -154,12 +233,15 @@ func (ar *arangorepository) Clear() error {
 	return nil
 }
 
-// ClearAnnotations clears all annotations from the repository datasource.
-func (ar *arangorepository) ClearAnnotations() error {
+// ClearAnnotations clears all annotations and groups from the repository
+// datasource. The group types are configuration and are kept along with the
+// ontologies, the audit log is never cleared.
+func (ar *arangorepository) ClearAnnotations(ctx context.Context) error {
 	for _, c := range []driver.Collection{
-		ar.anno.annot, ar.anno.ver, ar.anno.term, ar.anno.annog,
+		ar.anno.annot, ar.anno.ver, ar.anno.term,
+		ar.anno.annog, ar.anno.annogv,
 	} {
-		if err := c.Truncate(context.Background()); err != nil {
+		if err := c.Truncate(ctx); err != nil {
 			return fmt.Errorf("error in truncating %s", err)
 		}
 	}
@@ -168,14 +250,14 @@ func (ar *arangorepository) ClearAnnotations() error {
 		ar.anno.annotg,
 	} {
 		arangoDb := ar.database.Handler()
-		isok, err := arangoDb.GraphExists(context.Background(), grph.Name())
+		isok, err := arangoDb.GraphExists(ctx, grph.Name())
 		if err != nil {
 			return fmt.Errorf("error in checking existence of graph %s", err)
 		}
 		if !isok {
 			continue
 		}
-		if err := grph.Remove(context.Background()); err != nil {
+		if err := grph.Remove(ctx); err != nil {
 			return fmt.Errorf("error in removing graph %s", err)
 		}
 	}
@@ -197,6 +279,16 @@ func DocumentsExists(c driver.Collection, ids ...string) error {
 	return nil
 }
 
+// Close drops the session. There is nothing else to release, the session
+// only holds the http transport that arangomanager creates for the driver
+// and keeps unexported, whose idle connections are closed by the transport
+// after 90 seconds of inactivity or when the process exits.
+func (ar *arangorepository) Close() error {
+	ar.sess = nil
+
+	return nil
+}
+
 func (ar *arangorepository) Dbh() *manager.Database {
 	return ar.database
 }
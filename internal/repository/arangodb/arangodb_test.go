@@ -2,6 +2,7 @@ package arangodb
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -66,13 +67,16 @@ func getConnectParamsFromDb(tra *testarango.TestArango) *manager.ConnectParams {
 
 func getCollectionParams() *CollectionParams {
 	return &CollectionParams{
-		Annotation:   "annotation",
-		AnnoTerm:     "annotation_cvterm",
-		AnnoVersion:  "annotation_version",
-		AnnoTagGraph: "annotation_tag",
-		AnnoVerGraph: "annotation_history",
-		AnnoGroup:    "annotation_group",
-		AnnoIndexes:  []string{"entry_id"},
+		Annotation:       "annotation",
+		AnnoTerm:         "annotation_cvterm",
+		AnnoVersion:      "annotation_version",
+		AnnoTagGraph:     "annotation_tag",
+		AnnoVerGraph:     "annotation_history",
+		AnnoGroup:        "annotation_group",
+		AnnoGroupType:    "annotation_group_type",
+		AnnoGroupVersion: "annotation_group_version",
+		AnnoAudit:        "annotation_audit",
+		AnnoIndexes:      []string{"entry_id"},
 	}
 }
 
@@ -245,7 +249,7 @@ func newTestTaggedAnnotationsList(num int) []*annotation.NewTaggedAnnotation {
 	return nal
 }
 
-func setUp(t *testing.T) (*require.Assertions, repository.TaggedAnnotationRepository) {
+func setUp(t testing.TB) (*require.Assertions, repository.TaggedAnnotationRepository) {
 	t.Helper()
 	tra, err := testarango.NewTestArangoFromEnv(true)
 	if err != nil {
@@ -275,7 +279,7 @@ func TestLoadOboJSON(t *testing.T) {
 	fh, err := oboReader()
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	defer fh.Close()
-	info, err := anrepo.LoadOboJSON(bufio.NewReader(fh))
+	info, err := anrepo.LoadOboJSON(context.Background(), bufio.NewReader(fh))
 	assert.NoErrorf(err, "expect no error, received %s", err)
 	assert.True(info.IsCreated, "should match created status")
 }
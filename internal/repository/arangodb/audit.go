@@ -0,0 +1,92 @@
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+)
+
+// AddAuditEntry appends an entry to the audit log. The log has no update
+// or removal counterpart and is left untouched by Clear.
+func (ar *arangorepository) AddAuditEntry(
+	ctx context.Context,
+	entry *model.AuditEntry,
+) (*model.AuditEntry, error) {
+	aent := &model.AuditEntry{}
+	keys := entry.Keys
+	if keys == nil {
+		keys = make([]string, 0)
+	}
+	entryIds := entry.EntryIds
+	if entryIds == nil {
+		entryIds = make([]string, 0)
+	}
+	res, err := ar.doRun(
+		ctx,
+		"auditInst", auditInst,
+		map[string]interface{}{
+			"@audit_collection": ar.anno.audit.Name(),
+			"operation":         entry.Operation,
+			"actor":             entry.Actor,
+			"digest":            entry.Digest,
+			"keys":              keys,
+			"entry_ids":         entryIds,
+		})
+	if err != nil {
+		return aent, fmt.Errorf("error in adding audit entry %s", err)
+	}
+	if err := res.Read(aent); err != nil {
+		return aent, fmt.Errorf("error in reading data to structure %s", err)
+	}
+
+	return aent, nil
+}
+
+// ListAuditEntries retrieves the audit entries, newest first and by key
+// within the same millisecond, after the cursor entry. Like the other
+// lists, one more entry than the limit is returned to tell whether another
+// page exists.
+func (ar *arangorepository) ListAuditEntries(
+	ctx context.Context,
+	filter *model.AuditFilter,
+) ([]*model.AuditEntry, error) {
+	entries := make([]*model.AuditEntry, 0)
+	rs, err := ar.searchRows(
+		ctx,
+		"auditListQ", auditListQ,
+		map[string]interface{}{
+			"@audit_collection": ar.anno.audit.Name(),
+			"actor":             filter.Actor,
+			"entry_id":          filter.EntryId,
+			"since":             toMillis(filter.Since),
+			"until":             toMillis(filter.Until),
+			"cursor":            filter.Cursor,
+			"cursor_key":        filter.CursorKey,
+			"limit":             filter.Limit + 1,
+		})
+	if err != nil {
+		return entries, fmt.Errorf("error in searching audit entries %s", err)
+	}
+	if rs.IsEmpty() {
+		return entries, nil
+	}
+	for rs.Scan() {
+		aent := &model.AuditEntry{}
+		if err := rs.Read(aent); err != nil {
+			return entries, fmt.Errorf("error in reading data to structure %s", err)
+		}
+		entries = append(entries, aent)
+	}
+
+	return entries, nil
+}
+
+func toMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
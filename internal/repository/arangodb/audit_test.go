@@ -0,0 +1,91 @@
+package arangodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository/audit"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	start := time.Now().Add(-time.Minute)
+	aurepo := audit.NewTaggedAnnotationRepo(anrepo, logrus.NewEntry(logrus.New()))
+	mda, err := aurepo.AddAnnotation(context.Background(), newTestTaggedAnnotation())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = aurepo.AddAnnotation(context.Background(), newTestTaggedAnnotationWithParams("curation", "DDB_G0286429"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	uma, err := aurepo.EditAnnotation(context.Background(), newTestAnnotationUpdate(mda.Key, "edited"))
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = aurepo.RemoveAnnotationBy(
+		context.Background(),
+		uma.Key, false,
+		&model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "wrong gene"},
+	)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	err = aurepo.ClearAnnotations(context.Background())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	all, err := anrepo.ListAuditEntries(context.Background(), &model.AuditFilter{Limit: 10})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(all, 5, "should record every mutation even after clearing annotations")
+	assert.Equal("ClearAnnotations", all[0].Operation, "should list the newest entry first")
+	assert.Equal("AddAnnotation", all[4].Operation, "should list the oldest entry last")
+	assert.Len(all[4].Digest, 64, "should record the sha256 digest of the request")
+	bya, err := anrepo.ListAuditEntries(context.Background(), &model.AuditFilter{Actor: "pfey@gmail.com", Limit: 10})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(bya, 1, "should filter by actor")
+	assert.Equal("RemoveAnnotation", bya[0].Operation, "should match the operation")
+	assert.Equal([]string{uma.Key}, bya[0].Keys, "should match the affected keys")
+	bye, err := anrepo.ListAuditEntries(context.Background(), &model.AuditFilter{EntryId: "DDB_G0267474", Limit: 10})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(bye, 3, "should filter by entry id")
+	assert.ElementsMatch([]string{mda.Key, uma.Key}, bye[1].Keys, "should record both versions of the edit")
+	byd, err := anrepo.ListAuditEntries(context.Background(), &model.AuditFilter{Since: start, Until: start.Add(time.Second), Limit: 10})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Empty(byd, "should filter by time")
+	page, err := anrepo.ListAuditEntries(context.Background(), &model.AuditFilter{Limit: 2})
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(page, 3, "should return one more than the limit")
+}
+
+func TestAuditLogSameTimestamp(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	arp, ok := anrepo.(*arangorepository)
+	assert.True(ok, "should be an arangodb repository")
+	for i := 0; i < 5; i++ {
+		_, err := arp.anno.audit.CreateDocument(context.Background(), map[string]interface{}{
+			"operation":  "AddAnnotation",
+			"actor":      "pfey@gmail.com",
+			"keys":       []string{},
+			"entry_ids":  []string{},
+			"created_at": "2021-06-01T10:00:00.000Z",
+		})
+		assert.NoErrorf(err, "expect no error, received %s", err)
+	}
+	filter := &model.AuditFilter{Limit: 2}
+	seen := make(map[string]bool)
+	for pages := 0; pages < 5; pages++ {
+		entries, err := anrepo.ListAuditEntries(context.Background(), filter)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		page := entries
+		if int64(len(entries)) > filter.Limit {
+			page = entries[:filter.Limit]
+		}
+		for _, ent := range page {
+			assert.Falsef(seen[ent.Key], "should not list entry %s twice", ent.Key)
+			seen[ent.Key] = true
+		}
+		if int64(len(entries)) <= filter.Limit {
+			break
+		}
+		filter.Cursor, filter.CursorKey = model.AuditCursor(page[len(page)-1])
+	}
+	assert.Len(seen, 5, "should list every entry sharing the timestamp once")
+}
@@ -0,0 +1,116 @@
+package arangodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dictyBase/arangomanager/testarango"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+)
+
+func TestCancelledContext(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := anrepo.AddAnnotation(ctx, newTestTaggedAnnotation())
+	assert.Error(err, "expect error with a cancelled context")
+	assert.Contains(err.Error(), context.Canceled.Error(), "should report the cancellation")
+	_, err = anrepo.ListAnnotations(ctx, 0, 10, "")
+	assert.Error(err, "expect error with a cancelled context")
+	_, err = anrepo.ApplyBatch(
+		ctx,
+		[]*model.BatchOperation{newTestBatchCreate("a", "curation", "DDB_G0286429")},
+	)
+	assert.Error(err, "expect error with a cancelled context")
+	_, err = anrepo.ListAnnotations(context.Background(), 0, 10, "")
+	assert.True(repository.IsAnnotationListNotFound(err), "should not create any annotation")
+}
+
+func TestQueryDeadline(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	arp, ok := anrepo.(*arangorepository)
+	assert.True(ok, "expect an arangodb repository")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := arp.getRow(ctx, "sleepQ", "RETURN SLEEP(30)", nil)
+	assert.Error(err, "expect error when the deadline is exceeded")
+	assert.Less(time.Since(start), 10*time.Second, "should abort the query at the deadline")
+}
+
+func TestCancelledQuery(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	arp, ok := anrepo.(*arangorepository)
+	assert.True(ok, "expect an arangodb repository")
+	arp.queryRuntime = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := arp.getRow(ctx, "sleepQ", "RETURN SLEEP(30)", nil)
+	assert.Error(err, "expect error with a cancelled context")
+	assert.Less(time.Since(start), 10*time.Second, "should abort the request at the cancel")
+	assert.Eventually(func() bool {
+		running, err := runningQueries(arp)
+		assert.NoErrorf(err, "expect no error from listing the queries, received %s", err)
+		for _, qry := range running {
+			if strings.Contains(qry, "SLEEP") {
+				return false
+			}
+		}
+
+		return true
+	}, 10*time.Second, 200*time.Millisecond, "should stop the query on the server")
+}
+
+// runningQueries lists the queries the server is running in the database
+// of the repository.
+func runningQueries(arp *arangorepository) ([]string, error) {
+	var running []string
+	tra, err := testarango.NewTestArangoFromEnv(false)
+	if err != nil {
+		return running, err
+	}
+	req, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf(
+			"http://%s:%d/_db/%s/_api/query/current",
+			tra.Host, tra.Port, arp.database.Handler().Name(),
+		),
+		nil,
+	)
+	if err != nil {
+		return running, fmt.Errorf("error in creating request %s", err)
+	}
+	req.SetBasicAuth(tra.User, tra.Pass)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return running, fmt.Errorf("error in listing queries %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return running, fmt.Errorf("error in listing queries, status %d", resp.StatusCode)
+	}
+	var queries []struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&queries); err != nil {
+		return running, fmt.Errorf("error in decoding queries %s", err)
+	}
+	for _, qry := range queries {
+		running = append(running, qry.Query)
+	}
+
+	return running, nil
+}
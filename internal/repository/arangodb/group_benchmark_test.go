@@ -0,0 +1,75 @@
+package arangodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+)
+
+const benchGroupSize = 200
+
+func setUpBenchGroup(
+	b *testing.B,
+) (repository.TaggedAnnotationRepository, *model.AnnoGroup) {
+	b.Helper()
+	assert, anrepo := setUp(b)
+	tal := newTestTaggedAnnotationsList(benchGroupSize)
+	mla := make([]*model.AnnoDoc, 0)
+	for _, ann := range tal {
+		m, err := anrepo.AddAnnotation(context.Background(), ann)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		mla = append(mla, m)
+	}
+	g, err := anrepo.AddAnnotationGroup(
+		context.Background(),
+		testModelMaptoID(mla, model2IdCallback)...,
+	)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+
+	return anrepo, g
+}
+
+// BenchmarkGetAnnotationGroup hydrates all group members with a single
+// query.
+func BenchmarkGetAnnotationGroup(b *testing.B) {
+	anrepo, g := setUpBenchGroup(b)
+	defer tearDown(anrepo)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId); err != nil {
+			b.Fatalf("error in retrieving group %s", err)
+		}
+	}
+}
+
+// BenchmarkGetAnnotationGroupPerMember hydrates all group members with one
+// query per member, the way groups were read before.
+func BenchmarkGetAnnotationGroupPerMember(b *testing.B) {
+	anrepo, g := setUpBenchGroup(b)
+	defer tearDown(anrepo)
+	ids := testModelMaptoID(g.AnnoDocs, model2IdCallback)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			if _, err := anrepo.GetAnnotationByID(context.Background(), id); err != nil {
+				b.Fatalf("error in retrieving annotation %s", err)
+			}
+		}
+	}
+}
+
+// BenchmarkAppendToAnnotationGroup validates and appends members and
+// hydrates the group in a single round trip.
+func BenchmarkAppendToAnnotationGroup(b *testing.B) {
+	anrepo, g := setUpBenchGroup(b)
+	defer tearDown(anrepo)
+	ids := testModelMaptoID(g.AnnoDocs, model2IdCallback)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, ids[:2]...); err != nil {
+			b.Fatalf("error in appending to group %s", err)
+		}
+	}
+}
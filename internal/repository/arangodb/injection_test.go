@@ -0,0 +1,146 @@
+package arangodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+)
+
+var hostileInputs = []string{
+	`DDB_G0267474'`,
+	`DDB_G0267474' || true || '`,
+	`x' RETURN ann //`,
+	`\' FILTER true RETURN 1 //`,
+	`\\`,
+	`"; REMOVE ann IN annotation //`,
+	`' FOR a IN annotation REMOVE a IN annotation RETURN '`,
+	`@@anno_collection`,
+}
+
+func addHostileTestAnnotations(
+	t *testing.T,
+	anrepo repository.TaggedAnnotationRepository,
+) []*model.AnnoDoc {
+	t.Helper()
+	mla := make([]*model.AnnoDoc, 0)
+	for _, hin := range hostileInputs {
+		nta := newTestTaggedAnnotationWithParams("curation", hin)
+		nta.Data.Attributes.Value = hin
+		nta.Data.Attributes.EditableValue = hin
+		nta.Data.Attributes.CreatedBy = hin
+		m, err := anrepo.AddAnnotation(context.Background(), nta)
+		if err != nil {
+			t.Fatalf("expect no error in adding annotation with %s, received %s", hin, err)
+		}
+		mla = append(mla, m)
+	}
+
+	return mla
+}
+
+func TestHostileInputRead(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mla := addHostileTestAnnotations(t, anrepo)
+	for idx, hin := range hostileInputs {
+		m, err := anrepo.GetAnnotationByID(context.Background(), mla[idx].Key)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.Equal(m.Value, hin, "should store the value verbatim")
+		assert.Equal(m.EnrtyId, hin, "should store the entry id verbatim")
+		assert.Equal(m.CreatedBy, hin, "should store created by verbatim")
+		em, err := anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
+			Tag:      "curation",
+			Ontology: "dicty_annotation",
+			EntryId:  hin,
+		})
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.Equal(em.Key, mla[idx].Key, "should match the annotation")
+		prof, err := anrepo.GetEntryProfile(context.Background(), hin)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.Len(prof.Tags, 1, "should have a single tag")
+		assert.Len(prof.Tags[0].AnnoDocs, 1, "should have only the matching annotation")
+		_, err = anrepo.GetAnnotationByID(context.Background(), hin)
+		assert.Error(err, "expect error for hostile identifier")
+		_, err = anrepo.GetAnnotationByEntry(context.Background(), &annotation.EntryAnnotationRequest{
+			Tag:      hin,
+			Ontology: hin,
+			EntryId:  hin,
+		})
+		assert.True(repository.IsAnnotationNotFound(err), "should not match hostile tag")
+		_, err = anrepo.GetAnnotationTag(context.Background(), hin, hin)
+		assert.True(repository.IsAnnoTagNotFound(err), "should not match hostile tag")
+		_, err = anrepo.GetAnnotationGroup(context.Background(), hin)
+		assert.Error(err, "expect error for hostile group identifier")
+	}
+	mll, err := anrepo.ListAnnotations(context.Background(), 0, 20, "")
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.Len(mll, len(hostileInputs), "should have all annotations intact")
+}
+
+func TestHostileInputWrite(t *testing.T) {
+	t.Parallel()
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	mla := addHostileTestAnnotations(t, anrepo)
+	for idx, hin := range hostileInputs {
+		nta := newTestAnnoWithTagAndOnto(hin, hin)
+		_, err := anrepo.AddAnnotation(context.Background(), nta)
+		assert.Error(err, "expect error for hostile tag and ontology")
+		um, err := anrepo.EditAnnotation(context.Background(), &annotation.TaggedAnnotationUpdate{
+			Data: &annotation.TaggedAnnotationUpdate_Data{
+				Type: "annotations",
+				Id:   mla[idx].Key,
+				Attributes: &annotation.TaggedAnnotationUpdateAttributes{
+					Value:         hin + hin,
+					EditableValue: hin + hin,
+					CreatedBy:     hin,
+				},
+			},
+		})
+		assert.NoErrorf(err, "expect no error, received %s", err)
+		assert.Equal(um.Value, hin+hin, "should store the updated value verbatim")
+		mla[idx] = um
+		_, err = anrepo.EditAnnotation(context.Background(), &annotation.TaggedAnnotationUpdate{
+			Data: &annotation.TaggedAnnotationUpdate_Data{
+				Type: "annotations",
+				Id:   hin,
+				Attributes: &annotation.TaggedAnnotationUpdateAttributes{
+					Value:         hin,
+					EditableValue: hin,
+					CreatedBy:     hin,
+				},
+			},
+		})
+		assert.Error(err, "expect error for hostile identifier")
+		err = anrepo.RemoveAnnotation(context.Background(), hin, false)
+		assert.Error(err, "expect error for hostile identifier")
+	}
+	ids := testModelMaptoID(mla, model2IdCallback)
+	g, err := anrepo.AddAnnotationGroup(context.Background(), ids[:4]...)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.AddAnnotationGroup(context.Background(), hostileInputs...)
+	assert.Error(err, "expect error for hostile group members")
+	_, err = anrepo.AppendToAnnotationGroup(context.Background(), g.GroupId, hostileInputs...)
+	assert.Error(err, "expect error for hostile group members")
+	_, err = anrepo.RemoveFromAnnotationGroup(context.Background(), hostileInputs[0], ids[:2]...)
+	assert.Error(err, "expect error for hostile group identifier")
+	err = anrepo.RemoveAnnotationGroup(context.Background(), hostileInputs[1])
+	assert.Error(err, "expect error for hostile group identifier")
+	eg, err := anrepo.GetAnnotationGroup(context.Background(), g.GroupId)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	assert.ElementsMatch(
+		testModelMaptoID(eg.AnnoDocs, model2IdCallback),
+		ids[:4],
+		"should keep the group intact",
+	)
+	for _, m := range mla {
+		err := anrepo.RemoveAnnotation(context.Background(), m.Key, false)
+		assert.NoErrorf(err, "expect no error, received %s", err)
+	}
+	_, err = anrepo.ListAnnotations(context.Background(), 0, 20, "")
+	assert.True(repository.IsAnnotationListNotFound(err), "should have removed all annotations")
+}
@@ -1,6 +1,7 @@
 package arangodb
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -8,7 +9,12 @@ import (
 	ontoarango "github.com/dictyBase/go-obograph/storage/arangodb"
 )
 
-func (ar *arangorepository) LoadOboJSON(rde io.Reader) (*storage.UploadInformation, error) {
+// LoadOboJSON loads an ontology, the obograph storage has no support for
+// contexts so the context is only checked before the loading starts.
+func (ar *arangorepository) LoadOboJSON(ctx context.Context, rde io.Reader) (*storage.UploadInformation, error) {
+	if err := ctx.Err(); err != nil {
+		return &storage.UploadInformation{}, fmt.Errorf("error in uploading JSON %s", err)
+	}
 	dsb, err := ontoarango.NewDataSourceFromDb(ar.database, &ontoarango.CollectionParams{
 		OboGraph:     ar.onto.Obog.Name(),
 		GraphInfo:    ar.onto.Cv.Name(),
@@ -27,9 +33,9 @@ func (ar *arangorepository) LoadOboJSON(rde io.Reader) (*storage.UploadInformati
 	return info, nil
 }
 
-func (ar *arangorepository) termID(onto, term string) (string, error) {
+func (ar *arangorepository) termID(ctx context.Context, onto, term string) (string, error) {
 	var tid string
-	row, err := ar.database.GetRow(annExistTagQ, map[string]interface{}{
+	row, err := ar.getRow(ctx, "annExistTagQ", annExistTagQ, map[string]interface{}{
 		"@cv_collection":     ar.onto.Cv.Name(),
 		"@cvterm_collection": ar.onto.Term.Name(),
 		"ontology":           onto,
@@ -48,9 +54,9 @@ func (ar *arangorepository) termID(onto, term string) (string, error) {
 	return tid, nil
 }
 
-func (ar *arangorepository) termName(tid string) (string, error) {
+func (ar *arangorepository) termName(ctx context.Context, tid string) (string, error) {
 	var name string
-	cvtr, err := ar.database.GetRow(cvtID2LblQ, map[string]interface{}{
+	cvtr, err := ar.getRow(ctx, "cvtID2LblQ", cvtID2LblQ, map[string]interface{}{
 		"@cvterm_collection": ar.onto.Term.Name(),
 		"id":                 tid,
 	})
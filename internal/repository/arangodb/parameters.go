@@ -1,6 +1,9 @@
 package arangodb
 
-import "github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+import (
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/model"
+)
 
 type createParams struct {
 	attr *annotation.NewTaggedAnnotationAttributes
@@ -8,6 +11,37 @@ type createParams struct {
 	tag  string
 }
 
+// groupResult is the outcome of a group modification query.
+type groupResult struct {
+	model.AnnoGroup
+	// Exists is false when the group to modify is absent
+	Exists bool `json:"exists"`
+	// Missing are the requested member identifiers that do not exist
+	Missing []string `json:"missing"`
+	// Obsolete are the requested member identifiers that are obsolete
+	Obsolete []string `json:"obsolete"`
+	// TypeMissing is true when the group type is not defined
+	TypeMissing bool `json:"type_missing"`
+	// Disallowed are the member identifiers whose tags are not allowed by
+	// the group type
+	Disallowed []string `json:"disallowed"`
+	// Size is the number of members after the modification
+	Size       int64 `json:"size"`
+	MinMembers int64 `json:"min_members"`
+	MaxMembers int64 `json:"max_members"`
+}
+
+// restoreResult is the outcome of restoring an obsolete annotation.
+type restoreResult struct {
+	Exists     bool `json:"exists"`
+	IsObsolete bool `json:"is_obsolete"`
+	// Successor is the newer version of the annotation, if any
+	Successor string `json:"successor"`
+	// Live is the annotation occupying the same slot, if any
+	Live       string         `json:"live"`
+	Annotation *model.AnnoDoc `json:"annotation"`
+}
+
 // CollectionParams are the arangodb collections required for storing
 // annotations.
 type CollectionParams struct {
@@ -15,6 +49,14 @@ type CollectionParams struct {
 	Annotation string `validate:"required"`
 	// AnnoGroup is the collection for grouping annotations
 	AnnoGroup string `validate:"required"`
+	// AnnoGroupType is the collection for the types of annotation groups
+	AnnoGroupType string `validate:"required"`
+	// AnnoGroupVersion is the collection for the membership history of
+	// annotation groups
+	AnnoGroupVersion string `validate:"required"`
+	// AnnoAudit is the append only collection recording every mutation
+	// of the repository
+	AnnoAudit string `validate:"required"`
 	// AnnoTerm is the edge collection annotation with a named tag(ontology
 	// term)
 	AnnoTerm string `validate:"required"`
@@ -0,0 +1,187 @@
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// result is a cursor for a single row of data. It stands in for the result
+// of arangomanager, whose queries are not bound to the context of a request.
+type result struct {
+	ctx    context.Context
+	cursor driver.Cursor
+	empty  bool
+}
+
+// IsEmpty checks for empty result.
+func (r *result) IsEmpty() bool {
+	return r.empty
+}
+
+// Read reads the row of data to iface and closes the cursor.
+func (r *result) Read(iface interface{}) error {
+	defer r.cursor.Close()
+	if _, err := r.cursor.ReadDocument(r.ctx, iface); err != nil {
+		return fmt.Errorf("error in reading document %s", err)
+	}
+
+	return nil
+}
+
+// resultset is a cursor for multiple rows of result.
+type resultset struct {
+	ctx    context.Context
+	cursor driver.Cursor
+	empty  bool
+}
+
+// IsEmpty checks for empty resultset.
+func (r *resultset) IsEmpty() bool {
+	return r.empty
+}
+
+// Scan advances the resultset to the next row of data, it is true for an
+// empty resultset, so check IsEmpty first.
+func (r *resultset) Scan() bool {
+	if r.empty {
+		return r.empty
+	}
+	if r.cursor.HasMore() {
+		return true
+	}
+	r.cursor.Close()
+
+	return false
+}
+
+// Read reads the row of data to iface.
+func (r *resultset) Read(iface interface{}) error {
+	if _, err := r.cursor.ReadDocument(r.ctx, iface); err != nil {
+		return fmt.Errorf("error in reading document %s", err)
+	}
+
+	return nil
+}
+
+// Close closes the resultset.
+func (r *resultset) Close() error {
+	if r.empty {
+		return nil
+	}
+	if err := r.cursor.Close(); err != nil {
+		return fmt.Errorf("error in closing cursor %s", err)
+	}
+
+	return nil
+}
+
+// queryContext bounds the runtime of a query on the server by the deadline
+// of the context, or by the maximum runtime of the repository without one.
+// Cancelling the context only aborts the request of the client, so the
+// server always needs a bound to stop a query nobody waits for anymore.
+func (ar *arangorepository) queryContext(ctx context.Context) context.Context {
+	runtime := ar.queryRuntime
+	if dln, ok := ctx.Deadline(); ok {
+		runtime = time.Until(dln)
+	}
+
+	return driver.WithQueryMaxRuntime(ctx, runtime.Seconds())
+}
+
+// query runs an aql query in a span named after the query. The context
+// aborts the query while it is running and while its cursor is read.
+func (ar *arangorepository) query(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) (context.Context, driver.Cursor, error) {
+	ctx, span := ar.startSpan(ctx, "aql", name)
+	cursor, err := ar.database.Handler().Query(ar.queryContext(ctx), query, bindVars)
+	endSpan(span, err)
+
+	return ctx, cursor, err
+}
+
+// getRow runs a query that is expected to return a single row of result.
+func (ar *arangorepository) getRow(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) (*result, error) {
+	ctx, cursor, err := ar.query(ctx, name, query, bindVars)
+	if err != nil {
+		return &result{empty: true}, fmt.Errorf("error in query %s", err)
+	}
+	if !cursor.HasMore() {
+		cursor.Close()
+
+		return &result{empty: true}, nil
+	}
+
+	return &result{ctx: ctx, cursor: cursor}, nil
+}
+
+// doRun runs a data modification query that is expected to return a
+// result. It is an alias for getRow.
+func (ar *arangorepository) doRun(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) (*result, error) {
+	return ar.getRow(ctx, name, query, bindVars)
+}
+
+// do runs a data modification query that is not expected to return any
+// result.
+func (ar *arangorepository) do(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) error {
+	_, cursor, err := ar.query(driver.WithSilent(ctx), name, query, bindVars)
+	if err != nil {
+		return fmt.Errorf("error in data modification query %s", err)
+	}
+	cursor.Close()
+
+	return nil
+}
+
+// searchRows runs a query that is expected to return multiple rows of
+// result.
+func (ar *arangorepository) searchRows(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) (*resultset, error) {
+	ctx, cursor, err := ar.query(ctx, name, query, bindVars)
+	if err != nil {
+		return &resultset{empty: true}, fmt.Errorf("error in running search %s", err)
+	}
+	if !cursor.HasMore() {
+		cursor.Close()
+
+		return &resultset{empty: true}, nil
+	}
+
+	return &resultset{ctx: ctx, cursor: cursor}, nil
+}
+
+// countWithParams runs a query that is expected to return the count of
+// its result.
+func (ar *arangorepository) countWithParams(
+	ctx context.Context,
+	name, query string,
+	bindVars map[string]interface{},
+) (int64, error) {
+	_, cursor, err := ar.query(driver.WithQueryCount(ctx, true), name, query, bindVars)
+	if err != nil {
+		return 0, fmt.Errorf("error with query %s", err)
+	}
+	defer cursor.Close()
+
+	return cursor.Count(), nil
+}
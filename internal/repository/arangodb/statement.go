@@ -15,6 +15,36 @@ const (
 					ontology: cv.metadata.namespace
 				}
 	`
+	tagBatchGetQ = `
+		FOR key IN @keys
+			LET tag = FIRST(
+				FOR cv IN @@cv_collection
+					FOR cvt IN @@cvterm_collection
+						FILTER cv.metadata.namespace == key.ontology
+						FILTER cvt.label == key.name
+						FILTER cvt.graph_id == cv._id
+						LIMIT 1
+						RETURN {
+							id: cvt.id,
+							name: cvt.label,
+							is_obsolete: cvt.deprecated,
+							ontology: cv.metadata.namespace
+						}
+			)
+			FILTER tag != null
+			RETURN tag
+	`
+	ontologyGetQ = `
+		FOR cv IN @@cv_collection
+			FILTER cv.metadata.namespace IN @namespaces
+			RETURN {
+				id: cv.id,
+				iri: cv.iri,
+				label: cv.label,
+				namespace: cv.metadata.namespace,
+				version: cv.metadata.version
+			}
+	`
 	cvtID2LblQ = `
 		FOR cvt IN @@cvterm_collection
 			FILTER cvt._id == @id
@@ -62,7 +92,7 @@ const (
 		FOR cvt IN @@cvt_collection
 			FOR ann IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
 				FOR cv IN @@cv_collection
-					FILTER ann.is_obsolete == false
+					FILTER ann.is_obsolete == @is_obsolete
 					FILTER cvt.graph_id == cv._id
 					SORT ann.created_at DESC
 					LIMIT @limit
@@ -76,7 +106,7 @@ const (
 		FOR cvt IN @@cvt_collection
 			FOR ann IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
 				FOR cv IN @@cv_collection
-					FILTER ann.is_obsolete == false
+					FILTER ann.is_obsolete == @is_obsolete
 					FILTER cvt.graph_id == cv._id
 					%s
 					SORT ann.created_at DESC
@@ -91,7 +121,7 @@ const (
 		FOR cvt IN @@cvt_collection
 			FOR ann IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
 				FOR cv IN @@cv_collection
-					FILTER ann.is_obsolete == false
+					FILTER ann.is_obsolete == @is_obsolete
 					FILTER cvt.graph_id == cv._id
 					FILTER ann.created_at <= DATE_ISO8601(@cursor)
 					SORT ann.created_at DESC
@@ -105,7 +135,7 @@ const (
 		FOR cvt IN @@cvt_collection
 			FOR ann IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
 				FOR cv IN @@cv_collection
-					FILTER ann.is_obsolete == false
+					FILTER ann.is_obsolete == @is_obsolete
 					FILTER cvt.graph_id == cv._id
 					FILTER ann.created_at <= DATE_ISO8601(@cursor)
 					%s
@@ -116,37 +146,184 @@ const (
 							{ tag: cvt.label, ontology: cv.metadata.namespace }
 						)
 	`
+	annGroupGetQ = `
+		FOR grp IN @@anno_group_collection
+			FILTER grp._key == @key` + groupAnnotationsQ + `
+			RETURN MERGE(
+				UNSET(NOT_NULL(grp, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: grp._key,
+					annotations: annotations
+				}
+			)
+	`
+	// groupAnnotationsQ retrieves the members of the group grp in their
+	// order along with their tag and ontology.
+	groupAnnotationsQ = `
+		LET annotations = (
+			FOR aid IN NOT_NULL(grp.group, [])
+				FOR ann IN @@anno_collection
+					FILTER ann._key == aid
+					FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+						FOR cv IN @@cv_collection
+							FILTER cvt.graph_id == cv._id
+							RETURN MERGE(
+								ann,
+								{ tag: cvt.label, ontology: cv.metadata.namespace }
+							)
+		)`
+	// groupFindQ finds the group ag that is modified.
+	groupFindQ = `
+		LET ag = FIRST(
+			FOR g IN @@anno_group_collection
+				FILTER g._key == @key
+				RETURN g
+		)`
+	// groupTypeQ looks up the rules of the type of the group ag.
+	groupTypeQ = `
+		LET gtype = FIRST(
+			FOR gt IN @@anno_group_type_collection
+				FILTER ag.group_type != null
+				FILTER gt.ontology == ag.group_ontology
+				FILTER gt.tag == ag.group_type
+				RETURN gt
+		)
+		LET type_missing = ag.group_type != null AND gtype == null
+		LET min_members = NOT_NULL(gtype.min_members, 0)
+		LET max_members = NOT_NULL(gtype.max_members, 0)`
+	// groupNewMemberQ checks the annotations added to a group, they have to
+	// exist, be live and have a tag allowed by the group type.
+	groupNewMemberQ = `
+		LET found = (
+			FOR ann IN @@anno_collection
+				FILTER ann._key IN @group
+				RETURN {
+					key: ann._key,
+					is_obsolete: ann.is_obsolete,
+					tag: FIRST(
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							RETURN cvt.label
+					)
+				}
+		)
+		LET checks = {
+			missing: MINUS(@group, found[*].key),
+			obsolete: found[* FILTER CURRENT.is_obsolete == true].key,
+			disallowed: LENGTH(NOT_NULL(gtype.allowed_tags, [])) == 0 ? [] :
+				found[* FILTER CURRENT.tag NOT IN gtype.allowed_tags].key
+		}`
+	// groupValidQ tells whether the group ag can have the members, every
+	// check lists the offending annotations.
+	groupValidQ = `
+		LET valid = ag != null
+			AND !type_missing
+			AND LENGTH(FLATTEN(VALUES(checks))) == 0
+			AND (min_members == 0 OR LENGTH(members) >= min_members)
+			AND (max_members == 0 OR LENGTH(members) <= max_members)`
+	// groupUpdateQ sets the members of a valid group ag.
+	groupUpdateQ = `
+		LET upd = (
+			FILTER valid
+			UPDATE ag WITH {
+				updated_at: DATE_ISO8601(DATE_NOW()),
+				group: members,
+				version: NOT_NULL(ag.version, 1) + 1
+			} IN @@anno_group_collection RETURN NEW
+		)`
+	// groupHistoryQ records the written group as a new version with the
+	// action.
+	groupHistoryQ = `
+		LET grp = FIRST(upd)
+		LET history = (
+			FOR g IN upd
+				INSERT {
+					group_id: g._key,
+					version: g.version,
+					group: g.group,
+					action: action,
+					created_at: g.updated_at
+				} IN @@anno_group_version_collection
+				RETURN NEW._key
+		)`
+	// groupResultQ returns the written group along with the outcome of
+	// its validation.
+	groupResultQ = groupAnnotationsQ + `
+		RETURN MERGE(
+			UNSET(NOT_NULL(grp, {}), "_key", "_id", "_rev", "group"),
+			checks,
+			{
+				group_id: grp._key,
+				exists: ag != null,
+				type_missing: type_missing,
+				size: LENGTH(members),
+				min_members: min_members,
+				max_members: max_members,
+				group_type: ag.group_type,
+				group_ontology: ag.group_ontology,
+				annotations: annotations
+			}
+		)
+	`
 	annGroupInst = `
-		INSERT {
+		LET ag = { group_type: @group_type, group_ontology: @group_ontology }
+		LET action = "create"
+		LET members = @group` + groupTypeQ + groupNewMemberQ + groupValidQ + `
+		LET upd = (
+			FILTER valid
+			INSERT {
 				created_at: DATE_ISO8601(DATE_NOW()),
 				updated_at: DATE_ISO8601(DATE_NOW()),
-				group: @group
-			   } IN @@anno_group_collection RETURN NEW
-	`
-	annGroupUpd = `
-		UPDATE { _key: @key }
-			WITH { 
-					updated_at: DATE_ISO8601(DATE_NOW()),
-					group: @group 
-				 } IN @@anno_group_collection RETURN NEW
-	`
+				group: members,
+				version: 1,
+				group_type: @group_type,
+				group_ontology: @group_ontology,
+				name: @name,
+				description: @description,
+				created_by: @created_by,
+				updated_by: @created_by
+			} IN @@anno_group_collection RETURN NEW
+		)` + groupHistoryQ + groupResultQ
+	annGroupAppendQ = groupFindQ + `
+		LET action = "append"
+		LET members = APPEND(ag.group, @group, true)` +
+		groupTypeQ + groupNewMemberQ + groupValidQ + groupUpdateQ + groupHistoryQ + groupResultQ
+	annGroupRemoveQ = groupFindQ + `
+		LET action = "remove"
+		LET members = REMOVE_VALUES(ag.group, @group)
+		LET checks = {}` +
+		groupTypeQ + groupValidQ + groupUpdateQ + groupHistoryQ + groupResultQ
+	annGroupMoveQ = groupFindQ + `
+		LET action = "move"
+		LET mid = FIRST(@group)
+		LET rest = REMOVE_VALUE(NOT_NULL(ag.group, []), mid)
+		LET members = APPEND(
+			APPEND(@position == 0 ? [] : SLICE(rest, 0, @position), [mid]),
+			SLICE(rest, @position)
+		)
+		LET checks = { missing: mid IN NOT_NULL(ag.group, []) ? [] : [mid] }` +
+		groupTypeQ + groupValidQ + groupUpdateQ + groupHistoryQ + groupResultQ
+	annGroupReorderQ = groupFindQ + `
+		LET action = "reorder"
+		LET members = APPEND(@group, ag.group[* FILTER CURRENT NOT IN @group])
+		LET checks = { missing: @group[* FILTER CURRENT NOT IN NOT_NULL(ag.group, [])] }` +
+		groupTypeQ + groupValidQ + groupUpdateQ + groupHistoryQ + groupResultQ
 	annGroupListFilterQ = `
 		LET filterannos = (
-			FOR ann IN %s
-				FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-					FOR cv IN %s
+			FOR ann IN @@anno_collection
+				FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+					FOR cv IN @@cv_collection
 						FILTER ann.is_obsolete == false
 						FILTER cvt.graph_id == cv._id
 						%s
 						RETURN ann._key
 		)
-		FOR ag in %s
+		FOR ag IN @@anno_group_collection
 			LET annotations = (
-				FOR aid in ag.group
-					FOR ann IN %s
-						FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-							FOR cv IN %s
-								FILTER aid == ann._key
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
 								FILTER cvt.graph_id == cv._id
 								RETURN MERGE(
 									ann,
@@ -154,32 +331,35 @@ const (
 								)
 			)
 			FILTER ag.group ANY IN filterannos
+			FILTER @group_ontology == null OR ag.group_ontology == @group_ontology
+			FILTER @group_type == null OR ag.group_type == @group_type
 			SORT ag.created_at DESC
-			LIMIT %d
-			RETURN {
-				created_at: ag.created_at,
-				updated_at: ag.updated_at,
-				group_id: ag._key,
-				annotations: annotations
-			}
+			LIMIT @limit
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
 	`
 	annGroupListFilterWithCursorQ = `
 		LET filterannos = (
-			FOR ann IN %s
-				FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-					FOR cv IN %s
+			FOR ann IN @@anno_collection
+				FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+					FOR cv IN @@cv_collection
 						FILTER ann.is_obsolete == false
 						FILTER cvt.graph_id == cv._id
 						%s
 						RETURN ann._key
 		)
-		FOR ag in %s
+		FOR ag IN @@anno_group_collection
 			LET annotations = (
-				FOR aid in ag.group
-					FOR ann IN %s
-						FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-							FOR cv IN %s
-								FILTER aid == ann._key
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
 								FILTER cvt.graph_id == cv._id
 								RETURN MERGE(
 									ann,
@@ -187,95 +367,571 @@ const (
 								)
 			)
 			FILTER ag.group ANY IN filterannos
-			FILTER ag.created_at <= DATE_ISO8601(%d)
+			FILTER @group_ontology == null OR ag.group_ontology == @group_ontology
+			FILTER @group_type == null OR ag.group_type == @group_type
+			FILTER ag.created_at <= DATE_ISO8601(@cursor)
 			SORT ag.created_at DESC
-			LIMIT %d
-			RETURN {
-				created_at: ag.created_at,
-				updated_at: ag.updated_at,
-				group_id: ag._key,
-				annotations: annotations
-			}
+			LIMIT @limit
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
 	`
 	annGroupListQ = `
-		FOR ag IN %s
+		FOR ag IN @@anno_group_collection
 			LET annotations = (
-				FOR aid in ag.group
-					FOR ann IN %s
-						FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-							FOR cv IN %s
-								FILTER aid == ann._key
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
 								FILTER cvt.graph_id == cv._id
 								RETURN MERGE(
 									ann,
 									{ tag: cvt.label, ontology: cv.metadata.namespace }
 								)
 			)
+			FILTER @group_ontology == null OR ag.group_ontology == @group_ontology
+			FILTER @group_type == null OR ag.group_type == @group_type
 			SORT ag.created_at DESC
-			LIMIT %d
-			RETURN {
-				created_at: ag.created_at,
-				updated_at: ag.updated_at,
-				group_id: ag._key,
-				annotations: annotations
-			}
+			LIMIT @limit
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
 	`
 	annGroupListWithCursorQ = `
-		FOR ag IN %s
+		FOR ag IN @@anno_group_collection
 			LET annotations = (
-				FOR aid in ag.group
-					FOR ann IN %s
-						FOR cvt IN 1..1 OUTBOUND ann GRAPH '%s'
-							FOR cv IN %s
-								FILTER aid == ann._key
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
 								FILTER cvt.graph_id == cv._id
 								RETURN MERGE(
 									ann,
 									{ tag: cvt.label, ontology: cv.metadata.namespace }
 								)
 			)
-			FILTER ag.created_at <= DATE_ISO8601(%d)
+			FILTER @group_ontology == null OR ag.group_ontology == @group_ontology
+			FILTER @group_type == null OR ag.group_type == @group_type
+			FILTER ag.created_at <= DATE_ISO8601(@cursor)
 			SORT ag.created_at DESC
-			LIMIT %d
-			RETURN {
-				created_at: ag.created_at,
-				updated_at: ag.updated_at,
-				group_id: ag._key,
-				annotations: annotations
+			LIMIT @limit
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
+	`
+	annGroupTypeGetQ = `
+		FOR gt IN @@anno_group_type_collection
+			FILTER gt.ontology == @ontology
+			FILTER gt.tag == @tag
+			LIMIT 1
+			RETURN gt
+	`
+	annGroupTypeUpsertQ = `
+		UPSERT { ontology: @ontology, tag: @tag }
+			INSERT {
+				ontology: @ontology,
+				tag: @tag,
+				allowed_tags: @allowed_tags,
+				min_members: @min_members,
+				max_members: @max_members
 			}
+			UPDATE {
+				allowed_tags: @allowed_tags,
+				min_members: @min_members,
+				max_members: @max_members
+			} IN @@anno_group_type_collection
+			RETURN NEW
+	`
+	annGroupEditQ = `
+		FOR ag IN @@anno_group_collection
+			FILTER ag._key == @key
+			UPDATE ag WITH {
+				updated_at: DATE_ISO8601(DATE_NOW()),
+				name: @name,
+				description: @description,
+				updated_by: @updated_by
+			} IN @@anno_group_collection
+			LET grp = NEW` + groupAnnotationsQ + `
+			RETURN MERGE(
+				UNSET(NOT_NULL(grp, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: grp._key,
+					annotations: annotations
+				}
+			)
+	`
+	annGroupByAnnoQ = `
+		FOR ag IN @@anno_group_collection
+			FILTER @key IN ag.group
+			LET annotations = (
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
+								FILTER cvt.graph_id == cv._id
+								RETURN MERGE(
+									ann,
+									{ tag: cvt.label, ontology: cv.metadata.namespace }
+								)
+			)
+			SORT ag.created_at DESC
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
+	`
+	annGroupByAnnosQ = `
+		FOR ag IN @@anno_group_collection
+			FILTER LENGTH(INTERSECTION(@keys, NOT_NULL(ag.group, []))) > 0
+			LET annotations = (
+				FOR aid IN ag.group
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
+								FILTER cvt.graph_id == cv._id
+								RETURN MERGE(
+									ann,
+									{ tag: cvt.label, ontology: cv.metadata.namespace }
+								)
+			)
+			SORT ag.created_at DESC
+			RETURN MERGE(
+				UNSET(ag, "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
+	`
+	annGroupByEntryQ = `
+		LET keys = (
+			FOR ann IN @@anno_collection
+				FILTER ann.entry_id == @entry_id
+				RETURN ann._key
+		)
+		LET groups = UNIQUE(
+			FOR k IN keys
+				FOR g IN @@anno_group_collection
+					FILTER k IN g.group
+					RETURN g
+		)
+		FOR ag IN groups
+			LET annotations = (
+				FOR aid IN NOT_NULL(ag.group, [])
+					FOR ann IN @@anno_collection
+						FILTER ann._key == aid
+						FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+							FOR cv IN @@cv_collection
+								FILTER cvt.graph_id == cv._id
+								RETURN MERGE(
+									ann,
+									{ tag: cvt.label, ontology: cv.metadata.namespace }
+								)
+			)
+			SORT ag.created_at DESC
+			RETURN MERGE(
+				UNSET(NOT_NULL(ag, {}), "_key", "_id", "_rev", "group"),
+				{
+					group_id: ag._key,
+					annotations: annotations
+				}
+			)
+	`
+	annGroupVersionListQ = `
+		FOR gv IN @@anno_group_version_collection
+			FILTER gv.group_id == @key
+			SORT gv.version ASC
+			RETURN gv
+	`
+	annGroupVersionGetQ = `
+		FOR gv IN @@anno_group_version_collection
+			FILTER gv.group_id == @key
+			FILTER gv.version == @version
+			LIMIT 1
+			RETURN gv
 	`
 	annVerInstFn = `
 		function (params) {
-			var db = require('@arangodb').db
-			var d = new Date(Date.now())
-			var annoc = db._collection(params[0])
-			var n = annoc.save({
-				value: params[3],
-				editable_value: params[4],
-				created_by: params[5],
-				entry_id: params[6],
-				rank: params[7],
-				is_obsolete: false,
-				version: params[8],
-				created_at: d.toISOString()
-			}, { returnNew: true})
-			annoc.update(params[10],{ is_obsolete: true })
-			db._collection(params[1]).save({
-				_from: n._id,
-				_to: params[9]
+			var arangodb = require('@arangodb')
+			var db = arangodb.db
+			var cfg = params[0]
+			var d = new Date(Date.now()).toISOString()
+			var annoc = db._collection(cfg.anno_collection)
+			var termc = db._collection(cfg.anno_cv_collection)
+			var verc = db._collection(cfg.anno_ver_collection)
+			return params[1].map(function (edit) {
+				var old = annoc.document(edit.key)
+				var stale = old.is_obsolete ||
+					verc.outEdges(old._id).length > 0 ||
+					(edit.version > 0 && old.version !== edit.version)
+				if (stale) {
+					var err = new arangodb.ArangoError()
+					err.errorNum = cfg.stale_error_num
+					err.errorMessage = 'annotation ' + old._key + ' is at version ' +
+						old.version + ' and has been edited'
+					throw err
+				}
+				var n = annoc.save({
+					value: edit.value,
+					editable_value: edit.editable_value,
+					created_by: edit.created_by,
+					entry_id: old.entry_id,
+					rank: old.rank,
+					is_obsolete: false,
+					version: old.version + 1,
+					created_at: d
+				}, { returnNew: true })
+				annoc.update(old, { is_obsolete: true }, { overwrite: false })
+				termc.save({ _from: n._id, _to: termc.outEdges(old._id)[0]._to })
+				verc.save({ _from: old._id, _to: n._id })
+				db._query(cfg.replace_query, {
+					"@anno_group_collection": cfg.anno_group_collection,
+					"@anno_group_version_collection": cfg.anno_group_version_collection,
+					"old": old._key,
+					"new": n._key,
+					"updated_at": d
+				})
+				return n.new
 			})
-			db._collection(params[2]).save({
-				_from: params[10],
-				_to: n._id
+		}
+	`
+	annGroupMemberReplaceQ = `
+		FOR g IN @@anno_group_collection
+			FILTER @old IN g.group
+			UPDATE g WITH {
+				updated_at: @updated_at,
+				group: g.group[* RETURN CURRENT == @old ? @new : CURRENT],
+				version: NOT_NULL(g.version, 1) + 1
+			} IN @@anno_group_collection
+			LET upd = NEW
+			INSERT {
+				group_id: upd._key,
+				version: upd.version,
+				group: upd.group,
+				action: "edit",
+				created_at: @updated_at
+			} IN @@anno_group_version_collection
+	`
+	annGroupMemberCountQ = `
+		FOR key IN @keys
+			FOR g IN @@anno_group_collection
+				FILTER key IN g.group
+				COLLECT k = key AGGREGATE members = SUM(LENGTH(g.group))
+				RETURN { key: k, members: members }
+	`
+	annBatchFn = `
+		function (params) {
+			var arangodb = require('@arangodb')
+			var db = arangodb.db
+			var cfg = params[0]
+			var ops = params[1]
+			var annoc = db._collection(cfg.anno_collection)
+			var termc = db._collection(cfg.anno_cv_collection)
+			var verc = db._collection(cfg.anno_ver_collection)
+			var d = new Date(Date.now()).toISOString()
+			var refs = {}
+			var fail = function (idx, msg) {
+				var err = new arangodb.ArangoError()
+				err.errorNum = cfg.error_num
+				err.errorMessage = 'batch operation ' + idx + ': ' + msg
+				throw err
+			}
+			var merge = function (a, b) {
+				var m = {}
+				Object.keys(a).forEach(function (k) { m[k] = a[k] })
+				Object.keys(b).forEach(function (k) { m[k] = b[k] })
+				return m
+			}
+			var resolve = function (idx, id) {
+				if (id.charAt(0) !== '$') {
+					return id
+				}
+				if (!refs.hasOwnProperty(id.substring(1))) {
+					fail(idx, 'unknown reference ' + id)
+				}
+				return refs[id.substring(1)]
+			}
+			var liveAnno = function (idx, key) {
+				if (!annoc.exists(key)) {
+					fail(idx, 'annotation id ' + key + ' not found')
+				}
+				var doc = annoc.document(key)
+				if (doc.is_obsolete) {
+					fail(idx, 'annotation id ' + key + ' is obsolete')
+				}
+				return doc
+			}
+			var checkVersion = function (idx, doc, version) {
+				if (version > 0 && doc.version !== version) {
+					fail(idx, 'annotation id ' + doc._key + ' is at version ' + doc.version)
+				}
+				if (verc.outEdges(doc._id).length > 0) {
+					fail(idx, 'annotation id ' + doc._key + ' has been edited')
+				}
+			}
+			var checkGroup = function (idx, r) {
+				if (!r.exists) {
+					fail(idx, 'group not found')
+				}
+				if (r.missing.length > 0) {
+					fail(idx, 'annotation id ' + r.missing[0] + ' not found')
+				}
+				if (r.obsolete.length > 0) {
+					fail(idx, 'annotation id ' + r.obsolete[0] + ' is obsolete')
+				}
+				if (r.type_missing) {
+					fail(idx, 'group type ' + r.group_type + ' not found')
+				}
+				if (r.disallowed.length > 0) {
+					fail(idx, 'tag of annotation ' + r.disallowed[0] + ' is not allowed')
+				}
+				if (r.min_members > 0 && r.size < r.min_members) {
+					fail(idx, 'group needs at least ' + r.min_members + ' members')
+				}
+				if (r.max_members > 0 && r.size > r.max_members) {
+					fail(idx, 'group allows at most ' + r.max_members + ' members')
+				}
+				return r.group_id
+			}
+			return ops.map(function (op, idx) {
+				var id
+				switch (op.action) {
+				case 'create':
+					if (db._query(cfg.exist_query, merge(cfg.exist_vars, op.exist)).toArray().length > 0) {
+						fail(idx, 'annotation already exists')
+					}
+					id = db._query(cfg.create_query, merge(cfg.create_vars, op.create)).toArray()[0]._key
+					break
+				case 'update':
+					var old = liveAnno(idx, resolve(idx, op.id))
+					checkVersion(idx, old, op.version)
+					var n = annoc.save({
+						value: op.update.value,
+						editable_value: op.update.editable_value,
+						created_by: op.update.created_by,
+						entry_id: old.entry_id,
+						rank: old.rank,
+						is_obsolete: false,
+						version: old.version + 1,
+						created_at: d
+					})
+					annoc.update(old, { is_obsolete: true }, { overwrite: false })
+					termc.save({ _from: n._id, _to: termc.outEdges(old._id)[0]._to })
+					verc.save({ _from: old._id, _to: n._id })
+					db._query(cfg.replace_query, merge(cfg.replace_vars, {
+						old: old._key,
+						new: n._key,
+						updated_at: d
+					}))
+					id = n._key
+					break
+				case 'delete':
+					id = liveAnno(idx, resolve(idx, op.id))._key
+					annoc.update(id, {
+						is_obsolete: true,
+						deleted_by: op.delete.deleted_by,
+						deleted_at: d,
+						delete_reason: op.delete.reason
+					})
+					break
+				case 'group':
+				case 'append':
+					var vars = merge(cfg.group_vars, op.group)
+					vars.group = op.ids.map(function (m) {
+						return resolve(idx, m)
+					}).filter(function (m, i, all) {
+						return all.indexOf(m) === i
+					})
+					var query = cfg.group_query
+					if (op.action === 'append') {
+						vars.key = resolve(idx, op.id)
+						query = cfg.append_query
+					}
+					id = checkGroup(idx, db._query(query, vars).toArray()[0])
+					break
+				default:
+					fail(idx, 'unknown action ' + op.action)
+				}
+				if (op.ref) {
+					refs[op.ref] = id
+				}
+				return { action: op.action, ref: op.ref, id: id }
 			})
-			return n.new
+		}
+	`
+	annBulkMatchQ = `
+		FOR cvt IN @@cvt_collection
+			FOR ann IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
+				FOR cv IN @@cv_collection
+					FILTER ann.is_obsolete == false
+					FILTER cvt.graph_id == cv._id
+					%s
+					SORT ann.created_at
+					RETURN ann._key
+	`
+	annBulkGetQ = `
+		FOR ann IN @@anno_collection
+			FILTER ann._key IN @keys
+			FILTER ann.is_obsolete == false
+			SORT ann.created_at
+			RETURN ann
+	`
+	annSoftDeleteQ = `
+		FOR ann IN @@anno_collection
+			FILTER ann._key == @key
+			FILTER ann.is_obsolete == false
+			UPDATE ann WITH {
+				is_obsolete: true,
+				deleted_by: @deleted_by,
+				deleted_at: DATE_ISO8601(DATE_NOW()),
+				delete_reason: @reason
+			} IN @@anno_collection
+			RETURN NEW
+	`
+	annCountByOntologyQ = `
+		LET annos = (
+			FOR ann IN @@anno_collection
+				FILTER ann.is_obsolete == false
+				FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+					FOR cv IN @@cv_collection
+						FILTER cvt.graph_id == cv._id
+						COLLECT ontology = cv.metadata.namespace WITH COUNT INTO n
+						RETURN { ontology: ontology, n: n }
+		)
+		LET groups = (
+			FOR g IN @@anno_group_collection
+				LET onts = UNIQUE(
+					FOR aid IN NOT_NULL(g.group, [])
+						FOR ann IN @@anno_collection
+							FILTER ann._key == aid
+							FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+								FOR cv IN @@cv_collection
+									FILTER cvt.graph_id == cv._id
+									RETURN cv.metadata.namespace
+				)
+				FOR o IN onts
+					COLLECT ontology = o WITH COUNT INTO n
+					RETURN { ontology: ontology, n: n }
+		)
+		FOR ontology IN UNION_DISTINCT(annos[*].ontology, groups[*].ontology)
+			SORT ontology
+			RETURN {
+				ontology: ontology,
+				annotations: NOT_NULL(FIRST(annos[* FILTER CURRENT.ontology == ontology].n), 0),
+				groups: NOT_NULL(FIRST(groups[* FILTER CURRENT.ontology == ontology].n), 0)
+			}
+	`
+	auditInst = `
+		INSERT {
+			operation: @operation,
+			actor: @actor,
+			digest: @digest,
+			keys: @keys,
+			entry_ids: @entry_ids,
+			created_at: DATE_ISO8601(DATE_NOW())
+		} IN @@audit_collection
+		RETURN NEW
+	`
+	auditListQ = `
+		FOR a IN @@audit_collection
+			FILTER @actor == "" || a.actor == @actor
+			FILTER @entry_id == "" || @entry_id IN a.entry_ids
+			FILTER @since == 0 || a.created_at >= DATE_ISO8601(@since)
+			FILTER @until == 0 || a.created_at <= DATE_ISO8601(@until)
+			FILTER @cursor == 0
+				|| a.created_at < DATE_ISO8601(@cursor)
+				|| (a.created_at == DATE_ISO8601(@cursor) && a._key < @cursor_key)
+			SORT a.created_at DESC, a._key DESC
+			LIMIT @limit
+			RETURN a
+	`
+	annBulkObsoleteQ = `
+		FOR ann IN @@anno_collection
+			FILTER ann._key IN @keys
+			FILTER ann.is_obsolete == false
+			UPDATE ann WITH {
+				is_obsolete: true,
+				deleted_by: @deleted_by,
+				deleted_at: DATE_ISO8601(DATE_NOW()),
+				delete_reason: @reason
+			} IN @@anno_collection
+			RETURN NEW._key
+	`
+	annRestoreQ = `
+		LET ann = FIRST(
+			FOR a IN @@anno_collection
+				FILTER a._key == @key
+				RETURN a
+		)
+		LET cvt = FIRST(
+			FOR v IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+				RETURN v
+		)
+		LET ontology = FIRST(
+			FOR cv IN @@cv_collection
+				FILTER cv._id == cvt.graph_id
+				RETURN cv.metadata.namespace
+		)
+		LET successor = FIRST(
+			FOR e IN @@anno_ver_collection
+				FILTER e._from == ann._id
+				RETURN PARSE_IDENTIFIER(e._to).key
+		)
+		LET live = FIRST(
+			FOR a IN 1..1 INBOUND cvt GRAPH @anno_cvterm_graph
+				FILTER a.entry_id == ann.entry_id
+				FILTER a.rank == ann.rank
+				FILTER a.is_obsolete == false
+				RETURN a._key
+		)
+		LET upd = (
+			FILTER ann != null
+			FILTER ann.is_obsolete == true
+			FILTER successor == null
+			FILTER live == null
+			UPDATE ann WITH {
+				is_obsolete: false,
+				restored_by: @restored_by,
+				restored_at: DATE_ISO8601(DATE_NOW()),
+				deleted_by: null,
+				deleted_at: null,
+				delete_reason: null
+			} IN @@anno_collection OPTIONS { keepNull: false }
+			RETURN NEW
+		)
+		RETURN {
+			exists: ann != null,
+			is_obsolete: ann.is_obsolete == true,
+			successor: successor,
+			live: live,
+			annotation: LENGTH(upd) == 0 ? null :
+				MERGE(FIRST(upd), { tag: cvt.label, ontology: ontology })
 		}
 	`
 	annGetQ = `
-		FOR ann IN %s
-			FOR v IN 1..1 OUTBOUND ann GRAPH '%s'
-		        FOR cv IN %s
-					FILTER ann._key == '%s'
+		FOR ann IN @@anno_collection
+			FOR v IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+				FOR cv IN @@cv_collection
+					FILTER ann._key == @key
 					FILTER v.graph_id == cv._id
 					LIMIT 1
 					RETURN MERGE(
@@ -284,17 +940,187 @@ const (
 					)
 	`
 	annGetByEntryQ = `
-		FOR ann IN %s
-			FOR v IN 1..1 OUTBOUND ann GRAPH '%s'
-				FOR cv IN %s
-					FILTER ann.entry_id == '%s'
-					FILTER ann.rank == %d
-					FILTER ann.is_obsolete == %t
-					FILTER v.label == '%s'
+		FOR ann IN @@anno_collection
+			FOR v IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+				FOR cv IN @@cv_collection
+					FILTER ann.entry_id == @entry_id
+					FILTER ann.rank == @rank
+					FILTER ann.is_obsolete == @is_obsolete
+					FILTER v.label == @tag
 					FILTER v.graph_id == cv._id
-					FILTER cv.metadata.namespace == '%s'
+					FILTER cv.metadata.namespace == @ontology
 					SORT ann.version DESC
 					LIMIT 1
 					RETURN MERGE(ann, { ontology: cv.metadata.namespace, tag: v.label })
 	`
+	annEntryProfileQ = `
+		FOR ann IN @@anno_collection
+			FOR cvt IN 1..1 OUTBOUND ann GRAPH @anno_cvterm_graph
+				FOR cv IN @@cv_collection
+					FILTER ann.entry_id == @entry_id
+					FILTER ann.is_obsolete == false
+					FILTER cvt.graph_id == cv._id
+					LET groups = (
+						FOR ag IN @@anno_group_collection
+							FILTER ann._key IN ag.group
+							RETURN ag._key
+					)
+					COLLECT ontology = cv.metadata.namespace, tag = cvt.label
+						INTO annos = MERGE(
+							ann,
+							{ ontology: cv.metadata.namespace, tag: cvt.label, groups: groups }
+						)
+					SORT ontology, tag
+					RETURN {
+						ontology: ontology,
+						tag: tag,
+						annotations: (
+							FOR a IN annos
+								SORT a.rank ASC
+								RETURN a
+						)
+					}
+	`
+	annPurgeQ = `
+		LET groups = (
+			FOR g IN @@anno_group_collection
+				FILTER @key IN g.group
+				UPDATE g WITH {
+					updated_at: DATE_ISO8601(DATE_NOW()),
+					group: REMOVE_VALUE(g.group, @key),
+					version: NOT_NULL(g.version, 1) + 1
+				} IN @@anno_group_collection
+				RETURN NEW
+		)
+		LET history = (
+			FOR g IN groups
+				INSERT {
+					group_id: g._key,
+					version: g.version,
+					group: g.group,
+					action: "purge",
+					created_at: g.updated_at
+				} IN @@anno_group_version_collection
+				RETURN NEW._key
+		)
+		LET tag_edges = (
+			FOR e IN @@anno_cv_collection
+				FILTER e._from == @id
+				REMOVE e IN @@anno_cv_collection
+				RETURN OLD._key
+		)
+		LET version_edges = (
+			FOR e IN @@anno_ver_collection
+				FILTER e._from == @id OR e._to == @id
+				REMOVE e IN @@anno_ver_collection
+				RETURN OLD._key
+		)
+		REMOVE @key IN @@anno_collection
+	`
+	annDanglingQ = `
+		LET groups = (
+			FOR g IN @@anno_group_collection
+				LET dangling = (
+					FOR aid IN g.group
+						LET found = (
+							FOR ann IN @@anno_collection
+								FILTER ann._key == aid
+								LIMIT 1
+								RETURN 1
+						)
+						FILTER LENGTH(found) == 0
+						RETURN aid
+				)
+				FILTER LENGTH(dangling) > 0
+				RETURN { group_id: g._key, members: dangling }
+		)
+		LET tag_edges = (
+			FOR e IN @@anno_cv_collection
+				LET found = (
+					FOR ann IN @@anno_collection
+						FILTER ann._id == e._from
+						LIMIT 1
+						RETURN 1
+				)
+				FILTER LENGTH(found) == 0
+				RETURN e._key
+		)
+		LET version_edges = (
+			FOR e IN @@anno_ver_collection
+				LET found = (
+					FOR ann IN @@anno_collection
+						FILTER ann._id IN [e._from, e._to]
+						RETURN 1
+				)
+				FILTER LENGTH(found) < 2
+				RETURN e._key
+		)
+		RETURN {
+			groups: groups,
+			tag_edges: tag_edges,
+			version_edges: version_edges
+		}
+	`
+	annDanglingRepairQ = `
+		LET groups = (
+			FOR g IN @@anno_group_collection
+				LET dangling = (
+					FOR aid IN g.group
+						LET found = (
+							FOR ann IN @@anno_collection
+								FILTER ann._key == aid
+								LIMIT 1
+								RETURN 1
+						)
+						FILTER LENGTH(found) == 0
+						RETURN aid
+				)
+				FILTER LENGTH(dangling) > 0
+				UPDATE g WITH {
+					updated_at: DATE_ISO8601(DATE_NOW()),
+					group: REMOVE_VALUES(g.group, dangling),
+					version: NOT_NULL(g.version, 1) + 1
+				} IN @@anno_group_collection
+				RETURN { group_id: g._key, members: dangling, updated: NEW }
+		)
+		LET history = (
+			FOR g IN groups[*].updated
+				INSERT {
+					group_id: g._key,
+					version: g.version,
+					group: g.group,
+					action: "repair",
+					created_at: g.updated_at
+				} IN @@anno_group_version_collection
+				RETURN NEW._key
+		)
+		LET tag_edges = (
+			FOR e IN @@anno_cv_collection
+				LET found = (
+					FOR ann IN @@anno_collection
+						FILTER ann._id == e._from
+						LIMIT 1
+						RETURN 1
+				)
+				FILTER LENGTH(found) == 0
+				REMOVE e IN @@anno_cv_collection
+				RETURN OLD._key
+		)
+		LET version_edges = (
+			FOR e IN @@anno_ver_collection
+				LET found = (
+					FOR ann IN @@anno_collection
+						FILTER ann._id IN [e._from, e._to]
+						RETURN 1
+				)
+				FILTER LENGTH(found) < 2
+				REMOVE e IN @@anno_ver_collection
+				RETURN OLD._key
+		)
+		RETURN {
+			groups: groups,
+			tag_edges: tag_edges,
+			version_edges: version_edges
+		}
+	`
 )
@@ -0,0 +1,39 @@
+package arangodb
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dictyBase/modware-annotation/internal/repository/arangodb"
+
+// startSpan starts the client span of an aql query or a transaction. Only
+// the name of the query is recorded, the bound values are left out as they
+// carry the content of annotations.
+func (ar *arangorepository) startSpan(
+	ctx context.Context,
+	operation, name string,
+) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(
+		ctx,
+		operation+" "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String("arangodb"),
+			semconv.DBName(ar.database.Handler().Name()),
+			semconv.DBOperation(name),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,40 @@
+package arangodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dictyBase/modware-annotation/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestQuerySpans is not parallel as it replaces the global tracer provider.
+func TestQuerySpans(t *testing.T) {
+	assert, anrepo := setUp(t)
+	defer tearDown(anrepo)
+	exp := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	defer otel.SetTracerProvider(prev)
+	mda, err := anrepo.AddAnnotation(context.Background(), newTestTaggedAnnotation())
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	exp.Reset()
+	_, err = anrepo.GetAnnotationByID(context.Background(), mda.Key)
+	assert.NoErrorf(err, "expect no error, received %s", err)
+	_, err = anrepo.GetAnnotationByID(context.Background(), "9999999")
+	assert.Error(err, "expect error for a missing annotation")
+	spans := exp.GetSpans()
+	assert.Len(spans, 2, "should record a span for every query")
+	for _, spn := range spans {
+		assert.Equal("aql annGetQ", spn.Name, "should name the span after the query")
+		attrs := make(map[string]string)
+		for _, kv := range spn.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+			assert.NotEqual(mda.Key, kv.Value.Emit(), "should not record bound values")
+		}
+		assert.Equal("arangodb", attrs["db.system"], "should match the database system")
+		assert.Equal("annGetQ", attrs["db.operation"], "should match the query name")
+	}
+}
@@ -0,0 +1,541 @@
+// Package audit records every mutating call of an annotation repository in
+// its append only audit log.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/go-obograph/storage"
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type auditRepository struct {
+	repository.TaggedAnnotationRepository
+	logger *logrus.Entry
+}
+
+// NewTaggedAnnotationRepo wraps a repository so that every successful
+// mutation appends an entry to the audit log of the wrapped repository.
+// The actor is the caller of the request when it is authenticated. The
+// entry is written once the mutation is committed, a failure to write it
+// is logged and does not fail the mutation.
+func NewTaggedAnnotationRepo(
+	repo repository.TaggedAnnotationRepository,
+	logger *logrus.Entry,
+) repository.TaggedAnnotationRepository {
+	return &auditRepository{TaggedAnnotationRepository: repo, logger: logger}
+}
+
+// Digest is the hex encoded sha256 of the json form of a request payload.
+func Digest(payload interface{}) (string, error) {
+	cnt, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error in encoding payload %s", err)
+	}
+	sum := sha256.Sum256(cnt)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (ar *auditRepository) AddAnnotation(
+	ctx context.Context,
+	na *annotation.NewTaggedAnnotation,
+) (*model.AnnoDoc, error) {
+	mda, err := ar.TaggedAnnotationRepository.AddAnnotation(ctx, na)
+	if err != nil {
+		return mda, err
+	}
+	keys, entryIds := annoKeys(mda)
+
+	ar.record(
+		ctx,
+		"AddAnnotation", na.Data.Attributes.CreatedBy, na, keys, entryIds,
+	)
+
+	return mda, nil
+}
+
+func (ar *auditRepository) EditAnnotation(
+	ctx context.Context,
+	ua *annotation.TaggedAnnotationUpdate,
+) (*model.AnnoDoc, error) {
+	return ar.EditAnnotationAtVersion(ctx, ua, 0)
+}
+
+func (ar *auditRepository) EditAnnotationAtVersion(
+	ctx context.Context,
+	ua *annotation.TaggedAnnotationUpdate,
+	version int64,
+) (*model.AnnoDoc, error) {
+	mda, err := ar.TaggedAnnotationRepository.EditAnnotationAtVersion(ctx, ua, version)
+	if err != nil {
+		return mda, err
+	}
+	keys, entryIds := annoKeys(mda)
+
+	ar.record(
+		ctx,
+		"EditAnnotation",
+		ua.Data.Attributes.CreatedBy,
+		map[string]interface{}{"update": ua, "version": version},
+		append([]string{ua.Data.Id}, keys...),
+		entryIds,
+	)
+
+	return mda, nil
+}
+
+func (ar *auditRepository) RemoveAnnotation(ctx context.Context, id string, purge bool) error {
+	_, err := ar.RemoveAnnotationBy(ctx, id, purge, &model.DeleteInfo{})
+
+	return err
+}
+
+func (ar *auditRepository) RemoveAnnotationBy(
+	ctx context.Context,
+	id string,
+	purge bool,
+	info *model.DeleteInfo,
+) (*model.AnnoDoc, error) {
+	mda, err := ar.TaggedAnnotationRepository.RemoveAnnotationBy(ctx, id, purge, info)
+	if err != nil {
+		return mda, err
+	}
+	keys, entryIds := annoKeys(mda)
+
+	ar.record(
+		ctx,
+		"RemoveAnnotation",
+		info.DeletedBy,
+		map[string]interface{}{
+			"id":     id,
+			"purge":  purge,
+			"reason": info.Reason,
+		},
+		keys,
+		entryIds,
+	)
+
+	return mda, nil
+}
+
+func (ar *auditRepository) RestoreAnnotation(
+	ctx context.Context,
+	id, restoredBy string,
+) (*model.AnnoDoc, error) {
+	mda, err := ar.TaggedAnnotationRepository.RestoreAnnotation(ctx, id, restoredBy)
+	if err != nil {
+		return mda, err
+	}
+	keys, entryIds := annoKeys(mda)
+
+	ar.record(
+		ctx,
+		"RestoreAnnotation",
+		restoredBy,
+		map[string]interface{}{"id": id},
+		keys,
+		entryIds,
+	)
+
+	return mda, nil
+}
+
+func (ar *auditRepository) ClearAnnotations(ctx context.Context) error {
+	if err := ar.TaggedAnnotationRepository.ClearAnnotations(ctx); err != nil {
+		return err
+	}
+
+	ar.record(ctx, "ClearAnnotations", "", nil, nil, nil)
+
+	return nil
+}
+
+func (ar *auditRepository) Clear(ctx context.Context) error {
+	if err := ar.TaggedAnnotationRepository.Clear(ctx); err != nil {
+		return err
+	}
+
+	ar.record(ctx, "Clear", "", nil, nil, nil)
+
+	return nil
+}
+
+func (ar *auditRepository) AddAnnotationGroup(
+	ctx context.Context,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.AddAnnotationGroup(ctx, idslice...)
+
+	return ar.recordGroup(ctx, "AddAnnotationGroup", "", idslice, grp, err)
+}
+
+func (ar *auditRepository) AddTypedAnnotationGroup(
+	ctx context.Context,
+	params *model.GroupParams,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.AddTypedAnnotationGroup(
+		ctx,
+		params, idslice...,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"AddTypedAnnotationGroup",
+		params.CreatedBy,
+		map[string]interface{}{"params": params, "ids": idslice},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) EditAnnotationGroup(
+	ctx context.Context,
+	groupID, name, description, updatedBy string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.EditAnnotationGroup(
+		ctx,
+		groupID, name, description, updatedBy,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"EditAnnotationGroup",
+		updatedBy,
+		map[string]interface{}{
+			"group_id":    groupID,
+			"name":        name,
+			"description": description,
+		},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) MoveAnnotationGroupMember(
+	ctx context.Context,
+	groupID, annoID string,
+	position int64,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.MoveAnnotationGroupMember(
+		ctx,
+		groupID, annoID, position,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"MoveAnnotationGroupMember",
+		"",
+		map[string]interface{}{
+			"group_id": groupID,
+			"id":       annoID,
+			"position": position,
+		},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) ReorderAnnotationGroup(
+	ctx context.Context,
+	groupID string,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.ReorderAnnotationGroup(
+		ctx,
+		groupID, idslice...,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"ReorderAnnotationGroup",
+		"",
+		map[string]interface{}{"group_id": groupID, "ids": idslice},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) AppendToAnnotationGroup(
+	ctx context.Context,
+	groupID string,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.AppendToAnnotationGroup(
+		ctx,
+		groupID, idslice...,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"AppendToAnnotationGroup",
+		"",
+		map[string]interface{}{"group_id": groupID, "ids": idslice},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) RemoveFromAnnotationGroup(
+	ctx context.Context,
+	groupID string,
+	idslice ...string,
+) (*model.AnnoGroup, error) {
+	grp, err := ar.TaggedAnnotationRepository.RemoveFromAnnotationGroup(
+		ctx,
+		groupID, idslice...,
+	)
+
+	return ar.recordGroup(
+		ctx,
+		"RemoveFromAnnotationGroup",
+		"",
+		map[string]interface{}{"group_id": groupID, "ids": idslice},
+		grp,
+		err,
+	)
+}
+
+func (ar *auditRepository) RemoveAnnotationGroup(ctx context.Context, groupID string) error {
+	if err := ar.TaggedAnnotationRepository.RemoveAnnotationGroup(ctx, groupID); err != nil {
+		return err
+	}
+
+	ar.record(
+		ctx,
+		"RemoveAnnotationGroup",
+		"",
+		map[string]interface{}{"group_id": groupID},
+		[]string{groupID},
+		nil,
+	)
+
+	return nil
+}
+
+func (ar *auditRepository) BulkEditAnnotations(
+	ctx context.Context,
+	filter string,
+	edit *model.BulkEdit,
+	dryRun bool,
+) (*model.BulkResult, error) {
+	res, err := ar.TaggedAnnotationRepository.BulkEditAnnotations(
+		ctx,
+		filter, edit, dryRun,
+	)
+	if dryRun || len(res.NewIds) == 0 {
+		return res, err
+	}
+	// the chunks applied before a failure are recorded as well
+	ar.record(
+		ctx,
+		"BulkEditAnnotations",
+		edit.CreatedBy,
+		map[string]interface{}{"filter": filter, "edit": edit},
+		append(append([]string{}, res.Ids...), res.NewIds...),
+		nil,
+	)
+
+	return res, err
+}
+
+func (ar *auditRepository) BulkObsoleteAnnotations(
+	ctx context.Context,
+	filter string,
+	info *model.DeleteInfo,
+	dryRun bool,
+) (*model.BulkResult, error) {
+	res, err := ar.TaggedAnnotationRepository.BulkObsoleteAnnotations(
+		ctx,
+		filter, info, dryRun,
+	)
+	if dryRun || len(res.Ids) == 0 {
+		return res, err
+	}
+	ar.record(
+		ctx,
+		"BulkObsoleteAnnotations",
+		info.DeletedBy,
+		map[string]interface{}{"filter": filter, "reason": info.Reason},
+		res.Ids,
+		nil,
+	)
+
+	return res, err
+}
+
+func (ar *auditRepository) ApplyBatch(
+	ctx context.Context,
+	ops []*model.BatchOperation,
+) ([]*model.BatchResult, error) {
+	res, err := ar.TaggedAnnotationRepository.ApplyBatch(ctx, ops)
+	if err != nil {
+		return res, err
+	}
+	keys := make([]string, 0)
+	for _, r := range res {
+		keys = append(keys, r.Id)
+	}
+
+	ar.record(ctx, "ApplyBatch", batchActor(ops), ops, keys, nil)
+
+	return res, nil
+}
+
+func (ar *auditRepository) SetGroupType(
+	ctx context.Context,
+	gtp *model.GroupType,
+) (*model.GroupType, error) {
+	ngt, err := ar.TaggedAnnotationRepository.SetGroupType(ctx, gtp)
+	if err != nil {
+		return ngt, err
+	}
+
+	ar.record(ctx, "SetGroupType", "", gtp, []string{ngt.Key}, nil)
+
+	return ngt, nil
+}
+
+func (ar *auditRepository) DanglingReferences(
+	ctx context.Context,
+	repair bool,
+) (*model.DanglingReport, error) {
+	rpt, err := ar.TaggedAnnotationRepository.DanglingReferences(ctx, repair)
+	if err != nil || !repair {
+		return rpt, err
+	}
+	keys := make([]string, 0)
+	for _, grp := range rpt.Groups {
+		keys = append(keys, grp.GroupId)
+		keys = append(keys, grp.Members...)
+	}
+
+	ar.record(
+		ctx,
+		"DanglingReferences",
+		"",
+		map[string]interface{}{"repair": repair},
+		model.UniqueIds(keys),
+		nil,
+	)
+
+	return rpt, nil
+}
+
+// LoadOboJSON records the digest of the uploaded ontology file rather than
+// of its json form.
+func (ar *auditRepository) LoadOboJSON(
+	ctx context.Context,
+	r io.Reader,
+) (*storage.UploadInformation, error) {
+	hash := sha256.New()
+	info, err := ar.TaggedAnnotationRepository.LoadOboJSON(
+		ctx,
+		io.TeeReader(r, hash),
+	)
+	if err != nil {
+		return info, err
+	}
+	ar.add(ctx, &model.AuditEntry{
+		Operation: "LoadOboJSON",
+		Actor:     auth.Actor(ctx, ""),
+		Digest:    hex.EncodeToString(hash.Sum(nil)),
+	})
+
+	return info, nil
+}
+
+func (ar *auditRepository) recordGroup(
+	ctx context.Context,
+	operation, actor string,
+	payload interface{},
+	grp *model.AnnoGroup,
+	err error,
+) (*model.AnnoGroup, error) {
+	if err != nil {
+		return grp, err
+	}
+	keys, entryIds := annoKeys(grp.AnnoDocs...)
+
+	ar.record(
+		ctx,
+		operation,
+		actor,
+		payload,
+		append([]string{grp.GroupId}, keys...),
+		entryIds,
+	)
+
+	return grp, nil
+}
+
+// record appends the entry of a mutation that has already happened. The
+// actor is the authenticated caller, or the claimed actor without one.
+func (ar *auditRepository) record(
+	ctx context.Context,
+	operation, claimed string,
+	payload interface{},
+	keys, entryIds []string,
+) {
+	digest, err := Digest(payload)
+	if err != nil {
+		ar.logger.Errorf("error in recording %s in audit log %s", operation, err)
+
+		return
+	}
+	ar.add(ctx, &model.AuditEntry{
+		Operation: operation,
+		Actor:     auth.Actor(ctx, claimed),
+		Digest:    digest,
+		Keys:      keys,
+		EntryIds:  entryIds,
+	})
+}
+
+// add writes an audit entry, it is not aborted along with the request and a
+// failure is only logged as the mutation is already committed.
+func (ar *auditRepository) add(ctx context.Context, aent *model.AuditEntry) {
+	_, err := ar.AddAuditEntry(context.WithoutCancel(ctx), aent)
+	if err != nil {
+		ar.logger.Errorf("error in recording %s in audit log %s", aent.Operation, err)
+	}
+}
+
+func annoKeys(docs ...*model.AnnoDoc) ([]string, []string) {
+	keys := make([]string, 0)
+	entryIds := make([]string, 0)
+	for _, doc := range docs {
+		keys = append(keys, doc.Key)
+		entryIds = append(entryIds, doc.EnrtyId)
+	}
+
+	return keys, model.UniqueIds(entryIds)
+}
+
+// batchActor is the first curator claimed by the operations of a batch.
+func batchActor(ops []*model.BatchOperation) string {
+	for _, op := range ops {
+		switch {
+		case op.Create != nil && len(op.Create.CreatedBy) > 0:
+			return op.Create.CreatedBy
+		case op.Update != nil && len(op.Update.CreatedBy) > 0:
+			return op.Update.CreatedBy
+		case op.Delete != nil && len(op.Delete.DeletedBy) > 0:
+			return op.Delete.DeletedBy
+		case op.Group != nil && len(op.Group.CreatedBy) > 0:
+			return op.Group.CreatedBy
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,311 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dictyBase/go-genproto/dictybaseapis/annotation"
+	"github.com/dictyBase/modware-annotation/internal/app/auth"
+	"github.com/dictyBase/modware-annotation/internal/model"
+	"github.com/dictyBase/modware-annotation/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo records the audit entries and fails the calls it is told to.
+type fakeRepo struct {
+	repository.TaggedAnnotationRepository
+	entries  []*model.AuditEntry
+	auditErr error
+	callErr  error
+	bulk     *model.BulkResult
+}
+
+func (fr *fakeRepo) AddAuditEntry(
+	_ context.Context,
+	aent *model.AuditEntry,
+) (*model.AuditEntry, error) {
+	if fr.auditErr != nil {
+		return nil, fr.auditErr
+	}
+	fr.entries = append(fr.entries, aent)
+
+	return aent, nil
+}
+
+func (fr *fakeRepo) AddAnnotation(
+	_ context.Context,
+	_ *annotation.NewTaggedAnnotation,
+) (*model.AnnoDoc, error) {
+	if fr.callErr != nil {
+		return &model.AnnoDoc{}, fr.callErr
+	}
+
+	return newTestDoc(), nil
+}
+
+func (fr *fakeRepo) RemoveAnnotationGroup(_ context.Context, _ string) error {
+	return fr.callErr
+}
+
+func (fr *fakeRepo) AppendToAnnotationGroup(
+	_ context.Context,
+	groupID string,
+	_ ...string,
+) (*model.AnnoGroup, error) {
+	if fr.callErr != nil {
+		return &model.AnnoGroup{}, fr.callErr
+	}
+
+	return &model.AnnoGroup{
+		GroupId:  groupID,
+		AnnoDocs: []*model.AnnoDoc{newTestDoc()},
+	}, nil
+}
+
+func (fr *fakeRepo) BulkObsoleteAnnotations(
+	_ context.Context,
+	_ string,
+	_ *model.DeleteInfo,
+	_ bool,
+) (*model.BulkResult, error) {
+	return fr.bulk, fr.callErr
+}
+
+func (fr *fakeRepo) ApplyBatch(
+	_ context.Context,
+	ops []*model.BatchOperation,
+) ([]*model.BatchResult, error) {
+	if fr.callErr != nil {
+		return []*model.BatchResult{}, fr.callErr
+	}
+	res := make([]*model.BatchResult, 0)
+	for _, op := range ops {
+		res = append(res, &model.BatchResult{Action: op.Action, Id: op.Id})
+	}
+
+	return res, nil
+}
+
+func newTestDoc() *model.AnnoDoc {
+	mda := &model.AnnoDoc{EnrtyId: "DDB_G0267474"}
+	mda.Key = "4589"
+
+	return mda
+}
+
+func newTestAnnotation() *annotation.NewTaggedAnnotation {
+	return &annotation.NewTaggedAnnotation{
+		Data: &annotation.NewTaggedAnnotation_Data{
+			Type: "annotations",
+			Attributes: &annotation.NewTaggedAnnotationAttributes{
+				Value:     "ameboid",
+				CreatedBy: "claimed@gmail.com",
+				Tag:       "phenotype",
+				EntryId:   "DDB_G0267474",
+				Ontology:  "dicty_annotation",
+			},
+		},
+	}
+}
+
+func newTestRepo(frp *fakeRepo) (repository.TaggedAnnotationRepository, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+
+	return NewTaggedAnnotationRepo(frp, logrus.NewEntry(logger)), hook
+}
+
+func TestRecordActor(t *testing.T) {
+	t.Parallel()
+	curator := auth.NewContext(
+		context.Background(),
+		&auth.Identity{Subject: "pfey@gmail.com", Role: auth.RoleCurator},
+	)
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		call  func(context.Context, repository.TaggedAnnotationRepository) error
+		op    string
+		actor string
+	}{
+		{
+			name: "claimed curator without identity",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				_, err := repo.AddAnnotation(ctx, newTestAnnotation())
+
+				return err
+			},
+			op:    "AddAnnotation",
+			actor: "claimed@gmail.com",
+		},
+		{
+			name: "identity over the claimed curator",
+			ctx:  curator,
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				_, err := repo.AddAnnotation(ctx, newTestAnnotation())
+
+				return err
+			},
+			op:    "AddAnnotation",
+			actor: "pfey@gmail.com",
+		},
+		{
+			name: "identity of a group change",
+			ctx:  curator,
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				_, err := repo.AppendToAnnotationGroup(ctx, "g1", "4589")
+
+				return err
+			},
+			op:    "AppendToAnnotationGroup",
+			actor: "pfey@gmail.com",
+		},
+		{
+			name: "identity of a group removal",
+			ctx:  curator,
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				return repo.RemoveAnnotationGroup(ctx, "g1")
+			},
+			op:    "RemoveAnnotationGroup",
+			actor: "pfey@gmail.com",
+		},
+		{
+			name: "claimed curator of a batch without identity",
+			ctx:  context.Background(),
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				_, err := repo.ApplyBatch(ctx, []*model.BatchOperation{
+					{Action: model.BatchDelete, Id: "4589", Delete: &model.DeleteInfo{}},
+					{
+						Action: model.BatchUpdate,
+						Id:     "4590",
+						Update: &annotation.TaggedAnnotationUpdateAttributes{
+							Value:         "ameboid",
+							EditableValue: "ameboid",
+							CreatedBy:     "claimed@gmail.com",
+						},
+					},
+				})
+
+				return err
+			},
+			op:    "ApplyBatch",
+			actor: "claimed@gmail.com",
+		},
+		{
+			name: "identity of a batch",
+			ctx:  curator,
+			call: func(ctx context.Context, repo repository.TaggedAnnotationRepository) error {
+				_, err := repo.ApplyBatch(ctx, []*model.BatchOperation{
+					{
+						Action: model.BatchDelete,
+						Id:     "4589",
+						Delete: &model.DeleteInfo{DeletedBy: "claimed@gmail.com"},
+					},
+				})
+
+				return err
+			},
+			op:    "ApplyBatch",
+			actor: "pfey@gmail.com",
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			frp := &fakeRepo{}
+			repo, _ := newTestRepo(frp)
+			assert.NoError(tst.call(tst.ctx, repo), "expect no error from the call")
+			assert.Len(frp.entries, 1, "should record one entry")
+			assert.Equal(tst.op, frp.entries[0].Operation, "should match the operation")
+			assert.Equal(tst.actor, frp.entries[0].Actor, "should match the actor")
+			assert.Len(frp.entries[0].Digest, 64, "should record the sha256 digest")
+		})
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	frp := &fakeRepo{auditErr: errors.New("audit collection is gone")}
+	repo, hook := newTestRepo(frp)
+	mda, err := repo.AddAnnotation(context.Background(), newTestAnnotation())
+	assert.NoError(err, "expect no error when only the audit entry fails")
+	assert.Equal("4589", mda.Key, "should return the committed annotation")
+	assert.Len(hook.Entries, 1, "should log the audit failure")
+	assert.Equal(logrus.ErrorLevel, hook.LastEntry().Level, "should log as error")
+	assert.Contains(hook.LastEntry().Message, "AddAnnotation", "should log the operation")
+}
+
+func TestNoRecordOfFailedCall(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	frp := &fakeRepo{callErr: errors.New("conflict")}
+	repo, hook := newTestRepo(frp)
+	_, err := repo.AddAnnotation(context.Background(), newTestAnnotation())
+	assert.Error(err, "expect error from the call")
+	err = repo.RemoveAnnotationGroup(context.Background(), "g1")
+	assert.Error(err, "expect error from the call")
+	assert.Empty(frp.entries, "should not record failed calls")
+	assert.Empty(hook.Entries, "should not log anything")
+}
+
+func TestRecordBulkObsolete(t *testing.T) {
+	t.Parallel()
+	info := &model.DeleteInfo{DeletedBy: "pfey@gmail.com", Reason: "retracted paper"}
+	tests := []struct {
+		name    string
+		bulk    *model.BulkResult
+		callErr error
+		dryRun  bool
+		keys    []string
+	}{
+		{
+			name: "all chunks",
+			bulk: &model.BulkResult{Count: 2, Ids: []string{"1", "2"}},
+			keys: []string{"1", "2"},
+		},
+		{
+			name:    "chunks before a failure",
+			bulk:    &model.BulkResult{Count: 1, Ids: []string{"1"}},
+			callErr: errors.New("transaction aborted"),
+			keys:    []string{"1"},
+		},
+		{
+			name:    "failure of the first chunk",
+			bulk:    &model.BulkResult{},
+			callErr: errors.New("transaction aborted"),
+		},
+		{
+			name:   "dry run",
+			bulk:   &model.BulkResult{DryRun: true, Count: 2, Ids: []string{"1", "2"}},
+			dryRun: true,
+		},
+	}
+	for _, tst := range tests {
+		tst := tst
+		t.Run(tst.name, func(t *testing.T) {
+			t.Parallel()
+			assert := require.New(t)
+			frp := &fakeRepo{bulk: tst.bulk, callErr: tst.callErr}
+			repo, _ := newTestRepo(frp)
+			_, err := repo.BulkObsoleteAnnotations(
+				context.Background(),
+				"tag === 'phenotype'", info, tst.dryRun,
+			)
+			assert.ErrorIs(err, tst.callErr, "should return the error of the call")
+			if len(tst.keys) == 0 {
+				assert.Empty(frp.entries, "should record nothing")
+
+				return
+			}
+			assert.Len(frp.entries, 1, "should record one entry")
+			assert.Equal(tst.keys, frp.entries[0].Keys, "should record the committed keys")
+			assert.Equal(info.DeletedBy, frp.entries[0].Actor, "should match the actor")
+		})
+	}
+}
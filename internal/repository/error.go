@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -21,19 +22,111 @@ func (ge *GroupNotFoundError) Error() string {
 }
 
 func IsAnnotationNotFound(err error) bool {
-	if _, ok := err.(*AnnoNotFoundError); ok {
-		return true
-	}
+	var target *AnnoNotFoundError
 
-	return false
+	return errors.As(err, &target)
 }
 
 func IsGroupNotFound(err error) bool {
-	if _, ok := err.(*GroupNotFoundError); ok {
-		return true
-	}
+	var target *GroupNotFoundError
 
-	return false
+	return errors.As(err, &target)
+}
+
+// AnnoConflictError is a stale edit of an annotation that already has a
+// newer version.
+type AnnoConflictError struct {
+	Id     string
+	Reason string
+}
+
+func (ac *AnnoConflictError) Error() string {
+	return fmt.Sprintf("conflicting edit of annotation id %s, %s", ac.Id, ac.Reason)
+}
+
+func IsAnnotationConflict(err error) bool {
+	var target *AnnoConflictError
+
+	return errors.As(err, &target)
+}
+
+// AnnoSlotTakenError is a restore of an annotation whose entry, rank, tag
+// and ontology are used by another live annotation.
+type AnnoSlotTakenError struct {
+	Id     string
+	LiveId string
+}
+
+func (as *AnnoSlotTakenError) Error() string {
+	return fmt.Sprintf(
+		"annotation id %s cannot be restored, its slot is taken by annotation id %s",
+		as.Id, as.LiveId,
+	)
+}
+
+func IsAnnotationSlotTaken(err error) bool {
+	var target *AnnoSlotTakenError
+
+	return errors.As(err, &target)
+}
+
+// BatchError is the failure of an operation that rolled back a batch.
+type BatchError struct {
+	Index  int
+	Reason string
+}
+
+func (be *BatchError) Error() string {
+	return fmt.Sprintf("error in batch operation %d, %s", be.Index, be.Reason)
+}
+
+func IsBatchError(err error) bool {
+	var target *BatchError
+
+	return errors.As(err, &target)
+}
+
+type ObsoleteMemberError struct {
+	Id string
+}
+
+func (oe *ObsoleteMemberError) Error() string {
+	return fmt.Sprintf("annotation id %s is obsolete and cannot be a group member", oe.Id)
+}
+
+func IsObsoleteMember(err error) bool {
+	var target *ObsoleteMemberError
+
+	return errors.As(err, &target)
+}
+
+type GroupTypeNotFoundError struct {
+	Ontology string
+	Tag      string
+}
+
+func (gte *GroupTypeNotFoundError) Error() string {
+	return fmt.Sprintf("group type %s in ontology %s not found", gte.Tag, gte.Ontology)
+}
+
+func IsGroupTypeNotFound(err error) bool {
+	var target *GroupTypeNotFoundError
+
+	return errors.As(err, &target)
+}
+
+type GroupRuleError struct {
+	Reason string
+}
+
+func (gre *GroupRuleError) Error() string {
+	return fmt.Sprintf("group violates the rules of its type, %s", gre.Reason)
+}
+
+func IsGroupRuleViolation(err error) bool {
+	var target *GroupRuleError
+
+	return errors.As(err, &target)
 }
 
 type AnnoListNotFoundError struct{}
@@ -43,11 +136,9 @@ func (al *AnnoListNotFoundError) Error() string {
 }
 
 func IsAnnotationListNotFound(err error) bool {
-	if _, ok := err.(*AnnoListNotFoundError); ok {
-		return true
-	}
+	var target *AnnoListNotFoundError
 
-	return false
+	return errors.As(err, &target)
 }
 
 type AnnoGroupListNotFoundError struct{}
@@ -57,11 +148,9 @@ func (agl *AnnoGroupListNotFoundError) Error() string {
 }
 
 func IsAnnotationGroupListNotFound(err error) bool {
-	if _, ok := err.(*AnnoGroupListNotFoundError); ok {
-		return true
-	}
+	var target *AnnoGroupListNotFoundError
 
-	return false
+	return errors.As(err, &target)
 }
 
 type AnnoTagNotFoundError struct {
@@ -73,9 +162,7 @@ func (at *AnnoTagNotFoundError) Error() string {
 }
 
 func IsAnnoTagNotFound(err error) bool {
-	if _, ok := err.(*AnnoTagNotFoundError); ok {
-		return true
-	}
+	var target *AnnoTagNotFoundError
 
-	return false
+	return errors.As(err, &target)
 }
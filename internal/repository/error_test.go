@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinedErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	publish := errors.New("error in publishing")
+	conflict := errors.Join(&AnnoConflictError{Id: "4589", Reason: "stale"}, publish)
+	assert.True(IsAnnotationConflict(conflict), "should find the conflict joined with another error")
+	assert.False(IsAnnotationNotFound(conflict), "should not find another error")
+	wrapped := fmt.Errorf("error in bulk edit %w", &AnnoNotFoundError{Id: "4589"})
+	assert.True(IsAnnotationNotFound(wrapped), "should find a wrapped error")
+	assert.False(IsAnnotationConflict(publish), "should not classify a plain error")
+	assert.False(IsAnnotationConflict(nil), "should not classify a nil error")
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"io"
 
 	manager "github.com/dictyBase/arangomanager"
@@ -10,34 +11,104 @@ import (
 )
 
 // TaggedAnnotationRepository is an interface for accessing annotation
-// data from its data sources.
+// data from its data sources. Every call is bound to the context of the
+// request, a cancelled or expired context aborts the running queries.
 type TaggedAnnotationRepository interface {
 	// GetAnnotationById retrieves an annotation
-	GetAnnotationByID(id string) (*model.AnnoDoc, error)
-	GetAnnotationByEntry(req *annotation.EntryAnnotationRequest) (*model.AnnoDoc, error)
-	AddAnnotation(na *annotation.NewTaggedAnnotation) (*model.AnnoDoc, error)
-	EditAnnotation(ua *annotation.TaggedAnnotationUpdate) (*model.AnnoDoc, error)
-	RemoveAnnotation(id string, purge bool) error
+	GetAnnotationByID(ctx context.Context, id string) (*model.AnnoDoc, error)
+	GetAnnotationByEntry(ctx context.Context, req *annotation.EntryAnnotationRequest) (*model.AnnoDoc, error)
+	// GetEntryProfile retrieves all live annotations of an entry grouped
+	// by ontology and tag
+	GetEntryProfile(ctx context.Context, entryID string) (*model.EntryProfile, error)
+	AddAnnotation(ctx context.Context, na *annotation.NewTaggedAnnotation) (*model.AnnoDoc, error)
+	EditAnnotation(ctx context.Context, ua *annotation.TaggedAnnotationUpdate) (*model.AnnoDoc, error)
+	// EditAnnotationAtVersion edits an annotation only if it is still at
+	// the expected version
+	EditAnnotationAtVersion(ctx context.Context, ua *annotation.TaggedAnnotationUpdate, version int64) (*model.AnnoDoc, error)
+	RemoveAnnotation(ctx context.Context, id string, purge bool) error
+	// RemoveAnnotationBy removes an annotation and records who removed
+	// it and why
+	RemoveAnnotationBy(ctx context.Context, id string, purge bool, info *model.DeleteInfo) (*model.AnnoDoc, error)
+	// RestoreAnnotation brings back an obsolete annotation
+	RestoreAnnotation(ctx context.Context, id, restoredBy string) (*model.AnnoDoc, error)
 	// ListAnnotationGroup provides a paginated list of annotation along
 	// with optional filtering
-	ListAnnotations(cursor int64, limit int64, filter string) ([]*model.AnnoDoc, error)
-	ClearAnnotations() error
-	Clear() error
+	ListAnnotations(ctx context.Context, cursor int64, limit int64, filter string) ([]*model.AnnoDoc, error)
+	// ListObsoleteAnnotations provides a paginated list of obsolete
+	// annotations along with optional filtering
+	ListObsoleteAnnotations(ctx context.Context, cursor int64, limit int64, filter string) ([]*model.AnnoDoc, error)
+	ClearAnnotations(ctx context.Context) error
+	Clear(ctx context.Context) error
 	// AddAnnotationGroup creates a new annotation group
-	AddAnnotationGroup(idslice ...string) (*model.AnnoGroup, error)
+	AddAnnotationGroup(ctx context.Context, idslice ...string) (*model.AnnoGroup, error)
+	// AddTypedAnnotationGroup creates a new annotation group with a type,
+	// name and description
+	AddTypedAnnotationGroup(ctx context.Context, params *model.GroupParams, idslice ...string) (*model.AnnoGroup, error)
+	// EditAnnotationGroup changes the name and description of a group
+	EditAnnotationGroup(ctx context.Context, groupID, name, description, updatedBy string) (*model.AnnoGroup, error)
+	// MoveAnnotationGroupMember moves a member of a group to a position
+	MoveAnnotationGroupMember(ctx context.Context, groupID, annoID string, position int64) (*model.AnnoGroup, error)
+	// ReorderAnnotationGroup places members at the start of a group in the given order
+	ReorderAnnotationGroup(ctx context.Context, groupID string, idslice ...string) (*model.AnnoGroup, error)
+	// BulkEditAnnotations creates new versions of all live annotations
+	// matching a filter
+	BulkEditAnnotations(ctx context.Context, filter string, edit *model.BulkEdit, dryRun bool) (*model.BulkResult, error)
+	// BulkObsoleteAnnotations obsoletes all live annotations matching a
+	// filter and records who removed them and why
+	BulkObsoleteAnnotations(ctx context.Context, filter string, info *model.DeleteInfo, dryRun bool) (*model.BulkResult, error)
+	// ApplyBatch runs a list of operations atomically in the given order
+	ApplyBatch(ctx context.Context, ops []*model.BatchOperation) ([]*model.BatchResult, error)
+	// ListGroupsByAnnotation retrieves all groups containing an annotation
+	ListGroupsByAnnotation(ctx context.Context, annoID string) ([]*model.AnnoGroup, error)
+	// ListGroupsByAnnotations retrieves all groups containing any of the
+	// annotations
+	ListGroupsByAnnotations(ctx context.Context, annoIDs []string) ([]*model.AnnoGroup, error)
+	// ListGroupsByEntry retrieves all groups containing any annotation of an entry
+	ListGroupsByEntry(ctx context.Context, entryID string) ([]*model.AnnoGroup, error)
+	// ListAnnotationGroupVersions retrieves the membership history of a group
+	ListAnnotationGroupVersions(ctx context.Context, groupID string) ([]*model.GroupVersion, error)
+	// GetAnnotationGroupVersion retrieves the membership of a group at a version
+	GetAnnotationGroupVersion(ctx context.Context, groupID string, version int64) (*model.GroupVersion, error)
+	// SetGroupType creates or updates a group type along with its rules
+	SetGroupType(ctx context.Context, gtp *model.GroupType) (*model.GroupType, error)
+	// GetGroupType retrieves a group type
+	GetGroupType(ctx context.Context, ontology, tag string) (*model.GroupType, error)
 	// GetAnnotationGroup retrieves an annotation group
-	GetAnnotationGroup(groupID string) (*model.AnnoGroup, error)
+	GetAnnotationGroup(ctx context.Context, groupID string) (*model.AnnoGroup, error)
 	// AppendToAnnotationGroup adds new annotations to an existing group
-	AppendToAnnotationGroup(groupID string, idslice ...string) (*model.AnnoGroup, error)
+	AppendToAnnotationGroup(ctx context.Context, groupID string, idslice ...string) (*model.AnnoGroup, error)
 	// DeleteAnnotationGroup deletes an annotation group
-	RemoveAnnotationGroup(groupID string) error
+	RemoveAnnotationGroup(ctx context.Context, groupID string) error
 	// RemoveFromAnnotationGroup remove annotations from an existing group
-	RemoveFromAnnotationGroup(groupID string, idslice ...string) (*model.AnnoGroup, error)
+	RemoveFromAnnotationGroup(ctx context.Context, groupID string, idslice ...string) (*model.AnnoGroup, error)
 	// ListAnnotationGroup provides a paginated list of annotation groups along
 	// with optional filtering
-	ListAnnotationGroup(cursor, limit int64, filter string) ([]*model.AnnoGroup, error)
+	ListAnnotationGroup(ctx context.Context, cursor, limit int64, filter string) ([]*model.AnnoGroup, error)
+	// ListAnnotationGroupByType provides a paginated list of annotation
+	// groups of a group type along with optional filtering
+	ListAnnotationGroupByType(ctx context.Context, cursor, limit int64, filter, ontology, tag string) ([]*model.AnnoGroup, error)
+	// DanglingReferences reports group members, tag edges and version edges
+	// that refer to annotations that no longer exist, removing them when
+	// repair is true
+	DanglingReferences(ctx context.Context, repair bool) (*model.DanglingReport, error)
+	// CountByOntology counts the live annotations and the groups of every
+	// ontology
+	CountByOntology(ctx context.Context) ([]*model.OntologyCount, error)
 	// GetAnnotationTag retrieves tag information
-	GetAnnotationTag(name, ontology string) (*model.AnnoTag, error)
+	GetAnnotationTag(ctx context.Context, name, ontology string) (*model.AnnoTag, error)
+	// GetAnnotationTags retrieves the information of many tags in one
+	// query, unknown tags are left out
+	GetAnnotationTags(ctx context.Context, keys []*model.TagKey) ([]*model.AnnoTag, error)
+	// GetOntologies retrieves the ontologies of the given namespaces,
+	// unknown namespaces are left out
+	GetOntologies(ctx context.Context, namespaces []string) ([]*model.Ontology, error)
+	// AddAuditEntry appends an entry to the audit log
+	AddAuditEntry(ctx context.Context, entry *model.AuditEntry) (*model.AuditEntry, error)
+	// ListAuditEntries provides a paginated list of audit entries, newest
+	// first, filtered by actor, entry id and time
+	ListAuditEntries(ctx context.Context, filter *model.AuditFilter) ([]*model.AuditEntry, error)
 	Dbh() *manager.Database
-	LoadOboJSON(r io.Reader) (*storage.UploadInformation, error)
+	// Close releases the connection to the data source
+	Close() error
+	LoadOboJSON(ctx context.Context, r io.Reader) (*storage.UploadInformation, error)
 }
@@ -0,0 +1,102 @@
+// Package tracing configures the opentelemetry tracer provider shared by the
+// grpc server, the repository and the publisher.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// ServiceName identifies the spans of this service.
+const ServiceName = "modware-annotation"
+
+// Exporters are the accepted names of span exporters, none disables tracing.
+var Exporters = []string{"none", "stdout", "otlp"}
+
+// ShutdownFunc flushes the pending spans and stops the exporter.
+type ShutdownFunc func(context.Context) error
+
+// Params configures the export of spans.
+type Params struct {
+	// Exporter is one of Exporters
+	Exporter string
+	// Endpoint is the host:port of the otlp grpc collector, the exporter
+	// falls back to its environment variables when empty
+	Endpoint string
+	// Insecure disables TLS to the otlp collector
+	Insecure bool
+}
+
+// Setup installs the global tracer provider and the w3c trace context
+// propagator. Spans are dropped when the exporter is none.
+func Setup(ctx context.Context, params *Params) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{}, propagation.Baggage{},
+		),
+	)
+	exp, err := newExporter(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+	tpr := NewTracerProvider(sdktrace.WithBatcher(exp))
+	otel.SetTracerProvider(tpr)
+
+	return tpr.Shutdown, nil
+}
+
+// NewTracerProvider creates a tracer provider describing this service, the
+// options set the span processors and the sampler.
+func NewTracerProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(append(
+		[]sdktrace.TracerProviderOption{
+			sdktrace.WithResource(resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceName(ServiceName),
+			)),
+		},
+		opts...,
+	)...)
+}
+
+func newExporter(ctx context.Context, params *Params) (sdktrace.SpanExporter, error) {
+	switch params.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, fmt.Errorf("error in creating stdout span exporter %s", err)
+		}
+
+		return exp, nil
+	case "otlp":
+		var opts []otlptracegrpc.Option
+		if len(params.Endpoint) > 0 {
+			opts = append(opts, otlptracegrpc.WithEndpoint(params.Endpoint))
+		}
+		if params.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error in creating otlp span exporter %s", err)
+		}
+
+		return exp, nil
+	}
+
+	return nil, fmt.Errorf("unknown span exporter %s", params.Exporter)
+}